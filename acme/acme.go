@@ -13,11 +13,20 @@ import (
 	"github.com/pritunl/pritunl-cloud/database"
 	"github.com/pritunl/pritunl-cloud/errortypes"
 	"github.com/pritunl/pritunl-cloud/settings"
+	"github.com/pritunl/pritunl-cloud/webhook"
 	"golang.org/x/crypto/acme"
+	"gopkg.in/mgo.v2/bson"
 	"strings"
 	"time"
 )
 
+type renewData struct {
+	Certificate bson.ObjectId     `json:"certificate"`
+	Name        string            `json:"name"`
+	Domains     []string          `json:"domains"`
+	Info        *certificate.Info `json:"info"`
+}
+
 func Generate(db *database.Database, cert *certificate.Certificate) (
 	err error) {
 
@@ -206,6 +215,16 @@ func Generate(db *database.Database, cert *certificate.Certificate) (
 		return
 	}
 
+	err = webhook.Publish(db, "", webhook.CertificateRenew, &renewData{
+		Certificate: cert.Id,
+		Name:        cert.Name,
+		Domains:     cert.AcmeDomains,
+		Info:        cert.Info,
+	})
+	if err != nil {
+		return
+	}
+
 	return
 }
 
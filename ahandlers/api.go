@@ -0,0 +1,655 @@
+package ahandlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-cloud/audit"
+	"github.com/pritunl/pritunl-cloud/authority"
+	"github.com/pritunl/pritunl-cloud/certificate"
+	"github.com/pritunl/pritunl-cloud/datacenter"
+	"github.com/pritunl/pritunl-cloud/device"
+	"github.com/pritunl/pritunl-cloud/disk"
+	"github.com/pritunl/pritunl-cloud/domain"
+	"github.com/pritunl/pritunl-cloud/event"
+	"github.com/pritunl/pritunl-cloud/firewall"
+	"github.com/pritunl/pritunl-cloud/group"
+	"github.com/pritunl/pritunl-cloud/image"
+	"github.com/pritunl/pritunl-cloud/instance"
+	"github.com/pritunl/pritunl-cloud/log"
+	"github.com/pritunl/pritunl-cloud/meter"
+	"github.com/pritunl/pritunl-cloud/metric"
+	"github.com/pritunl/pritunl-cloud/node"
+	"github.com/pritunl/pritunl-cloud/openapi"
+	"github.com/pritunl/pritunl-cloud/organization"
+	"github.com/pritunl/pritunl-cloud/policy"
+	"github.com/pritunl/pritunl-cloud/quota"
+	"github.com/pritunl/pritunl-cloud/session"
+	"github.com/pritunl/pritunl-cloud/spec"
+	"github.com/pritunl/pritunl-cloud/storage"
+	"github.com/pritunl/pritunl-cloud/template"
+	"github.com/pritunl/pritunl-cloud/upload"
+	"github.com/pritunl/pritunl-cloud/user"
+	"github.com/pritunl/pritunl-cloud/vpc"
+	"github.com/pritunl/pritunl-cloud/webhook"
+	"github.com/pritunl/pritunl-cloud/zone"
+	"gopkg.in/mgo.v2/bson"
+)
+
+var (
+	apiDoc  *openapi.Document
+	apiIds  = []bson.ObjectId{}
+	apiPage = []string{"page", "page_count", "id", "name", "organization"}
+)
+
+func registerApi(api *openapi.Router) {
+	api.GET("/audit", auditsSearchGet, &openapi.Op{
+		Summary: "Search audit events",
+		Query: []string{
+			"page", "page_count", "user", "type", "ip", "start", "end",
+		},
+		Response: auditsData{},
+	})
+	api.GET("/audit/:user_id", auditsGet, &openapi.Op{
+		Summary:  "List user audit events",
+		Query:    []string{"page", "page_count"},
+		Response: auditsData{},
+	})
+	api.GET("/audit_verify", auditVerifyGet, &openapi.Op{
+		Summary:  "Verify audit hash chain",
+		Response: audit.Verification{},
+	})
+
+	api.GET("/authority", authoritiesGet, &openapi.Op{
+		Summary:  "List authorities",
+		Query:    append(apiPage, "network_role", "role"),
+		Response: authoritiesData{},
+	})
+	api.GET("/authority/:authority_id", authorityGet, &openapi.Op{
+		Summary:  "Get authority",
+		Response: authority.Authority{},
+	})
+	api.PUT("/authority/:authority_id", authorityPut, &openapi.Op{
+		Summary:  "Update authority",
+		Request:  authorityData{},
+		Response: authority.Authority{},
+	})
+	api.POST("/authority", authorityPost, &openapi.Op{
+		Summary:  "Create authority",
+		Request:  authorityData{},
+		Response: authority.Authority{},
+	})
+	api.DELETE("/authority", authoritiesDelete, &openapi.Op{
+		Summary: "Delete authorities",
+		Request: apiIds,
+	})
+	api.DELETE("/authority/:authority_id", authorityDelete, &openapi.Op{
+		Summary: "Delete authority",
+	})
+
+	api.GET("/certificate", certificatesGet, &openapi.Op{
+		Summary:  "List certificates",
+		Response: []*certificate.Certificate{},
+	})
+	api.GET("/certificate/:cert_id", certificateGet, &openapi.Op{
+		Summary:  "Get certificate",
+		Response: certificate.Certificate{},
+	})
+	api.PUT("/certificate/:cert_id", certificatePut, &openapi.Op{
+		Summary:  "Update certificate",
+		Request:  certificateData{},
+		Response: certificate.Certificate{},
+	})
+	api.POST("/certificate", certificatePost, &openapi.Op{
+		Summary:  "Create certificate",
+		Request:  certificateData{},
+		Response: certificate.Certificate{},
+	})
+	api.DELETE("/certificate/:cert_id", certificateDelete, &openapi.Op{
+		Summary: "Delete certificate",
+	})
+
+	api.GET("/datacenter", datacentersGet, &openapi.Op{
+		Summary:  "List datacenters",
+		Response: []*datacenter.Datacenter{},
+	})
+	api.GET("/datacenter/:dc_id", datacenterGet, &openapi.Op{
+		Summary:  "Get datacenter",
+		Response: datacenter.Datacenter{},
+	})
+	api.PUT("/datacenter/:dc_id", datacenterPut, &openapi.Op{
+		Summary:  "Update datacenter",
+		Request:  datacenterData{},
+		Response: datacenter.Datacenter{},
+	})
+	api.POST("/datacenter", datacenterPost, &openapi.Op{
+		Summary:  "Create datacenter",
+		Request:  datacenterData{},
+		Response: datacenter.Datacenter{},
+	})
+	api.DELETE("/datacenter/:dc_id", datacenterDelete, &openapi.Op{
+		Summary: "Delete datacenter",
+	})
+
+	api.GET("/device/:user_id", devicesGet, &openapi.Op{
+		Summary:  "List user devices",
+		Response: []*device.Device{},
+	})
+	api.PUT("/device/:device_id", devicePut, &openapi.Op{
+		Summary:  "Update device",
+		Request:  deviceData{},
+		Response: device.Device{},
+	})
+	api.POST("/device", devicePost, &openapi.Op{
+		Summary:  "Create device",
+		Request:  deviceData{},
+		Response: device.Device{},
+	})
+	api.DELETE("/device/:device_id", deviceDelete, &openapi.Op{
+		Summary: "Delete device",
+	})
+
+	api.GET("/disk", disksGet, &openapi.Op{
+		Summary:  "List disks",
+		Query:    append(apiPage, "instance"),
+		Response: disksData{},
+	})
+	api.GET("/disk/:disk_id", diskGet, &openapi.Op{
+		Summary:  "Get disk",
+		Response: disk.Disk{},
+	})
+	api.PUT("/disk", disksPut, &openapi.Op{
+		Summary: "Update disks state",
+		Request: disksMultiData{},
+	})
+	api.PUT("/disk/:disk_id", diskPut, &openapi.Op{
+		Summary:  "Update disk",
+		Request:  diskData{},
+		Response: disk.Disk{},
+	})
+	api.POST("/disk", diskPost, &openapi.Op{
+		Summary:  "Create disk",
+		Request:  diskData{},
+		Response: disk.Disk{},
+	})
+	api.DELETE("/disk", disksDelete, &openapi.Op{
+		Summary: "Delete disks",
+		Query:   []string{"force"},
+		Request: apiIds,
+	})
+	api.DELETE("/disk/:disk_id", diskDelete, &openapi.Op{
+		Summary: "Delete disk",
+	})
+
+	api.GET("/domain", domainsGet, &openapi.Op{
+		Summary:  "List domains",
+		Query:    append(apiPage, "names"),
+		Response: domainsData{},
+	})
+	api.GET("/domain/:domain_id", domainGet, &openapi.Op{
+		Summary:  "Get domain",
+		Response: domain.Domain{},
+	})
+	api.PUT("/domain/:domain_id", domainPut, &openapi.Op{
+		Summary:  "Update domain",
+		Request:  domainData{},
+		Response: domain.Domain{},
+	})
+	api.POST("/domain", domainPost, &openapi.Op{
+		Summary:  "Create domain",
+		Request:  domainData{},
+		Response: domain.Domain{},
+	})
+	api.DELETE("/domain", domainsDelete, &openapi.Op{
+		Summary: "Delete domains",
+		Request: apiIds,
+	})
+	api.DELETE("/domain/:domain_id", domainDelete, &openapi.Op{
+		Summary: "Delete domain",
+	})
+
+	api.GET("/event", eventGet, &openapi.Op{
+		Summary:  "Event websocket",
+		Response: event.Event{},
+	})
+
+	api.GET("/firewall", firewallsGet, &openapi.Op{
+		Summary:  "List firewalls",
+		Query:    append(apiPage, "network_role"),
+		Response: firewallsData{},
+	})
+	api.GET("/firewall/:firewall_id", firewallGet, &openapi.Op{
+		Summary:  "Get firewall",
+		Response: firewall.Firewall{},
+	})
+	api.PUT("/firewall/:firewall_id", firewallPut, &openapi.Op{
+		Summary:  "Update firewall",
+		Request:  firewallData{},
+		Response: firewall.Firewall{},
+	})
+	api.POST("/firewall", firewallPost, &openapi.Op{
+		Summary:  "Create firewall",
+		Request:  firewallData{},
+		Response: firewall.Firewall{},
+	})
+	api.DELETE("/firewall", firewallsDelete, &openapi.Op{
+		Summary: "Delete firewalls",
+		Request: apiIds,
+	})
+	api.DELETE("/firewall/:firewall_id", firewallDelete, &openapi.Op{
+		Summary: "Delete firewall",
+	})
+
+	api.GET("/image", imagesGet, &openapi.Op{
+		Summary:  "List images",
+		Query:    append(apiPage, "datacenter", "type"),
+		Response: imagesData{},
+	})
+	api.GET("/image/:image_id", imageGet, &openapi.Op{
+		Summary:  "Get image",
+		Response: image.Image{},
+	})
+	api.PUT("/image/:image_id", imagePut, &openapi.Op{
+		Summary:  "Update image",
+		Request:  imageData{},
+		Response: image.Image{},
+	})
+	api.DELETE("/image", imagesDelete, &openapi.Op{
+		Summary: "Delete images",
+		Request: apiIds,
+	})
+	api.DELETE("/image/:image_id", imageDelete, &openapi.Op{
+		Summary: "Delete image",
+	})
+
+	api.GET("/image_upload", uploadsGet, &openapi.Op{
+		Summary:  "List image uploads and imports",
+		Query:    []string{"organization"},
+		Response: []*upload.Upload{},
+	})
+	api.GET("/image_upload/:upload_id", uploadGet, &openapi.Op{
+		Summary:  "Get image upload",
+		Response: upload.Upload{},
+	})
+	api.PUT("/image_upload/:upload_id", uploadPut, &openapi.Op{
+		Summary:  "Write image upload chunk at offset",
+		Query:    []string{"offset"},
+		Binary:   true,
+		Response: upload.Upload{},
+	})
+	api.POST("/image_upload", uploadPost, &openapi.Op{
+		Summary:  "Create image upload",
+		Request:  uploadData{},
+		Response: upload.Upload{},
+	})
+	api.DELETE("/image_upload/:upload_id", uploadDelete, &openapi.Op{
+		Summary: "Delete image upload",
+	})
+	api.POST("/image_import", importPost, &openapi.Op{
+		Summary:  "Import image from URL",
+		Request:  uploadData{},
+		Response: upload.Upload{},
+	})
+
+	api.GET("/instance", instancesGet, &openapi.Op{
+		Summary:  "List instances",
+		Query:    append(apiPage, "network_role", "group", "node"),
+		Response: instancesData{},
+	})
+	api.PUT("/instance", instancesPut, &openapi.Op{
+		Summary: "Update instances state",
+		Request: instanceMultiData{},
+	})
+	api.GET("/instance/:instance_id", instanceGet, &openapi.Op{
+		Summary:  "Get instance",
+		Response: instance.Instance{},
+	})
+	api.PUT("/instance/:instance_id", instancePut, &openapi.Op{
+		Summary:  "Update instance",
+		Request:  instanceData{},
+		Response: instance.Instance{},
+	})
+	api.POST("/instance", instancePost, &openapi.Op{
+		Summary:  "Create instance, returns an array when count > 1",
+		Request:  instanceData{},
+		Response: instance.Instance{},
+	})
+	api.DELETE("/instance", instancesDelete, &openapi.Op{
+		Summary: "Delete instances",
+		Query:   []string{"force"},
+		Request: apiIds,
+	})
+	api.DELETE("/instance/:instance_id", instanceDelete, &openapi.Op{
+		Summary: "Delete instance",
+	})
+
+	api.GET("/log", logsGet, &openapi.Op{
+		Summary:  "List logs",
+		Query:    []string{"page", "page_count", "level", "message"},
+		Response: logsData{},
+	})
+	api.GET("/log/:log_id", logGet, &openapi.Op{
+		Summary:  "Get log entry",
+		Response: log.Entry{},
+	})
+
+	api.GET("/meter", meterGet, &openapi.Op{
+		Summary:  "Get usage report",
+		Query:    []string{"organization", "start", "end", "format"},
+		Response: []*meter.Report{},
+	})
+
+	api.GET("/metric/node/:node_id", metricNodeGet, &openapi.Op{
+		Summary:  "Get node metrics",
+		Query:    []string{"resolution", "start", "end"},
+		Response: []*metric.Point{},
+	})
+	api.GET("/metric/instance/:instance_id", metricInstanceGet, &openapi.Op{
+		Summary:  "Get instance metrics",
+		Query:    []string{"resolution", "start", "end"},
+		Response: []*metric.Point{},
+	})
+
+	api.GET("/node", nodesGet, &openapi.Op{
+		Summary: "List nodes",
+		Query: []string{
+			"page", "page_count", "id", "name", "names", "network_role",
+			"zone",
+		},
+		Response: nodesData{},
+	})
+	api.GET("/node/:node_id", nodeGet, &openapi.Op{
+		Summary:  "Get node",
+		Response: node.Node{},
+	})
+	api.GET("/node/:node_id/cache", nodeCacheGet, &openapi.Op{
+		Summary:  "List node image cache",
+		Response: []*node.CacheImage{},
+	})
+	api.PUT("/node/:node_id", nodePut, &openapi.Op{
+		Summary:  "Update node",
+		Request:  nodeData{},
+		Response: node.Node{},
+	})
+	api.DELETE("/node/:node_id", nodeDelete, &openapi.Op{
+		Summary: "Delete node",
+	})
+
+	api.GET("/organization", organizationsGet, &openapi.Op{
+		Summary:  "List organizations",
+		Response: []*organization.Organization{},
+	})
+	api.GET("/organization/:org_id", organizationGet, &openapi.Op{
+		Summary:  "Get organization",
+		Response: organization.Organization{},
+	})
+	api.GET("/organization/:org_id/quota", organizationQuotaGet, &openapi.Op{
+		Summary:  "Get organization quota",
+		Response: quota.Report{},
+	})
+	api.GET("/organization/:org_id/spec", specGet, &openapi.Op{
+		Summary:  "Export organization spec, YAML unless format=json",
+		Query:    []string{"format"},
+		Response: spec.Spec{},
+	})
+	api.POST("/organization/:org_id/spec/plan", specPlanPost, &openapi.Op{
+		Summary:  "Plan organization spec",
+		Query:    []string{"prune"},
+		Request:  spec.Spec{},
+		Response: specChangesData{},
+	})
+	api.POST("/organization/:org_id/spec/apply", specApplyPost, &openapi.Op{
+		Summary:  "Apply organization spec",
+		Query:    []string{"prune"},
+		Request:  spec.Spec{},
+		Response: specChangesData{},
+	})
+	api.PUT("/organization/:org_id", organizationPut, &openapi.Op{
+		Summary:  "Update organization",
+		Request:  organizationData{},
+		Response: organization.Organization{},
+	})
+	api.POST("/organization", organizationPost, &openapi.Op{
+		Summary:  "Create organization",
+		Request:  organizationData{},
+		Response: organization.Organization{},
+	})
+	api.DELETE("/organization/:org_id", organizationDelete, &openapi.Op{
+		Summary: "Delete organization",
+	})
+
+	api.GET("/policy", policiesGet, &openapi.Op{
+		Summary:  "List policies",
+		Response: []*policy.Policy{},
+	})
+	api.GET("/policy/:policy_id", policyGet, &openapi.Op{
+		Summary:  "Get policy",
+		Response: policy.Policy{},
+	})
+	api.PUT("/policy/:policy_id", policyPut, &openapi.Op{
+		Summary:  "Update policy",
+		Request:  policyData{},
+		Response: policy.Policy{},
+	})
+	api.POST("/policy", policyPost, &openapi.Op{
+		Summary:  "Create policy",
+		Request:  policyData{},
+		Response: policy.Policy{},
+	})
+	api.DELETE("/policy/:policy_id", policyDelete, &openapi.Op{
+		Summary: "Delete policy",
+	})
+
+	api.GET("/session/:user_id", sessionsGet, &openapi.Op{
+		Summary:  "List user sessions",
+		Query:    []string{"show_removed"},
+		Response: []*session.Session{},
+	})
+	api.DELETE("/session/:session_id", sessionDelete, &openapi.Op{
+		Summary: "Delete session",
+	})
+
+	api.GET("/settings", settingsGet, &openapi.Op{
+		Summary:  "Get settings",
+		Response: settingsData{},
+	})
+	api.PUT("/settings", settingsPut, &openapi.Op{
+		Summary:  "Update settings",
+		Request:  settingsData{},
+		Response: settingsData{},
+	})
+
+	api.GET("/storage", storagesGet, &openapi.Op{
+		Summary:  "List storages",
+		Response: []*storage.Storage{},
+	})
+	api.GET("/storage/:store_id", storageGet, &openapi.Op{
+		Summary:  "Get storage",
+		Response: storage.Storage{},
+	})
+	api.PUT("/storage/:store_id", storagePut, &openapi.Op{
+		Summary:  "Update storage",
+		Request:  storageData{},
+		Response: storage.Storage{},
+	})
+	api.POST("/storage", storagePost, &openapi.Op{
+		Summary:  "Create storage",
+		Request:  storageData{},
+		Response: storage.Storage{},
+	})
+	api.DELETE("/storage/:store_id", storageDelete, &openapi.Op{
+		Summary: "Delete storage",
+	})
+
+	api.GET("/user", usersGet, &openapi.Op{
+		Summary: "List users",
+		Query: []string{
+			"page", "page_count", "id", "username", "role", "type",
+			"administrator", "disabled",
+		},
+		Response: usersData{},
+	})
+	api.GET("/user/:user_id", userGet, &openapi.Op{
+		Summary:  "Get user",
+		Response: user.User{},
+	})
+	api.PUT("/user/:user_id", userPut, &openapi.Op{
+		Summary:  "Update user",
+		Request:  userData{},
+		Response: user.User{},
+	})
+	api.POST("/user", userPost, &openapi.Op{
+		Summary:  "Create user",
+		Request:  userData{},
+		Response: user.User{},
+	})
+	api.DELETE("/user", usersDelete, &openapi.Op{
+		Summary: "Delete users",
+		Request: apiIds,
+	})
+
+	api.GET("/vpc", vpcsGet, &openapi.Op{
+		Summary:  "List VPCs",
+		Query:    append(apiPage, "names", "network", "datacenter"),
+		Response: vpcsData{},
+	})
+	api.GET("/vpc/:vpc_id", vpcGet, &openapi.Op{
+		Summary:  "Get VPC",
+		Response: vpc.Vpc{},
+	})
+	api.PUT("/vpc/:vpc_id", vpcPut, &openapi.Op{
+		Summary:  "Update VPC",
+		Request:  vpcData{},
+		Response: vpc.Vpc{},
+	})
+	api.POST("/vpc", vpcPost, &openapi.Op{
+		Summary:  "Create VPC",
+		Request:  vpcData{},
+		Response: vpc.Vpc{},
+	})
+	api.DELETE("/vpc", vpcsDelete, &openapi.Op{
+		Summary: "Delete VPCs",
+		Request: apiIds,
+	})
+	api.DELETE("/vpc/:vpc_id", vpcDelete, &openapi.Op{
+		Summary: "Delete VPC",
+	})
+
+	api.GET("/webhook", webhooksGet, &openapi.Op{
+		Summary:  "List webhooks",
+		Query:    apiPage,
+		Response: webhooksData{},
+	})
+	api.GET("/webhook/:webhook_id", webhookGet, &openapi.Op{
+		Summary:  "Get webhook",
+		Response: webhook.Webhook{},
+	})
+	api.GET("/webhook/:webhook_id/delivery", webhookDeliveriesGet, &openapi.Op{
+		Summary:  "List webhook deliveries",
+		Response: []*webhook.Delivery{},
+	})
+	api.PUT("/webhook/:webhook_id", webhookPut, &openapi.Op{
+		Summary:  "Update webhook",
+		Request:  webhookData{},
+		Response: webhook.Webhook{},
+	})
+	api.POST("/webhook", webhookPost, &openapi.Op{
+		Summary:  "Create webhook",
+		Request:  webhookData{},
+		Response: webhook.Webhook{},
+	})
+	api.DELETE("/webhook", webhooksDelete, &openapi.Op{
+		Summary: "Delete webhooks",
+		Request: apiIds,
+	})
+	api.DELETE("/webhook/:webhook_id", webhookDelete, &openapi.Op{
+		Summary: "Delete webhook",
+	})
+
+	api.GET("/group", groupsGet, &openapi.Op{
+		Summary:  "List instance groups",
+		Query:    apiPage,
+		Response: groupsData{},
+	})
+	api.GET("/group/:group_id", groupGet, &openapi.Op{
+		Summary:  "Get instance group",
+		Response: group.Group{},
+	})
+	api.PUT("/group/:group_id", groupPut, &openapi.Op{
+		Summary:  "Update instance group",
+		Request:  groupData{},
+		Response: group.Group{},
+	})
+	api.POST("/group", groupPost, &openapi.Op{
+		Summary:  "Create instance group",
+		Request:  groupData{},
+		Response: group.Group{},
+	})
+	api.DELETE("/group", groupsDelete, &openapi.Op{
+		Summary: "Delete instance groups",
+		Request: apiIds,
+	})
+	api.DELETE("/group/:group_id", groupDelete, &openapi.Op{
+		Summary: "Delete instance group",
+	})
+
+	api.GET("/template", templatesGet, &openapi.Op{
+		Summary:  "List templates",
+		Query:    apiPage,
+		Response: templatesData{},
+	})
+	api.GET("/template/:template_id", templateGet, &openapi.Op{
+		Summary:  "Get template",
+		Response: template.Template{},
+	})
+	api.GET("/template/:template_id/version", templateVersionsGet, &openapi.Op{
+		Summary:  "List template versions",
+		Response: []*template.Version{},
+	})
+	api.PUT("/template/:template_id", templatePut, &openapi.Op{
+		Summary:  "Update template",
+		Request:  templateData{},
+		Response: template.Template{},
+	})
+	api.POST("/template", templatePost, &openapi.Op{
+		Summary:  "Create template",
+		Request:  templateData{},
+		Response: template.Template{},
+	})
+	api.POST("/template/:template_id/launch", templateLaunchPost, &openapi.Op{
+		Summary:  "Launch instances from template",
+		Request:  templateLaunchData{},
+		Response: []*instance.Instance{},
+	})
+	api.DELETE("/template", templatesDelete, &openapi.Op{
+		Summary: "Delete templates",
+		Request: apiIds,
+	})
+	api.DELETE("/template/:template_id", templateDelete, &openapi.Op{
+		Summary: "Delete template",
+	})
+
+	api.GET("/zone", zonesGet, &openapi.Op{
+		Summary:  "List zones",
+		Response: []*zone.Zone{},
+	})
+	api.GET("/zone/:zone_id", zoneGet, &openapi.Op{
+		Summary:  "Get zone",
+		Response: zone.Zone{},
+	})
+	api.PUT("/zone/:zone_id", zonePut, &openapi.Op{
+		Summary:  "Update zone",
+		Request:  zoneData{},
+		Response: zone.Zone{},
+	})
+	api.POST("/zone", zonePost, &openapi.Op{
+		Summary:  "Create zone",
+		Request:  zoneData{},
+		Response: zone.Zone{},
+	})
+	api.DELETE("/zone/:zone_id", zoneDelete, &openapi.Op{
+		Summary: "Delete zone",
+	})
+}
+
+func openapiGet(c *gin.Context) {
+	c.JSON(200, apiDoc)
+}
@@ -6,7 +6,10 @@ import (
 	"github.com/pritunl/pritunl-cloud/database"
 	"github.com/pritunl/pritunl-cloud/demo"
 	"github.com/pritunl/pritunl-cloud/utils"
+	"gopkg.in/mgo.v2/bson"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type auditsData struct {
@@ -49,3 +52,76 @@ func auditsGet(c *gin.Context) {
 
 	c.JSON(200, data)
 }
+
+func auditsSearchGet(c *gin.Context) {
+	db := c.MustGet("db").(*database.Database)
+
+	page, _ := strconv.Atoi(c.Query("page"))
+	pageCount, _ := strconv.Atoi(c.Query("page_count"))
+
+	query := bson.M{}
+
+	userId, ok := utils.ParseObjectId(c.Query("user"))
+	if ok {
+		query["u"] = userId
+	}
+
+	typ := strings.TrimSpace(c.Query("type"))
+	if typ != "" {
+		query["y"] = typ
+	}
+
+	ip := strings.TrimSpace(c.Query("ip"))
+	if ip != "" {
+		query["a.ip"] = ip
+	}
+
+	timestamp := bson.M{}
+
+	if c.Query("start") != "" {
+		start, ok := parseMeterTime(c.Query("start"), time.Time{})
+		if !ok {
+			utils.AbortWithStatus(c, 400)
+			return
+		}
+		timestamp["$gte"] = start
+	}
+
+	if c.Query("end") != "" {
+		end, ok := parseMeterTime(c.Query("end"), time.Time{})
+		if !ok {
+			utils.AbortWithStatus(c, 400)
+			return
+		}
+		timestamp["$lt"] = end
+	}
+
+	if len(timestamp) > 0 {
+		query["t"] = timestamp
+	}
+
+	audits, count, err := audit.GetAllPaged(db, &query, page, pageCount)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	data := &auditsData{
+		Audits: audits,
+		Count:  count,
+	}
+
+	c.JSON(200, data)
+}
+
+func auditVerifyGet(c *gin.Context) {
+	db := c.MustGet("db").(*database.Database)
+
+	verif, err := audit.Verify(db)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	c.JSON(200, verif)
+}
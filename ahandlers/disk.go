@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"github.com/dropbox/godropbox/container/set"
 	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-cloud/audit"
 	"github.com/pritunl/pritunl-cloud/database"
 	"github.com/pritunl/pritunl-cloud/demo"
 	"github.com/pritunl/pritunl-cloud/disk"
 	"github.com/pritunl/pritunl-cloud/errortypes"
 	"github.com/pritunl/pritunl-cloud/event"
+	"github.com/pritunl/pritunl-cloud/quota"
 	"github.com/pritunl/pritunl-cloud/utils"
 	"gopkg.in/mgo.v2/bson"
 	"strconv"
@@ -63,12 +65,21 @@ func diskPut(c *gin.Context) {
 		return
 	}
 
+	orig := audit.Snapshot(dsk)
+
 	dsk.Name = dta.Name
 	dsk.Instance = dta.Instance
 	dsk.Index = dta.Index
 
+	snapshot := false
 	if dsk.State == disk.Available && dta.State == disk.Snapshot {
 		dsk.State = disk.Snapshot
+		snapshot = true
+	} else if dsk.State == disk.Available && dta.State == disk.Flatten &&
+		dsk.Backing != "" {
+
+		dsk.State = disk.Flatten
+		dsk.Message = ""
 	}
 
 	fields := set.NewSet(
@@ -76,6 +87,7 @@ func diskPut(c *gin.Context) {
 		"name",
 		"instance",
 		"index",
+		"message",
 	)
 
 	errData, err := dsk.Validate(db)
@@ -89,12 +101,29 @@ func diskPut(c *gin.Context) {
 		return
 	}
 
+	if snapshot {
+		errData, err = quota.Check(db, dsk.Organization, &quota.Usage{
+			Images: 1,
+		})
+		if err != nil {
+			utils.AbortWithError(c, 500, err)
+			return
+		}
+
+		if errData != nil {
+			c.JSON(400, errData)
+			return
+		}
+	}
+
 	err = dsk.CommitFields(db, fields)
 	if err != nil {
 		utils.AbortWithError(c, 500, err)
 		return
 	}
 
+	c.Set("audit_changes", audit.Diff(orig, dsk, fields))
+
 	event.PublishDispatch(db, "disk.change")
 
 	c.JSON(200, dsk)
@@ -137,6 +166,19 @@ func diskPost(c *gin.Context) {
 		return
 	}
 
+	errData, err = quota.Check(db, dsk.Organization, &quota.Usage{
+		DiskSize: dsk.Size,
+	})
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	if errData != nil {
+		c.JSON(400, errData)
+		return
+	}
+
 	err = dsk.Insert(db)
 	if err != nil {
 		utils.AbortWithError(c, 500, err)
@@ -172,6 +214,36 @@ func disksPut(c *gin.Context) {
 		return
 	}
 
+	disks, err := disk.GetAll(db, &bson.M{
+		"_id": &bson.M{
+			"$in": data.Ids,
+		},
+	})
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	snapshots := map[bson.ObjectId]int{}
+	for _, dsk := range disks {
+		snapshots[dsk.Organization] += 1
+	}
+
+	for orgId, count := range snapshots {
+		errData, e := quota.Check(db, orgId, &quota.Usage{
+			Images: count,
+		})
+		if e != nil {
+			utils.AbortWithError(c, 500, e)
+			return
+		}
+
+		if errData != nil {
+			c.JSON(400, errData)
+			return
+		}
+	}
+
 	doc := bson.M{
 		"state": data.State,
 	}
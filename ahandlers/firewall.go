@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/dropbox/godropbox/container/set"
 	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-cloud/audit"
 	"github.com/pritunl/pritunl-cloud/database"
 	"github.com/pritunl/pritunl-cloud/demo"
 	"github.com/pritunl/pritunl-cloud/event"
@@ -53,6 +54,8 @@ func firewallPut(c *gin.Context) {
 		return
 	}
 
+	orig := audit.Snapshot(fire)
+
 	fire.Name = data.Name
 	fire.Organization = data.Organization
 	fire.NetworkRoles = data.NetworkRoles
@@ -83,6 +86,8 @@ func firewallPut(c *gin.Context) {
 		return
 	}
 
+	c.Set("audit_changes", audit.Diff(orig, fire, fields))
+
 	event.PublishDispatch(db, "firewall.change")
 
 	c.JSON(200, fire)
@@ -4,12 +4,14 @@ import (
 	"fmt"
 	"github.com/dropbox/godropbox/container/set"
 	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-cloud/audit"
 	"github.com/pritunl/pritunl-cloud/data"
 	"github.com/pritunl/pritunl-cloud/database"
 	"github.com/pritunl/pritunl-cloud/datacenter"
 	"github.com/pritunl/pritunl-cloud/demo"
 	"github.com/pritunl/pritunl-cloud/event"
 	"github.com/pritunl/pritunl-cloud/image"
+	"github.com/pritunl/pritunl-cloud/quota"
 	"github.com/pritunl/pritunl-cloud/utils"
 	"gopkg.in/mgo.v2/bson"
 	"strconv"
@@ -17,9 +19,12 @@ import (
 )
 
 type imageData struct {
-	Id           bson.ObjectId `json:"id"`
-	Name         string        `json:"name"`
-	Organization bson.ObjectId `json:"organization"`
+	Id           bson.ObjectId   `json:"id"`
+	Name         string          `json:"name"`
+	Organization bson.ObjectId   `json:"organization"`
+	Shares       []bson.ObjectId `json:"shares"`
+	Deprecated   bool            `json:"deprecated"`
+	Obsolete     bool            `json:"obsolete"`
 }
 
 type imagesData struct {
@@ -53,12 +58,22 @@ func imagePut(c *gin.Context) {
 		return
 	}
 
+	orig := audit.Snapshot(img)
+
+	curOrg := img.Organization
+
 	img.Name = dta.Name
 	img.Organization = dta.Organization
+	img.Shares = dta.Shares
+	img.Deprecated = dta.Deprecated
+	img.Obsolete = dta.Obsolete
 
 	fields := set.NewSet(
 		"name",
 		"organization",
+		"shares",
+		"deprecated",
+		"obsolete",
 	)
 
 	errData, err := img.Validate(db)
@@ -72,12 +87,29 @@ func imagePut(c *gin.Context) {
 		return
 	}
 
+	if img.Organization != curOrg {
+		errData, err = quota.Check(db, img.Organization, &quota.Usage{
+			Images: 1,
+		})
+		if err != nil {
+			utils.AbortWithError(c, 500, err)
+			return
+		}
+
+		if errData != nil {
+			c.JSON(400, errData)
+			return
+		}
+	}
+
 	err = img.CommitFields(db, fields)
 	if err != nil {
 		utils.AbortWithError(c, 500, err)
 		return
 	}
 
+	c.Set("audit_changes", audit.Diff(orig, img, fields))
+
 	event.PublishDispatch(db, "image.change")
 
 	c.JSON(200, img)
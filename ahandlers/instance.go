@@ -5,12 +5,18 @@ import (
 	"github.com/dropbox/godropbox/container/set"
 	"github.com/gin-gonic/gin"
 	"github.com/pritunl/pritunl-cloud/aggregate"
+	"github.com/pritunl/pritunl-cloud/audit"
 	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/datacenter"
 	"github.com/pritunl/pritunl-cloud/demo"
+	"github.com/pritunl/pritunl-cloud/errortypes"
 	"github.com/pritunl/pritunl-cloud/event"
+	"github.com/pritunl/pritunl-cloud/image"
 	"github.com/pritunl/pritunl-cloud/instance"
+	"github.com/pritunl/pritunl-cloud/quota"
 	"github.com/pritunl/pritunl-cloud/utils"
 	"github.com/pritunl/pritunl-cloud/vm"
+	"github.com/pritunl/pritunl-cloud/zone"
 	"gopkg.in/mgo.v2/bson"
 	"strconv"
 	"strings"
@@ -23,13 +29,16 @@ type instanceData struct {
 	Vpc          bson.ObjectId `json:"vpc"`
 	Node         bson.ObjectId `json:"node"`
 	Image        bson.ObjectId `json:"image"`
+	ImageFamily  string        `json:"image_family"`
 	Domain       bson.ObjectId `json:"domain"`
+	StaticIp     string        `json:"static_ip"`
 	Name         string        `json:"name"`
 	State        string        `json:"state"`
 	InitDiskSize int           `json:"init_disk_size"`
 	Memory       int           `json:"memory"`
 	Processors   int           `json:"processors"`
 	NetworkRoles []string      `json:"network_roles"`
+	UserData     string        `json:"user_data"`
 	Count        int           `json:"count"`
 }
 
@@ -69,8 +78,13 @@ func instancePut(c *gin.Context) {
 		return
 	}
 
+	orig := audit.Snapshot(inst)
+
 	inst.PreCommit()
 
+	curProcessors := inst.Processors
+	curMemory := inst.Memory
+
 	inst.Name = data.Name
 	inst.Vpc = data.Vpc
 	if data.State != "" {
@@ -80,6 +94,8 @@ func instancePut(c *gin.Context) {
 	inst.Processors = data.Processors
 	inst.NetworkRoles = data.NetworkRoles
 	inst.Domain = data.Domain
+	inst.StaticIp = data.StaticIp
+	inst.UserData = data.UserData
 
 	fields := set.NewSet(
 		"name",
@@ -90,6 +106,8 @@ func instancePut(c *gin.Context) {
 		"processors",
 		"network_roles",
 		"domain",
+		"static_ip",
+		"user_data",
 	)
 
 	errData, err := inst.Validate(db)
@@ -103,6 +121,20 @@ func instancePut(c *gin.Context) {
 		return
 	}
 
+	errData, err = quota.Check(db, inst.Organization, &quota.Usage{
+		Processors: inst.Processors - curProcessors,
+		Memory:     inst.Memory - curMemory,
+	})
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	if errData != nil {
+		c.JSON(400, errData)
+		return
+	}
+
 	err = inst.PostCommit(db)
 	if err != nil {
 		utils.AbortWithError(c, 500, err)
@@ -115,6 +147,8 @@ func instancePut(c *gin.Context) {
 		return
 	}
 
+	c.Set("audit_changes", audit.Diff(orig, inst, fields))
+
 	event.PublishDispatch(db, "instance.change")
 
 	c.JSON(200, inst)
@@ -136,12 +170,69 @@ func instancePost(c *gin.Context) {
 		return
 	}
 
+	if data.ImageFamily != "" {
+		zne, err := zone.Get(db, data.Zone)
+		if err != nil {
+			utils.AbortWithError(c, 500, err)
+			return
+		}
+
+		dc, err := datacenter.Get(db, zne.Datacenter)
+		if err != nil {
+			utils.AbortWithError(c, 500, err)
+			return
+		}
+
+		img, err := image.GetFamily(db, data.Organization, data.ImageFamily,
+			dc.Storages())
+		if err != nil {
+			utils.AbortWithError(c, 500, err)
+			return
+		}
+
+		if img == nil {
+			errData := &errortypes.ErrorData{
+				Error:   "image_family_not_found",
+				Message: "No launchable image found in image family",
+			}
+			c.JSON(400, errData)
+			return
+		}
+
+		data.Image = img.Id
+	}
+
 	insts := []*instance.Instance{}
 
 	if data.Count == 0 {
 		data.Count = 1
 	}
 
+	if data.StaticIp != "" && data.Count > 1 {
+		errData := &errortypes.ErrorData{
+			Error:   "static_ip_count_invalid",
+			Message: "Static IP address cannot be used with multiple instances",
+		}
+		c.JSON(400, errData)
+		return
+	}
+
+	errData, err := quota.Check(db, data.Organization, &quota.Usage{
+		Instances:  data.Count,
+		Processors: utils.Max(data.Processors, 1) * data.Count,
+		Memory:     utils.Max(data.Memory, 256) * data.Count,
+		DiskSize:   utils.Max(data.InitDiskSize, 10) * data.Count,
+	})
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	if errData != nil {
+		c.JSON(400, errData)
+		return
+	}
+
 	for i := 0; i < data.Count; i++ {
 		name := ""
 		if strings.Contains(data.Name, "%") {
@@ -163,6 +254,8 @@ func instancePost(c *gin.Context) {
 			Processors:   data.Processors,
 			NetworkRoles: data.NetworkRoles,
 			Domain:       data.Domain,
+			StaticIp:     data.StaticIp,
+			UserData:     data.UserData,
 		}
 
 		errData, err := inst.Validate(db)
@@ -357,6 +450,11 @@ func instancesGet(c *gin.Context) {
 			query["network_roles"] = networkRole
 		}
 
+		groupId, ok := utils.ParseObjectId(c.Query("group"))
+		if ok {
+			query["group"] = groupId
+		}
+
 		organization, ok := utils.ParseObjectId(c.Query("organization"))
 		if ok {
 			query["organization"] = organization
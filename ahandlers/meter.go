@@ -0,0 +1,75 @@
+package ahandlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/meter"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"time"
+)
+
+func parseMeterTime(val string, def time.Time) (timestamp time.Time, ok bool) {
+	if val == "" {
+		timestamp = def
+		ok = true
+		return
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, val)
+	if err == nil {
+		ok = true
+		return
+	}
+
+	timestamp, err = time.Parse("2006-01-02", val)
+	if err == nil {
+		ok = true
+		return
+	}
+
+	return
+}
+
+func meterGet(c *gin.Context) {
+	db := c.MustGet("db").(*database.Database)
+
+	now := time.Now().UTC()
+
+	end, ok := parseMeterTime(c.Query("end"), now)
+	if !ok {
+		utils.AbortWithStatus(c, 400)
+		return
+	}
+
+	start, ok := parseMeterTime(c.Query("start"), end.AddDate(0, 0, -30))
+	if !ok {
+		utils.AbortWithStatus(c, 400)
+		return
+	}
+
+	orgId, _ := utils.ParseObjectId(c.Query("organization"))
+
+	reports, err := meter.GetReport(db, orgId, start, end)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", fmt.Sprintf(
+			"attachment; filename=\"usage-%s-%s.csv\"",
+			start.Format("20060102"), end.Format("20060102")))
+
+		err = meter.WriteCsv(c.Writer, reports)
+		if err != nil {
+			utils.AbortWithError(c, 500, err)
+			return
+		}
+
+		return
+	}
+
+	c.JSON(200, reports)
+}
@@ -0,0 +1,41 @@
+package ahandlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/metric"
+	"github.com/pritunl/pritunl-cloud/utils"
+)
+
+func metricGet(c *gin.Context, param string) {
+	db := c.MustGet("db").(*database.Database)
+
+	resourceId, ok := utils.ParseObjectId(c.Param(param))
+	if !ok {
+		utils.AbortWithStatus(c, 400)
+		return
+	}
+
+	start, end, resolution, ok := metric.ParseRange(
+		c.Query("start"), c.Query("end"), c.Query("resolution"))
+	if !ok {
+		utils.AbortWithStatus(c, 400)
+		return
+	}
+
+	points, err := metric.GetSeries(db, resourceId, resolution, start, end)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	c.JSON(200, points)
+}
+
+func metricNodeGet(c *gin.Context) {
+	metricGet(c, "node_id")
+}
+
+func metricInstanceGet(c *gin.Context) {
+	metricGet(c, "instance_id")
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/pritunl/pritunl-cloud/demo"
 	"github.com/pritunl/pritunl-cloud/errortypes"
 	"github.com/pritunl/pritunl-cloud/event"
+	"github.com/pritunl/pritunl-cloud/image"
 	"github.com/pritunl/pritunl-cloud/node"
 	"github.com/pritunl/pritunl-cloud/utils"
 	"gopkg.in/mgo.v2/bson"
@@ -32,6 +33,8 @@ type nodeData struct {
 	ForwardedForHeader   string          `json:"forwarded_for_header"`
 	ForwardedProtoHeader string          `json:"forwarded_proto_header"`
 	Firewall             bool            `json:"firewall"`
+	LinkedClones         bool            `json:"linked_clones"`
+	CacheLimit           int             `json:"cache_limit"`
 	NetworkRoles         []string        `json:"network_roles"`
 }
 
@@ -79,6 +82,8 @@ func nodePut(c *gin.Context) {
 	nde.ForwardedForHeader = data.ForwardedForHeader
 	nde.ForwardedProtoHeader = data.ForwardedProtoHeader
 	nde.Firewall = data.Firewall
+	nde.LinkedClones = data.LinkedClones
+	nde.CacheLimit = data.CacheLimit
 	nde.NetworkRoles = data.NetworkRoles
 
 	fields := set.NewSet(
@@ -96,6 +101,8 @@ func nodePut(c *gin.Context) {
 		"forwarded_for_header",
 		"forwarded_proto_header",
 		"firewall",
+		"linked_clones",
+		"cache_limit",
 		"network_roles",
 	)
 
@@ -133,6 +140,43 @@ func nodePut(c *gin.Context) {
 	c.JSON(200, nde)
 }
 
+func nodeCacheGet(c *gin.Context) {
+	db := c.MustGet("db").(*database.Database)
+
+	nodeId, ok := utils.ParseObjectId(c.Param("node_id"))
+	if !ok {
+		utils.AbortWithStatus(c, 400)
+		return
+	}
+
+	nde, err := node.Get(db, nodeId)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	caches := nde.CacheImages
+	if caches == nil {
+		caches = []*node.CacheImage{}
+	}
+
+	for _, cache := range caches {
+		img, e := image.Get(db, cache.Image)
+		if e != nil {
+			if _, ok := e.(*database.NotFoundError); ok {
+				continue
+			}
+			utils.AbortWithError(c, 500, e)
+			return
+		}
+
+		img.Json()
+		cache.Name = img.Name
+	}
+
+	c.JSON(200, caches)
+}
+
 func nodeDelete(c *gin.Context) {
 	if demo.Blocked(c) {
 		return
@@ -7,14 +7,16 @@ import (
 	"github.com/pritunl/pritunl-cloud/demo"
 	"github.com/pritunl/pritunl-cloud/event"
 	"github.com/pritunl/pritunl-cloud/organization"
+	"github.com/pritunl/pritunl-cloud/quota"
 	"github.com/pritunl/pritunl-cloud/utils"
 	"gopkg.in/mgo.v2/bson"
 )
 
 type organizationData struct {
-	Id    bson.ObjectId `json:"id"`
-	Name  string        `json:"name"`
-	Roles []string      `json:"roles"`
+	Id    bson.ObjectId       `json:"id"`
+	Name  string              `json:"name"`
+	Roles []string            `json:"roles"`
+	Quota *organization.Quota `json:"quota"`
 }
 
 func organizationPut(c *gin.Context) {
@@ -45,10 +47,12 @@ func organizationPut(c *gin.Context) {
 
 	org.Name = data.Name
 	org.Roles = data.Roles
+	org.Quota = data.Quota
 
 	fields := set.NewSet(
 		"name",
 		"roles",
+		"quota",
 	)
 
 	errData, err := org.Validate(db)
@@ -92,6 +96,7 @@ func organizationPost(c *gin.Context) {
 	org := &organization.Organization{
 		Name:  data.Name,
 		Roles: data.Roles,
+		Quota: data.Quota,
 	}
 
 	errData, err := org.Validate(db)
@@ -158,6 +163,24 @@ func organizationGet(c *gin.Context) {
 	c.JSON(200, org)
 }
 
+func organizationQuotaGet(c *gin.Context) {
+	db := c.MustGet("db").(*database.Database)
+
+	orgId, ok := utils.ParseObjectId(c.Param("org_id"))
+	if !ok {
+		utils.AbortWithStatus(c, 400)
+		return
+	}
+
+	report, err := quota.GetReport(db, orgId)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	c.JSON(200, report)
+}
+
 func organizationsGet(c *gin.Context) {
 	db := c.MustGet("db").(*database.Database)
 
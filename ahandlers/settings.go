@@ -5,11 +5,17 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/pritunl/pritunl-cloud/database"
 	"github.com/pritunl/pritunl-cloud/demo"
+	"github.com/pritunl/pritunl-cloud/errortypes"
 	"github.com/pritunl/pritunl-cloud/event"
+	"github.com/pritunl/pritunl-cloud/image"
 	"github.com/pritunl/pritunl-cloud/secondary"
 	"github.com/pritunl/pritunl-cloud/settings"
 	"github.com/pritunl/pritunl-cloud/utils"
+	"github.com/pritunl/pritunl-cloud/verify"
 	"gopkg.in/mgo.v2/bson"
+	"reflect"
+	"regexp"
+	"strings"
 )
 
 type settingsData struct {
@@ -23,6 +29,18 @@ type settingsData struct {
 	AuthUserMaxDuration    int                           `json:"auth_user_max_duration"`
 	ElasticAddress         string                        `json:"elastic_address"`
 	ElasticProxyRequests   bool                          `json:"elastic_proxy_requests"`
+	ImageSignaturePolicy   string                        `json:"image_signature_policy"`
+	ImageTrustedKeys       []string                      `json:"image_trusted_keys"`
+	ImageFamilyPattern     string                        `json:"image_family_pattern"`
+}
+
+func hasSubexp(reg *regexp.Regexp, name string) bool {
+	for _, subName := range reg.SubexpNames() {
+		if subName == name {
+			return true
+		}
+	}
+	return false
 }
 
 func getSettingsData() *settingsData {
@@ -33,6 +51,9 @@ func getSettingsData() *settingsData {
 		AuthAdminMaxDuration:   settings.Auth.AdminMaxDuration,
 		AuthUserExpire:         settings.Auth.UserExpire,
 		AuthUserMaxDuration:    settings.Auth.UserMaxDuration,
+		ImageSignaturePolicy:   settings.Image.SignaturePolicy,
+		ImageTrustedKeys:       settings.Image.TrustedKeys,
+		ImageFamilyPattern:     settings.Image.FamilyPattern,
 	}
 
 	return data
@@ -57,6 +78,52 @@ func settingsPut(c *gin.Context) {
 		return
 	}
 
+	if data.ImageSignaturePolicy == "" {
+		data.ImageSignaturePolicy = verify.None
+	}
+
+	if !verify.Policies[data.ImageSignaturePolicy] {
+		errData := &errortypes.ErrorData{
+			Error:   "signature_policy_invalid",
+			Message: "Image signature policy is invalid",
+		}
+		c.JSON(400, errData)
+		return
+	}
+
+	if data.ImageFamilyPattern != "" {
+		reg, e := regexp.Compile(data.ImageFamilyPattern)
+		if e != nil || !hasSubexp(reg, "family") ||
+			!hasSubexp(reg, "version") {
+
+			errData := &errortypes.ErrorData{
+				Error:   "family_pattern_invalid",
+				Message: "Image family pattern must match family and version",
+			}
+			c.JSON(400, errData)
+			return
+		}
+	}
+
+	trustedKeys := []string{}
+	for _, key := range data.ImageTrustedKeys {
+		key = strings.TrimSpace(key)
+		if key != "" {
+			trustedKeys = append(trustedKeys, key)
+		}
+	}
+	data.ImageTrustedKeys = trustedKeys
+
+	_, err = verify.NewKeyring(data.ImageTrustedKeys...)
+	if err != nil {
+		errData := &errortypes.ErrorData{
+			Error:   "trusted_key_invalid",
+			Message: "Image trusted key is invalid",
+		}
+		c.JSON(400, errData)
+		return
+	}
+
 	fields := set.NewSet(
 		"providers",
 		"secondary_providers",
@@ -105,6 +172,41 @@ func settingsPut(c *gin.Context) {
 		return
 	}
 
+	imageFields := set.NewSet()
+
+	if settings.Image.SignaturePolicy != data.ImageSignaturePolicy {
+		settings.Image.SignaturePolicy = data.ImageSignaturePolicy
+		imageFields.Add("signature_policy")
+	}
+
+	keysChanged := !reflect.DeepEqual(
+		settings.Image.TrustedKeys, data.ImageTrustedKeys)
+	settings.Image.TrustedKeys = data.ImageTrustedKeys
+	imageFields.Add("trusted_keys")
+
+	if data.ImageFamilyPattern != "" &&
+		settings.Image.FamilyPattern != data.ImageFamilyPattern {
+
+		settings.Image.FamilyPattern = data.ImageFamilyPattern
+		imageFields.Add("family_pattern")
+	}
+
+	err = settings.Commit(db, settings.Image, imageFields)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	if keysChanged {
+		err = image.ClearVerified(db)
+		if err != nil {
+			utils.AbortWithError(c, 500, err)
+			return
+		}
+
+		event.PublishDispatch(db, "image.change")
+	}
+
 	event.PublishDispatch(db, "settings.change")
 
 	data = getSettingsData()
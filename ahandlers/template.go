@@ -0,0 +1,365 @@
+package ahandlers
+
+import (
+	"fmt"
+	"github.com/dropbox/godropbox/container/set"
+	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-cloud/audit"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/demo"
+	"github.com/pritunl/pritunl-cloud/event"
+	"github.com/pritunl/pritunl-cloud/template"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"gopkg.in/mgo.v2/bson"
+	"strconv"
+	"strings"
+)
+
+type templateData struct {
+	Id           bson.ObjectId  `json:"id"`
+	Name         string         `json:"name"`
+	Organization bson.ObjectId  `json:"organization"`
+	Spec         *template.Spec `json:"spec"`
+}
+
+type templateLaunchData struct {
+	Version   int                 `json:"version"`
+	Count     int                 `json:"count"`
+	Name      string              `json:"name"`
+	State     string              `json:"state"`
+	StaticIp  string              `json:"static_ip"`
+	Overrides *template.Overrides `json:"overrides"`
+}
+
+type templatesData struct {
+	Templates []*template.Template `json:"templates"`
+	Count     int                  `json:"count"`
+}
+
+func templatePut(c *gin.Context) {
+	if demo.Blocked(c) {
+		return
+	}
+
+	db := c.MustGet("db").(*database.Database)
+	data := &templateData{}
+
+	templateId, ok := utils.ParseObjectId(c.Param("template_id"))
+	if !ok {
+		utils.AbortWithStatus(c, 400)
+		return
+	}
+
+	err := c.Bind(data)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	tmpl, err := template.Get(db, templateId)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	orig := audit.Snapshot(tmpl)
+
+	tmpl.Name = data.Name
+	tmpl.Organization = data.Organization
+	changed := tmpl.SetSpec(data.Spec)
+
+	fields := set.NewSet(
+		"name",
+		"organization",
+		"version",
+		"timestamp",
+		"spec",
+	)
+
+	errData, err := tmpl.Validate(db)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	if errData != nil {
+		c.JSON(400, errData)
+		return
+	}
+
+	err = tmpl.CommitFields(db, fields)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	if changed {
+		err = tmpl.InsertVersion(db)
+		if err != nil {
+			utils.AbortWithError(c, 500, err)
+			return
+		}
+	}
+
+	c.Set("audit_changes", audit.Diff(orig, tmpl, fields))
+
+	event.PublishDispatch(db, "template.change")
+
+	c.JSON(200, tmpl)
+}
+
+func templatePost(c *gin.Context) {
+	if demo.Blocked(c) {
+		return
+	}
+
+	db := c.MustGet("db").(*database.Database)
+	data := &templateData{
+		Name: "New Template",
+	}
+
+	err := c.Bind(data)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	tmpl := &template.Template{
+		Name:         data.Name,
+		Organization: data.Organization,
+	}
+	tmpl.SetSpec(data.Spec)
+
+	errData, err := tmpl.Validate(db)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	if errData != nil {
+		c.JSON(400, errData)
+		return
+	}
+
+	err = tmpl.Insert(db)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	event.PublishDispatch(db, "template.change")
+
+	c.JSON(200, tmpl)
+}
+
+func templateLaunchPost(c *gin.Context) {
+	if demo.Blocked(c) {
+		return
+	}
+
+	db := c.MustGet("db").(*database.Database)
+	data := &templateLaunchData{}
+
+	templateId, ok := utils.ParseObjectId(c.Param("template_id"))
+	if !ok {
+		utils.AbortWithStatus(c, 400)
+		return
+	}
+
+	err := c.Bind(data)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	tmpl, err := template.Get(db, templateId)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	spec, version, err := tmpl.GetSpec(db, data.Version)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	if data.Overrides != nil {
+		data.Overrides.Apply(spec)
+	}
+
+	if data.Name == "" {
+		data.Name = tmpl.Name
+	}
+
+	lnch := &template.Launch{
+		Organization: tmpl.Organization,
+		Template:     tmpl.Id,
+		Version:      version,
+		Spec:         spec,
+		Name:         data.Name,
+		State:        data.State,
+		StaticIp:     data.StaticIp,
+		Count:        data.Count,
+	}
+
+	errData, err := lnch.Validate(db)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	if errData != nil {
+		c.JSON(400, errData)
+		return
+	}
+
+	insts, errData, err := lnch.Create(db)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	event.PublishDispatch(db, "instance.change")
+	if len(spec.Disks) > 0 {
+		event.PublishDispatch(db, "disk.change")
+	}
+
+	if errData != nil {
+		c.JSON(400, errData)
+		return
+	}
+
+	c.JSON(200, insts)
+}
+
+func templateDelete(c *gin.Context) {
+	if demo.Blocked(c) {
+		return
+	}
+
+	db := c.MustGet("db").(*database.Database)
+
+	templateId, ok := utils.ParseObjectId(c.Param("template_id"))
+	if !ok {
+		utils.AbortWithStatus(c, 400)
+		return
+	}
+
+	err := template.Remove(db, templateId)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	event.PublishDispatch(db, "template.change")
+
+	c.JSON(200, nil)
+}
+
+func templatesDelete(c *gin.Context) {
+	if demo.Blocked(c) {
+		return
+	}
+
+	db := c.MustGet("db").(*database.Database)
+	data := []bson.ObjectId{}
+
+	err := c.Bind(&data)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	err = template.RemoveMulti(db, data)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	event.PublishDispatch(db, "template.change")
+
+	c.JSON(200, nil)
+}
+
+func templateGet(c *gin.Context) {
+	db := c.MustGet("db").(*database.Database)
+
+	templateId, ok := utils.ParseObjectId(c.Param("template_id"))
+	if !ok {
+		utils.AbortWithStatus(c, 400)
+		return
+	}
+
+	tmpl, err := template.Get(db, templateId)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	c.JSON(200, tmpl)
+}
+
+func templateVersionsGet(c *gin.Context) {
+	db := c.MustGet("db").(*database.Database)
+
+	templateId, ok := utils.ParseObjectId(c.Param("template_id"))
+	if !ok {
+		utils.AbortWithStatus(c, 400)
+		return
+	}
+
+	tmpl, err := template.Get(db, templateId)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	vers, err := template.GetVersions(db, tmpl.Id)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	c.JSON(200, vers)
+}
+
+func templatesGet(c *gin.Context) {
+	db := c.MustGet("db").(*database.Database)
+
+	page, _ := strconv.Atoi(c.Query("page"))
+	pageCount, _ := strconv.Atoi(c.Query("page_count"))
+
+	query := bson.M{}
+
+	templateId, ok := utils.ParseObjectId(c.Query("id"))
+	if ok {
+		query["_id"] = templateId
+	}
+
+	name := strings.TrimSpace(c.Query("name"))
+	if name != "" {
+		query["name"] = &bson.M{
+			"$regex":   fmt.Sprintf(".*%s.*", name),
+			"$options": "i",
+		}
+	}
+
+	organization, ok := utils.ParseObjectId(c.Query("organization"))
+	if ok {
+		query["organization"] = organization
+	}
+
+	tmpls, count, err := template.GetAllPaged(db, &query, page, pageCount)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	data := &templatesData{
+		Templates: tmpls,
+		Count:     count,
+	}
+
+	c.JSON(200, data)
+}
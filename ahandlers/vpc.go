@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"github.com/dropbox/godropbox/container/set"
 	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-cloud/audit"
 	"github.com/pritunl/pritunl-cloud/database"
 	"github.com/pritunl/pritunl-cloud/demo"
 	"github.com/pritunl/pritunl-cloud/event"
+	"github.com/pritunl/pritunl-cloud/quota"
 	"github.com/pritunl/pritunl-cloud/utils"
 	"github.com/pritunl/pritunl-cloud/vpc"
 	"gopkg.in/mgo.v2/bson"
@@ -15,13 +17,18 @@ import (
 )
 
 type vpcData struct {
-	Id           bson.ObjectId `json:"id"`
-	Name         string        `json:"name"`
-	Network      string        `json:"network"`
-	Organization bson.ObjectId `json:"organization"`
-	Datacenter   bson.ObjectId `json:"datacenter"`
-	Routes       []*vpc.Route  `json:"routes"`
-	LinkUris     []string      `json:"link_uris"`
+	Id            bson.ObjectId `json:"id"`
+	Name          string        `json:"name"`
+	Network       string        `json:"network"`
+	Organization  bson.ObjectId `json:"organization"`
+	Datacenter    bson.ObjectId `json:"datacenter"`
+	Routes        []*vpc.Route  `json:"routes"`
+	LinkUris      []string      `json:"link_uris"`
+	LinkAuth      string        `json:"link_auth"`
+	LinkPeers     []*vpc.Peer   `json:"link_peers"`
+	LinkNodeCount int           `json:"link_node_count"`
+	LinkRouting   string        `json:"link_routing"`
+	LinkCert      bson.ObjectId `json:"link_cert"`
 }
 
 type vpcsData struct {
@@ -55,15 +62,28 @@ func vpcPut(c *gin.Context) {
 		return
 	}
 
+	orig := audit.Snapshot(vc)
+
 	vc.Name = data.Name
 	vc.Routes = data.Routes
 	vc.LinkUris = data.LinkUris
+	vc.LinkAuth = data.LinkAuth
+	vpc.MergePeers(vc.LinkPeers, data.LinkPeers)
+	vc.LinkPeers = data.LinkPeers
+	vc.LinkNodeCount = data.LinkNodeCount
+	vc.LinkRouting = data.LinkRouting
+	vc.LinkCert = data.LinkCert
 
 	fields := set.NewSet(
 		"state",
 		"name",
 		"routes",
 		"link_uris",
+		"link_auth",
+		"link_peers",
+		"link_node_count",
+		"link_routing",
+		"link_cert",
 	)
 
 	errData, err := vc.Validate(db)
@@ -83,6 +103,8 @@ func vpcPut(c *gin.Context) {
 		return
 	}
 
+	c.Set("audit_changes", audit.Diff(orig, vc, fields))
+
 	event.PublishDispatch(db, "vpc.change")
 
 	vc.Json()
@@ -107,12 +129,17 @@ func vpcPost(c *gin.Context) {
 	}
 
 	vc := &vpc.Vpc{
-		Name:         data.Name,
-		Network:      data.Network,
-		Organization: data.Organization,
-		Datacenter:   data.Datacenter,
-		Routes:       data.Routes,
-		LinkUris:     data.LinkUris,
+		Name:          data.Name,
+		Network:       data.Network,
+		Organization:  data.Organization,
+		Datacenter:    data.Datacenter,
+		Routes:        data.Routes,
+		LinkUris:      data.LinkUris,
+		LinkAuth:      data.LinkAuth,
+		LinkPeers:     data.LinkPeers,
+		LinkNodeCount: data.LinkNodeCount,
+		LinkRouting:   data.LinkRouting,
+		LinkCert:      data.LinkCert,
 	}
 
 	vc.GenerateVpcId()
@@ -128,6 +155,19 @@ func vpcPost(c *gin.Context) {
 		return
 	}
 
+	errData, err = quota.Check(db, vc.Organization, &quota.Usage{
+		Vpcs: 1,
+	})
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	if errData != nil {
+		c.JSON(400, errData)
+		return
+	}
+
 	err = vc.Insert(db)
 	if err != nil {
 		utils.AbortWithError(c, 500, err)
@@ -12,9 +12,11 @@ import (
 )
 
 type zoneData struct {
-	Id            bson.ObjectId   `json:"id"`
-	Datacenter    bson.ObjectId   `json:"datacenter"`
-	Name          string          `json:"name"`
+	Id               bson.ObjectId   `json:"id"`
+	Datacenter       bson.ObjectId   `json:"datacenter"`
+	Name             string          `json:"name"`
+	PrefetchImages   []bson.ObjectId `json:"prefetch_images"`
+	PrefetchFamilies []string        `json:"prefetch_families"`
 }
 
 func zonePut(c *gin.Context) {
@@ -44,9 +46,13 @@ func zonePut(c *gin.Context) {
 	}
 
 	zne.Name = data.Name
+	zne.PrefetchImages = data.PrefetchImages
+	zne.PrefetchFamilies = data.PrefetchFamilies
 
 	fields := set.NewSet(
 		"name",
+		"prefetch_images",
+		"prefetch_families",
 	)
 
 	errData, err := zne.Validate(db)
@@ -88,8 +94,10 @@ func zonePost(c *gin.Context) {
 	}
 
 	zne := &zone.Zone{
-		Datacenter:    data.Datacenter,
-		Name:          data.Name,
+		Datacenter:       data.Datacenter,
+		Name:             data.Name,
+		PrefetchImages:   data.PrefetchImages,
+		PrefetchFamilies: data.PrefetchFamilies,
 	}
 
 	errData, err := zne.Validate(db)
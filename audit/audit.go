@@ -1,10 +1,19 @@
 package audit
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/dropbox/godropbox/container/set"
 	"github.com/dropbox/godropbox/errors"
 	"github.com/pritunl/pritunl-cloud/agent"
 	"github.com/pritunl/pritunl-cloud/database"
 	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/requires"
+	"github.com/pritunl/pritunl-cloud/settings"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"github.com/pritunl/pritunl-cloud/webhook"
 	"gopkg.in/mgo.v2/bson"
 	"time"
 )
@@ -18,6 +27,83 @@ type Audit struct {
 	Type      string        `bson:"y" json:"type"`
 	Fields    Fields        `bson:"f" json:"fields"`
 	Agent     *agent.Agent  `bson:"a" json:"agent"`
+	Sequence  int64         `bson:"s,omitempty" json:"sequence"`
+	PrevHash  string        `bson:"p,omitempty" json:"prev_hash"`
+	Hash      string        `bson:"h,omitempty" json:"hash"`
+}
+
+func (a *Audit) GetHash() (hash string, err error) {
+	chainKey := settings.System.AuditChainKey
+	if len(chainKey) == 0 {
+		err = &errortypes.ReadError{
+			errors.New("audit: Chain key not set"),
+		}
+		return
+	}
+
+	data, err := bson.Marshal(a)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "audit: Failed to marshal entry"),
+		}
+		return
+	}
+
+	// Hash the stored form so verification matches database precision
+	adt := &Audit{}
+	err = bson.Unmarshal(data, adt)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "audit: Failed to unmarshal entry"),
+		}
+		return
+	}
+	adt.Hash = ""
+	adt.Timestamp = adt.Timestamp.UTC()
+
+	jsonData, err := json.Marshal(adt)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "audit: Failed to marshal entry"),
+		}
+		return
+	}
+
+	hashFunc := hmac.New(sha256.New, chainKey)
+	hashFunc.Write(jsonData)
+	hash = hex.EncodeToString(hashFunc.Sum(nil))
+
+	return
+}
+
+func (a *Audit) chain(db *database.Database) (err error) {
+	coll := db.Audits()
+
+	last := &Audit{}
+	err = coll.Find(&bson.M{
+		"s": &bson.M{
+			"$gt": 0,
+		},
+	}).Sort("-s").One(last)
+	if err != nil {
+		err = database.ParseError(err)
+		if _, ok := err.(*database.NotFoundError); !ok {
+			return
+		}
+		err = nil
+		last = &Audit{}
+	}
+
+	a.Sequence = last.Sequence + 1
+	a.PrevHash = last.Hash
+	a.Hash = ""
+
+	a.Hash, err = a.GetHash()
+	if err != nil {
+		return
+	}
+
+	return
 }
 
 func (a *Audit) Insert(db *database.Database) (err error) {
@@ -30,11 +116,60 @@ func (a *Audit) Insert(db *database.Database) (err error) {
 		return
 	}
 
-	err = coll.Insert(a)
+	a.Id = bson.NewObjectId()
+
+	for i := 0; i < 20; i++ {
+		err = a.chain(db)
+		if err != nil {
+			return
+		}
+
+		err = coll.Insert(a)
+		if err != nil {
+			err = database.ParseError(err)
+			if _, ok := err.(*database.DuplicateKeyError); ok {
+				continue
+			}
+			return
+		}
+
+		break
+	}
+	if err != nil {
+		return
+	}
+
+	err = webhook.Publish(db, "", webhook.AuditEntry, a)
 	if err != nil {
-		err = database.ParseError(err)
 		return
 	}
 
 	return
 }
+
+func init() {
+	module := requires.New("audit")
+	module.After("settings")
+
+	module.Handler = func() (err error) {
+		if len(settings.System.AuditChainKey) != 0 {
+			return
+		}
+
+		db := database.GetDatabase()
+		defer db.Close()
+
+		settings.System.AuditChainKey, err = utils.RandBytes(64)
+		if err != nil {
+			return
+		}
+
+		err = settings.Commit(db, settings.System,
+			set.NewSet("audit_chain_key"))
+		if err != nil {
+			return
+		}
+
+		return
+	}
+}
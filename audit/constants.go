@@ -33,6 +33,14 @@ const (
 	UserDeviceRegister        = "user_device_register"
 	UserAccountDisable        = "user_account_disable"
 
+	AdminResourceCreate = "admin_resource_create"
+	AdminResourceUpdate = "admin_resource_update"
+	AdminResourceDelete = "admin_resource_delete"
+
+	UserResourceCreate = "user_resource_create"
+	UserResourceUpdate = "user_resource_update"
+	UserResourceDelete = "user_resource_delete"
+
 	DeviceRegister       = "device_register"
 	DeviceRegisterFailed = "device_register_failed"
 	DuoApprove           = "duo_approve"
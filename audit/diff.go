@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"github.com/dropbox/godropbox/container/set"
+	"gopkg.in/mgo.v2/bson"
+	"reflect"
+	"strings"
+)
+
+var (
+	redactedKeys = set.NewSet(
+		"key",
+		"token",
+		"private_key",
+		"pre_shared_key",
+	)
+	redactedSubstrs = []string{
+		"secret",
+		"password",
+		"token",
+		"private_key",
+		"pre_shared_key",
+	}
+)
+
+func isRedacted(key string) bool {
+	if redactedKeys.Contains(key) {
+		return true
+	}
+
+	for _, substr := range redactedSubstrs {
+		if strings.Contains(key, substr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func redact(val interface{}) interface{} {
+	switch v := val.(type) {
+	case bson.M:
+		redacted := bson.M{}
+		for key, item := range v {
+			if isRedacted(key) {
+				redacted[key] = "[redacted]"
+			} else {
+				redacted[key] = redact(item)
+			}
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = redact(item)
+		}
+		return redacted
+	default:
+		return val
+	}
+}
+
+func Snapshot(obj interface{}) (snap bson.M) {
+	snap = bson.M{}
+
+	data, err := bson.Marshal(obj)
+	if err != nil {
+		return
+	}
+
+	bson.Unmarshal(data, &snap)
+
+	return
+}
+
+func Diff(orig bson.M, obj interface{}, fields set.Set) (changes Fields) {
+	changes = Fields{}
+	cur := Snapshot(obj)
+
+	for fieldInf := range fields.Iter() {
+		field := fieldInf.(string)
+
+		before := orig[field]
+		after := cur[field]
+		if reflect.DeepEqual(before, after) {
+			continue
+		}
+
+		if isRedacted(field) {
+			before = "[redacted]"
+			after = "[redacted]"
+		} else {
+			before = redact(before)
+			after = redact(after)
+		}
+
+		changes[field] = Fields{
+			"before": before,
+			"after":  after,
+		}
+	}
+
+	return
+}
@@ -0,0 +1,226 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/minio/minio-go"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/settings"
+	"github.com/pritunl/pritunl-cloud/storage"
+	"gopkg.in/mgo.v2/bson"
+	"log/syslog"
+)
+
+const (
+	exportS3     = "s3"
+	exportSyslog = "syslog"
+	exportLimit  = 5000
+)
+
+type export struct {
+	Id       string `bson:"_id"`
+	Sequence int64  `bson:"sequence"`
+	Hash     string `bson:"hash"`
+}
+
+func (e *export) commit(db *database.Database) (err error) {
+	coll := db.AuditsExport()
+
+	_, err = coll.UpsertId(e.Id, e)
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+func getExport(db *database.Database, target string) (
+	exprt *export, err error) {
+
+	coll := db.AuditsExport()
+	exprt = &export{}
+
+	err = coll.FindOneId(target, exprt)
+	if err != nil {
+		if _, ok := err.(*database.NotFoundError); !ok {
+			return
+		}
+		err = nil
+		exprt = &export{
+			Id: target,
+		}
+	}
+
+	return
+}
+
+func getExports(db *database.Database) (exports []*export, err error) {
+	coll := db.AuditsExport()
+	exports = []*export{}
+
+	err = coll.Find(&bson.M{}).All(&exports)
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+func getEntries(db *database.Database, after int64) (
+	audits []*Audit, err error) {
+
+	coll := db.Audits()
+	audits = []*Audit{}
+
+	err = coll.Find(&bson.M{
+		"s": &bson.M{
+			"$gt": after,
+		},
+	}).Sort("s").Limit(exportLimit).All(&audits)
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+func exportStorage(db *database.Database) (err error) {
+	exprt, err := getExport(db, exportS3)
+	if err != nil {
+		return
+	}
+
+	audits, err := getEntries(db, exprt.Sequence)
+	if err != nil || len(audits) == 0 {
+		return
+	}
+
+	store, err := storage.Get(db, settings.Audit.ExportStorage)
+	if err != nil {
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	for _, adt := range audits {
+		data, e := json.Marshal(adt)
+		if e != nil {
+			err = &errortypes.ParseError{
+				errors.Wrap(e, "audit: Failed to marshal entry"),
+			}
+			return
+		}
+
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	first := audits[0]
+	last := audits[len(audits)-1]
+	key := fmt.Sprintf("%s%020d-%020d.jsonl",
+		settings.Audit.ExportPrefix, first.Sequence, last.Sequence)
+
+	client, err := minio.New(
+		store.Endpoint, store.AccessKey, store.SecretKey, !store.Insecure)
+	if err != nil {
+		err = &errortypes.ConnectionError{
+			errors.Wrap(err, "audit: Failed to connect to storage"),
+		}
+		return
+	}
+
+	_, err = client.PutObject(store.Bucket, key, buf, int64(buf.Len()),
+		minio.PutObjectOptions{
+			ContentType: "application/x-ndjson",
+		})
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "audit: Failed to write object"),
+		}
+		return
+	}
+
+	exprt.Sequence = last.Sequence
+	exprt.Hash = last.Hash
+
+	err = exprt.commit(db)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func exportSyslogEntries(db *database.Database) (err error) {
+	exprt, err := getExport(db, exportSyslog)
+	if err != nil {
+		return
+	}
+
+	audits, err := getEntries(db, exprt.Sequence)
+	if err != nil || len(audits) == 0 {
+		return
+	}
+
+	writer, err := syslog.Dial(settings.Audit.SyslogProtocol,
+		settings.Audit.SyslogAddress, syslog.LOG_AUTH|syslog.LOG_INFO,
+		"pritunl-cloud-audit")
+	if err != nil {
+		err = &errortypes.ConnectionError{
+			errors.Wrap(err, "audit: Failed to connect to syslog"),
+		}
+		return
+	}
+	defer writer.Close()
+
+	for _, adt := range audits {
+		data, e := json.Marshal(adt)
+		if e != nil {
+			err = &errortypes.ParseError{
+				errors.Wrap(e, "audit: Failed to marshal entry"),
+			}
+			break
+		}
+
+		_, err = writer.Write(data)
+		if err != nil {
+			err = &errortypes.WriteError{
+				errors.Wrap(err, "audit: Failed to write to syslog"),
+			}
+			break
+		}
+
+		exprt.Sequence = adt.Sequence
+		exprt.Hash = adt.Hash
+	}
+
+	e := exprt.commit(db)
+	if err == nil {
+		err = e
+	}
+
+	return
+}
+
+func Export(db *database.Database) (err error) {
+	if settings.Audit.ExportStorage != "" {
+		err = exportStorage(db)
+		if err != nil {
+			return
+		}
+	}
+
+	if settings.Audit.SyslogAddress != "" {
+		err = exportSyslogEntries(db)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
@@ -87,3 +87,36 @@ func New(db *database.Database, r *http.Request,
 
 	return
 }
+
+func GetAllPaged(db *database.Database, query *bson.M,
+	page, pageCount int) (audits []*Audit, count int, err error) {
+
+	coll := db.Audits()
+	audits = []*Audit{}
+
+	qury := coll.Find(query)
+
+	count, err = qury.Count()
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	skip := utils.Min(page*pageCount, utils.Max(0, count-pageCount))
+
+	cursor := qury.Sort("-t").Skip(skip).Limit(pageCount).Iter()
+
+	adt := &Audit{}
+	for cursor.Next(adt) {
+		audits = append(audits, adt)
+		adt = &Audit{}
+	}
+
+	err = cursor.Close()
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
@@ -0,0 +1,115 @@
+package audit
+
+import (
+	"fmt"
+	"github.com/pritunl/pritunl-cloud/database"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type VerifyError struct {
+	Sequence int64         `json:"sequence"`
+	Id       bson.ObjectId `json:"id"`
+	Error    string        `json:"error"`
+}
+
+type Verification struct {
+	Valid    bool           `json:"valid"`
+	Entries  int            `json:"entries"`
+	Sequence int64          `json:"sequence"`
+	Hash     string         `json:"hash"`
+	Errors   []*VerifyError `json:"errors"`
+}
+
+func (v *Verification) addError(adt *Audit, msg string) {
+	v.Valid = false
+	if len(v.Errors) < 100 {
+		v.Errors = append(v.Errors, &VerifyError{
+			Sequence: adt.Sequence,
+			Id:       adt.Id,
+			Error:    msg,
+		})
+	}
+}
+
+func Verify(db *database.Database) (verif *Verification, err error) {
+	coll := db.Audits()
+
+	verif = &Verification{
+		Valid:  true,
+		Errors: []*VerifyError{},
+	}
+
+	exports, err := getExports(db)
+	if err != nil {
+		return
+	}
+
+	exportHashes := map[int64]string{}
+	for _, exprt := range exports {
+		exportHashes[exprt.Sequence] = ""
+	}
+
+	cursor := coll.Find(&bson.M{
+		"s": &bson.M{
+			"$gt": 0,
+		},
+	}).Sort("s").Iter()
+
+	adt := &Audit{}
+	for cursor.Next(adt) {
+		verif.Entries += 1
+
+		if adt.Sequence != verif.Sequence+1 {
+			verif.addError(adt, fmt.Sprintf(
+				"Missing entries %d to %d",
+				verif.Sequence+1, adt.Sequence-1))
+		}
+
+		if adt.PrevHash != verif.Hash {
+			verif.addError(adt, "Previous hash mismatch")
+		}
+
+		hash, e := adt.GetHash()
+		if e != nil {
+			err = e
+			cursor.Close()
+			return
+		}
+
+		if hash != adt.Hash {
+			verif.addError(adt, "Entry hash mismatch")
+		}
+
+		verif.Sequence = adt.Sequence
+		verif.Hash = adt.Hash
+		if _, ok := exportHashes[adt.Sequence]; ok {
+			exportHashes[adt.Sequence] = adt.Hash
+		}
+		adt = &Audit{}
+	}
+
+	err = cursor.Close()
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	for _, exprt := range exports {
+		if exprt.Sequence > verif.Sequence {
+			verif.addError(&Audit{
+				Sequence: exprt.Sequence,
+			}, fmt.Sprintf(
+				"Entries after %d missing, %s export reached %d",
+				verif.Sequence, exprt.Id, exprt.Sequence))
+		} else if exprt.Sequence > 0 &&
+			exportHashes[exprt.Sequence] != exprt.Hash {
+
+			verif.addError(&Audit{
+				Sequence: exprt.Sequence,
+			}, fmt.Sprintf(
+				"Entry hash does not match %s export", exprt.Id))
+		}
+	}
+
+	return
+}
@@ -77,8 +77,11 @@ func (f *Authority) Insert(db *database.Database) (err error) {
 		return
 	}
 
+	f.Id = bson.NewObjectId()
+
 	err = coll.Insert(f)
 	if err != nil {
+		f.Id = ""
 		err = database.ParseError(err)
 		return
 	}
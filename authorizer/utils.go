@@ -79,6 +79,11 @@ func AuthorizeUser(db *database.Database, w http.ResponseWriter,
 			return
 		}
 
+		err = sig.Validate(db)
+		if err != nil {
+			return
+		}
+
 		authr = &Authorizer{
 			typ: User,
 			sig: sig,
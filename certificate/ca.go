@@ -0,0 +1,252 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"math/big"
+	"time"
+)
+
+func newSerial() (serial *big.Int, err error) {
+	serialLimit := new(big.Int).Lsh(big.NewInt(1), 128)
+	serial, err = rand.Int(rand.Reader, serialLimit)
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(
+				err,
+				"certificate: Failed to generate certificate serial",
+			),
+		}
+		return
+	}
+
+	return
+}
+
+func encodeCert(certByt []byte, certKey *ecdsa.PrivateKey) (
+	certPem, keyPem string, err error) {
+
+	certKeyByte, err := x509.MarshalECPrivateKey(certKey)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "certificate: Failed to parse private key"),
+		}
+		return
+	}
+
+	keyPem = string(pem.EncodeToMemory(&pem.Block{
+		Type:  "EC PRIVATE KEY",
+		Bytes: certKeyByte,
+	}))
+
+	certPem = string(pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: certByt,
+	}))
+
+	return
+}
+
+func decodeCert(certPem, keyPem string) (cert *x509.Certificate,
+	certKey *ecdsa.PrivateKey, err error) {
+
+	certBlock, _ := pem.Decode([]byte(certPem))
+	if certBlock == nil {
+		err = &errortypes.ParseError{
+			errors.New("certificate: Failed to decode certificate"),
+		}
+		return
+	}
+
+	cert, err = x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "certificate: Failed to parse certificate"),
+		}
+		return
+	}
+
+	if keyPem == "" {
+		return
+	}
+
+	keyBlock, _ := pem.Decode([]byte(keyPem))
+	if keyBlock == nil {
+		err = &errortypes.ParseError{
+			errors.New("certificate: Failed to decode private key"),
+		}
+		return
+	}
+
+	certKey, err = x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "certificate: Failed to parse private key"),
+		}
+		return
+	}
+
+	return
+}
+
+func NewCa(name string, lifetime time.Duration) (
+	certPem, keyPem string, err error) {
+
+	certKey, err := ecdsa.GenerateKey(
+		elliptic.P384(),
+		rand.Reader,
+	)
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "certificate: Failed to generate private key"),
+		}
+		return
+	}
+
+	serial, err := newSerial()
+	if err != nil {
+		return
+	}
+
+	certTempl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization: []string{"Pritunl Cloud"},
+			CommonName:   name,
+		},
+		NotBefore:             time.Now().Add(-24 * time.Hour),
+		NotAfter:              time.Now().Add(lifetime),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		SignatureAlgorithm:    x509.ECDSAWithSHA256,
+	}
+
+	certByt, err := x509.CreateCertificate(rand.Reader, certTempl, certTempl,
+		certKey.Public(), certKey)
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "certificate: Failed to create certificate"),
+		}
+		return
+	}
+
+	certPem, keyPem, err = encodeCert(certByt, certKey)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func NewCert(caCertPem, caKeyPem, name string, lifetime time.Duration) (
+	certPem, keyPem string, err error) {
+
+	caCert, caKey, err := decodeCert(caCertPem, caKeyPem)
+	if err != nil {
+		return
+	}
+
+	certKey, err := ecdsa.GenerateKey(
+		elliptic.P384(),
+		rand.Reader,
+	)
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "certificate: Failed to generate private key"),
+		}
+		return
+	}
+
+	serial, err := newSerial()
+	if err != nil {
+		return
+	}
+
+	notAfter := time.Now().Add(lifetime)
+	if notAfter.After(caCert.NotAfter) {
+		notAfter = caCert.NotAfter
+	}
+
+	certTempl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization: []string{"Pritunl Cloud"},
+			CommonName:   name,
+		},
+		DNSNames:  []string{name},
+		NotBefore: time.Now().Add(-24 * time.Hour),
+		NotAfter:  notAfter,
+		KeyUsage: x509.KeyUsageKeyEncipherment |
+			x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{
+			x509.ExtKeyUsageServerAuth,
+			x509.ExtKeyUsageClientAuth,
+		},
+		BasicConstraintsValid: true,
+		SignatureAlgorithm:    x509.ECDSAWithSHA256,
+	}
+
+	certByt, err := x509.CreateCertificate(rand.Reader, certTempl, caCert,
+		certKey.Public(), caKey)
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "certificate: Failed to create certificate"),
+		}
+		return
+	}
+
+	certPem, keyPem, err = encodeCert(certByt, certKey)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func GetExpiration(certPem string) (expires time.Time, err error) {
+	cert, _, err := decodeCert(certPem, "")
+	if err != nil {
+		return
+	}
+
+	expires = cert.NotAfter
+
+	return
+}
+
+func IsIssuer(certPem, caCertPem string) bool {
+	cert, _, err := decodeCert(certPem, "")
+	if err != nil {
+		return false
+	}
+
+	caCert, _, err := decodeCert(caCertPem, "")
+	if err != nil {
+		return false
+	}
+
+	err = cert.CheckSignatureFrom(caCert)
+	if err != nil {
+		return false
+	}
+
+	return true
+}
+
+func GetSubject(certPem string) (subject string, err error) {
+	cert, _, err := decodeCert(certPem, "")
+	if err != nil {
+		return
+	}
+
+	subject = cert.Subject.String()
+
+	return
+}
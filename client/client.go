@@ -0,0 +1,201 @@
+package client
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha512"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/constants"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"gopkg.in/mgo.v2/bson"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type idsData struct {
+	Ids   []bson.ObjectId `json:"ids"`
+	State string          `json:"state,omitempty"`
+}
+
+type Client struct {
+	Url          string
+	Token        string
+	Secret       string
+	Organization string
+	Insecure     bool
+	client       *http.Client
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.client == nil {
+		transport := &http.Transport{}
+		if c.Insecure {
+			transport.TLSClientConfig = &tls.Config{
+				InsecureSkipVerify: true,
+			}
+		}
+
+		c.client = &http.Client{
+			Transport: transport,
+			Timeout:   60 * time.Second,
+		}
+	}
+
+	return c.client
+}
+
+func (c *Client) sign(header http.Header, method, path string) (
+	err error) {
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	nonce, err := utils.RandStr(32)
+	if err != nil {
+		return
+	}
+
+	authString := strings.Join([]string{
+		c.Token,
+		timestamp,
+		nonce,
+		method,
+		path,
+	}, "&")
+
+	hashFunc := hmac.New(sha512.New, []byte(c.Secret))
+	hashFunc.Write([]byte(authString))
+	sig := base64.StdEncoding.EncodeToString(hashFunc.Sum(nil))
+
+	header.Set("Pritunl-Cloud-Token", c.Token)
+	header.Set("Pritunl-Cloud-Timestamp", timestamp)
+	header.Set("Pritunl-Cloud-Nonce", nonce)
+	header.Set("Pritunl-Cloud-Signature", sig)
+	if c.Organization != "" {
+		header.Set("Organization", c.Organization)
+	}
+
+	return
+}
+
+func (c *Client) parseUrl(path string) (u *url.URL, err error) {
+	if c.Url == "" || c.Token == "" || c.Secret == "" {
+		err = &errortypes.AuthenticationError{
+			errors.New("client: Missing API URL, token or secret"),
+		}
+		return
+	}
+
+	u, err = url.Parse(
+		strings.TrimRight(c.Url, "/") + constants.ApiPrefix + path)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "client: Failed to parse API URL"),
+		}
+		return
+	}
+
+	return
+}
+
+func (c *Client) Do(method, path string, query url.Values,
+	input, output interface{}) (err error) {
+
+	u, err := c.parseUrl(path)
+	if err != nil {
+		return
+	}
+	if query != nil {
+		u.RawQuery = query.Encode()
+	}
+
+	var body io.Reader
+	if input != nil {
+		data, e := json.Marshal(input)
+		if e != nil {
+			err = &errortypes.ParseError{
+				errors.Wrap(e, "client: Failed to marshal request"),
+			}
+			return
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		err = &errortypes.RequestError{
+			errors.Wrap(err, "client: Failed to create request"),
+		}
+		return
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if input != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	err = c.sign(req.Header, req.Method, req.URL.Path)
+	if err != nil {
+		return
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		err = &errortypes.RequestError{
+			errors.Wrap(err, "client: Request failed"),
+		}
+		return
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "client: Failed to read response"),
+		}
+		return
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err = newError(resp.StatusCode, data)
+		return
+	}
+
+	if output != nil && len(data) > 0 {
+		err = json.Unmarshal(data, output)
+		if err != nil {
+			err = &errortypes.ParseError{
+				errors.Wrap(err, "client: Failed to parse response"),
+			}
+			return
+		}
+	}
+
+	return
+}
+
+func (c *Client) Get(path string, query url.Values,
+	output interface{}) error {
+
+	return c.Do("GET", path, query, nil, output)
+}
+
+func (c *Client) Post(path string, input, output interface{}) error {
+	return c.Do("POST", path, nil, input, output)
+}
+
+func (c *Client) Put(path string, input, output interface{}) error {
+	return c.Do("PUT", path, nil, input, output)
+}
+
+func (c *Client) Delete(path string, input interface{}) error {
+	return c.Do("DELETE", path, nil, input, nil)
+}
@@ -0,0 +1,5 @@
+package client
+
+const (
+	DefaultPageCount = 100
+)
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"github.com/pritunl/pritunl-cloud/disk"
+	"gopkg.in/mgo.v2/bson"
+	"net/url"
+)
+
+type disksData struct {
+	Disks []*disk.Disk `json:"disks"`
+	Count int          `json:"count"`
+}
+
+func (c *Client) Disk(dskId bson.ObjectId) (
+	dsk *disk.Disk, err error) {
+
+	dsk = &disk.Disk{}
+	err = c.Get("/disk/"+dskId.Hex(), nil, dsk)
+	if err != nil {
+		dsk = nil
+		return
+	}
+
+	return
+}
+
+func (c *Client) DisksPage(query url.Values, page, pageCount int) (
+	dsks []*disk.Disk, count int, err error) {
+
+	data := &disksData{}
+	err = c.Get("/disk", pageQuery(query, page, pageCount), data)
+	if err != nil {
+		return
+	}
+
+	dsks = data.Disks
+	count = data.Count
+
+	return
+}
+
+func (c *Client) Disks(query url.Values) (
+	dsks []*disk.Disk, err error) {
+
+	dsks = []*disk.Disk{}
+
+	for page := 0; ; page++ {
+		pageItems, count, e := c.DisksPage(
+			query, page, DefaultPageCount)
+		if e != nil {
+			err = e
+			return
+		}
+
+		pageItems = pageItems[pageStart(len(dsks), len(pageItems), count):]
+		dsks = append(dsks, pageItems...)
+		if lastPage(len(dsks), len(pageItems), count) {
+			break
+		}
+	}
+
+	return
+}
+
+func (c *Client) CreateDisk(dsk *disk.Disk) (
+	newdsk *disk.Disk, err error) {
+
+	newdsk = &disk.Disk{}
+	err = c.Post("/disk", dsk, newdsk)
+	if err != nil {
+		newdsk = nil
+		return
+	}
+
+	return
+}
+
+func (c *Client) UpdateDisk(dsk *disk.Disk) (
+	newdsk *disk.Disk, err error) {
+
+	newdsk = &disk.Disk{}
+	err = c.Put("/disk/"+dsk.Id.Hex(), dsk, newdsk)
+	if err != nil {
+		newdsk = nil
+		return
+	}
+
+	return
+}
+
+func (c *Client) DeleteDisk(dskId bson.ObjectId) error {
+	return c.Delete("/disk/"+dskId.Hex(), nil)
+}
+
+func (c *Client) DeleteDisks(dskIds []bson.ObjectId) error {
+	return c.Delete("/disk", dskIds)
+}
+
+func (c *Client) SnapshotDisks(dskIds []bson.ObjectId) (err error) {
+	err = c.Put("/disk", &idsData{
+		Ids:   dskIds,
+		State: disk.Snapshot,
+	}, nil)
+	if err != nil {
+		return
+	}
+
+	return
+}
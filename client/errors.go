@@ -0,0 +1,63 @@
+package client
+
+import (
+	"encoding/json"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"net/http"
+)
+
+// Error returned for non-2xx API responses, Data is set when the server
+// responded with a validation error
+type Error struct {
+	errors.DropboxError
+	StatusCode int
+	Data       *errortypes.ErrorData
+}
+
+func newError(statusCode int, body []byte) (err *Error) {
+	err = &Error{
+		StatusCode: statusCode,
+	}
+
+	msg := http.StatusText(statusCode)
+
+	errData := &errortypes.ErrorData{}
+	e := json.Unmarshal(body, errData)
+	if e == nil && errData.Error != "" {
+		err.Data = errData
+		msg = errData.Message
+	}
+
+	err.DropboxError = errors.Newf("client: Request error %d %s",
+		statusCode, msg)
+
+	return
+}
+
+func GetErrorData(err error) *errortypes.ErrorData {
+	if e, ok := err.(*Error); ok {
+		return e.Data
+	}
+	return nil
+}
+
+func GetStatusCode(err error) int {
+	if e, ok := err.(*Error); ok {
+		return e.StatusCode
+	}
+	return 0
+}
+
+// Ownership failures are returned as 405 by the user API
+func IsNotFound(err error) bool {
+	switch GetStatusCode(err) {
+	case 404, 405:
+		return true
+	}
+	return false
+}
+
+func IsUnauthorized(err error) bool {
+	return GetStatusCode(err) == 401
+}
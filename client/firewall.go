@@ -0,0 +1,97 @@
+package client
+
+import (
+	"github.com/pritunl/pritunl-cloud/firewall"
+	"gopkg.in/mgo.v2/bson"
+	"net/url"
+)
+
+type firewallsData struct {
+	Firewalls []*firewall.Firewall `json:"firewalls"`
+	Count     int                  `json:"count"`
+}
+
+func (c *Client) Firewall(fireId bson.ObjectId) (
+	fire *firewall.Firewall, err error) {
+
+	fire = &firewall.Firewall{}
+	err = c.Get("/firewall/"+fireId.Hex(), nil, fire)
+	if err != nil {
+		fire = nil
+		return
+	}
+
+	return
+}
+
+func (c *Client) FirewallsPage(query url.Values, page, pageCount int) (
+	fires []*firewall.Firewall, count int, err error) {
+
+	data := &firewallsData{}
+	err = c.Get("/firewall", pageQuery(query, page, pageCount), data)
+	if err != nil {
+		return
+	}
+
+	fires = data.Firewalls
+	count = data.Count
+
+	return
+}
+
+func (c *Client) Firewalls(query url.Values) (
+	fires []*firewall.Firewall, err error) {
+
+	fires = []*firewall.Firewall{}
+
+	for page := 0; ; page++ {
+		pageItems, count, e := c.FirewallsPage(
+			query, page, DefaultPageCount)
+		if e != nil {
+			err = e
+			return
+		}
+
+		pageItems = pageItems[pageStart(len(fires), len(pageItems), count):]
+		fires = append(fires, pageItems...)
+		if lastPage(len(fires), len(pageItems), count) {
+			break
+		}
+	}
+
+	return
+}
+
+func (c *Client) CreateFirewall(fire *firewall.Firewall) (
+	newfire *firewall.Firewall, err error) {
+
+	newfire = &firewall.Firewall{}
+	err = c.Post("/firewall", fire, newfire)
+	if err != nil {
+		newfire = nil
+		return
+	}
+
+	return
+}
+
+func (c *Client) UpdateFirewall(fire *firewall.Firewall) (
+	newfire *firewall.Firewall, err error) {
+
+	newfire = &firewall.Firewall{}
+	err = c.Put("/firewall/"+fire.Id.Hex(), fire, newfire)
+	if err != nil {
+		newfire = nil
+		return
+	}
+
+	return
+}
+
+func (c *Client) DeleteFirewall(fireId bson.ObjectId) error {
+	return c.Delete("/firewall/"+fireId.Hex(), nil)
+}
+
+func (c *Client) DeleteFirewalls(fireIds []bson.ObjectId) error {
+	return c.Delete("/firewall", fireIds)
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"github.com/pritunl/pritunl-cloud/instance"
+	"gopkg.in/mgo.v2/bson"
+	"net/url"
+)
+
+type instancesData struct {
+	Instances []*instance.Instance `json:"instances"`
+	Count     int                  `json:"count"`
+}
+
+type instanceCreateData struct {
+	*instance.Instance
+	Count int `json:"count"`
+}
+
+func (c *Client) Instance(instId bson.ObjectId) (
+	inst *instance.Instance, err error) {
+
+	inst = &instance.Instance{}
+	err = c.Get("/instance/"+instId.Hex(), nil, inst)
+	if err != nil {
+		inst = nil
+		return
+	}
+
+	return
+}
+
+func (c *Client) InstancesPage(query url.Values, page, pageCount int) (
+	insts []*instance.Instance, count int, err error) {
+
+	data := &instancesData{}
+	err = c.Get("/instance", pageQuery(query, page, pageCount), data)
+	if err != nil {
+		return
+	}
+
+	insts = data.Instances
+	count = data.Count
+
+	return
+}
+
+func (c *Client) Instances(query url.Values) (
+	insts []*instance.Instance, err error) {
+
+	insts = []*instance.Instance{}
+
+	for page := 0; ; page++ {
+		pageInsts, count, e := c.InstancesPage(
+			query, page, DefaultPageCount)
+		if e != nil {
+			err = e
+			return
+		}
+
+		pageInsts = pageInsts[pageStart(len(insts), len(pageInsts), count):]
+		insts = append(insts, pageInsts...)
+		if lastPage(len(insts), len(pageInsts), count) {
+			break
+		}
+	}
+
+	return
+}
+
+func (c *Client) CreateInstance(inst *instance.Instance) (
+	newInst *instance.Instance, err error) {
+
+	newInst = &instance.Instance{}
+	err = c.Post("/instance", inst, newInst)
+	if err != nil {
+		newInst = nil
+		return
+	}
+
+	return
+}
+
+func (c *Client) CreateInstances(inst *instance.Instance, count int) (
+	insts []*instance.Instance, err error) {
+
+	if count <= 1 {
+		newInst, e := c.CreateInstance(inst)
+		if e != nil {
+			err = e
+			return
+		}
+
+		insts = []*instance.Instance{newInst}
+		return
+	}
+
+	insts = []*instance.Instance{}
+	err = c.Post("/instance", &instanceCreateData{
+		Instance: inst,
+		Count:    count,
+	}, &insts)
+	if err != nil {
+		insts = nil
+		return
+	}
+
+	return
+}
+
+func (c *Client) UpdateInstance(inst *instance.Instance) (
+	newInst *instance.Instance, err error) {
+
+	newInst = &instance.Instance{}
+	err = c.Put("/instance/"+inst.Id.Hex(), inst, newInst)
+	if err != nil {
+		newInst = nil
+		return
+	}
+
+	return
+}
+
+func (c *Client) SetInstancesState(instIds []bson.ObjectId,
+	state string) (err error) {
+
+	err = c.Put("/instance", &idsData{
+		Ids:   instIds,
+		State: state,
+	}, nil)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (c *Client) StartInstances(instIds []bson.ObjectId) error {
+	return c.SetInstancesState(instIds, instance.Start)
+}
+
+func (c *Client) StopInstances(instIds []bson.ObjectId) error {
+	return c.SetInstancesState(instIds, instance.Stop)
+}
+
+func (c *Client) DeleteInstance(instId bson.ObjectId) error {
+	return c.Delete("/instance/"+instId.Hex(), nil)
+}
+
+func (c *Client) DeleteInstances(instIds []bson.ObjectId) error {
+	return c.Delete("/instance", instIds)
+}
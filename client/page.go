@@ -0,0 +1,41 @@
+package client
+
+import (
+	"net/url"
+	"strconv"
+)
+
+func pageQuery(query url.Values, page, pageCount int) (
+	pageQuery url.Values) {
+
+	pageQuery = url.Values{}
+	for key, vals := range query {
+		pageQuery[key] = vals
+	}
+
+	if pageCount <= 0 {
+		pageCount = DefaultPageCount
+	}
+
+	pageQuery.Set("page", strconv.Itoa(page))
+	pageQuery.Set("page_count", strconv.Itoa(pageCount))
+
+	return
+}
+
+// Server clamps the skip to the last page, skip items already loaded
+func pageStart(total, pageLen, count int) int {
+	start := total + pageLen - count
+	if start < 0 {
+		return 0
+	}
+	if start > pageLen {
+		return pageLen
+	}
+	return start
+}
+
+// Server clamps the skip to the last page, stop once all items are loaded
+func lastPage(total, pageLen, count int) bool {
+	return pageLen < DefaultPageCount || total >= count
+}
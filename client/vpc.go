@@ -0,0 +1,97 @@
+package client
+
+import (
+	"github.com/pritunl/pritunl-cloud/vpc"
+	"gopkg.in/mgo.v2/bson"
+	"net/url"
+)
+
+type vpcsData struct {
+	Vpcs  []*vpc.Vpc `json:"vpcs"`
+	Count int        `json:"count"`
+}
+
+func (c *Client) Vpc(vcId bson.ObjectId) (
+	vc *vpc.Vpc, err error) {
+
+	vc = &vpc.Vpc{}
+	err = c.Get("/vpc/"+vcId.Hex(), nil, vc)
+	if err != nil {
+		vc = nil
+		return
+	}
+
+	return
+}
+
+func (c *Client) VpcsPage(query url.Values, page, pageCount int) (
+	vcs []*vpc.Vpc, count int, err error) {
+
+	data := &vpcsData{}
+	err = c.Get("/vpc", pageQuery(query, page, pageCount), data)
+	if err != nil {
+		return
+	}
+
+	vcs = data.Vpcs
+	count = data.Count
+
+	return
+}
+
+func (c *Client) Vpcs(query url.Values) (
+	vcs []*vpc.Vpc, err error) {
+
+	vcs = []*vpc.Vpc{}
+
+	for page := 0; ; page++ {
+		pageItems, count, e := c.VpcsPage(
+			query, page, DefaultPageCount)
+		if e != nil {
+			err = e
+			return
+		}
+
+		pageItems = pageItems[pageStart(len(vcs), len(pageItems), count):]
+		vcs = append(vcs, pageItems...)
+		if lastPage(len(vcs), len(pageItems), count) {
+			break
+		}
+	}
+
+	return
+}
+
+func (c *Client) CreateVpc(vc *vpc.Vpc) (
+	newvc *vpc.Vpc, err error) {
+
+	newvc = &vpc.Vpc{}
+	err = c.Post("/vpc", vc, newvc)
+	if err != nil {
+		newvc = nil
+		return
+	}
+
+	return
+}
+
+func (c *Client) UpdateVpc(vc *vpc.Vpc) (
+	newvc *vpc.Vpc, err error) {
+
+	newvc = &vpc.Vpc{}
+	err = c.Put("/vpc/"+vc.Id.Hex(), vc, newvc)
+	if err != nil {
+		newvc = nil
+		return
+	}
+
+	return
+}
+
+func (c *Client) DeleteVpc(vcId bson.ObjectId) error {
+	return c.Delete("/vpc/"+vcId.Hex(), nil)
+}
+
+func (c *Client) DeleteVpcs(vcIds []bson.ObjectId) error {
+	return c.Delete("/vpc", vcIds)
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/gorilla/websocket"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/event"
+	"net/http"
+	"time"
+)
+
+const (
+	watchReadTimeout = 90 * time.Second
+)
+
+// Watch listens to the event websocket and calls handler with each
+// dispatch event until handler returns false or the connection fails
+func (c *Client) Watch(handler func(evt *event.Event) bool) (err error) {
+	u, err := c.parseUrl("/event")
+	if err != nil {
+		return
+	}
+
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+		break
+	default:
+		u.Scheme = "ws"
+	}
+
+	header := http.Header{}
+	err = c.sign(header, "GET", u.Path)
+	if err != nil {
+		return
+	}
+
+	dialer := &websocket.Dialer{
+		HandshakeTimeout: 30 * time.Second,
+	}
+	if c.Insecure {
+		dialer.TLSClientConfig = &tls.Config{
+			InsecureSkipVerify: true,
+		}
+	}
+
+	conn, resp, err := dialer.Dial(u.String(), header)
+	if err != nil {
+		if resp != nil {
+			err = newError(resp.StatusCode, nil)
+		} else {
+			err = &errortypes.ConnectionError{
+				errors.Wrap(err, "client: Failed to connect to event socket"),
+			}
+		}
+		return
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(watchReadTimeout))
+	conn.SetPingHandler(func(data string) (err error) {
+		conn.SetReadDeadline(time.Now().Add(watchReadTimeout))
+		err = conn.WriteControl(websocket.PongMessage, []byte(data),
+			time.Now().Add(10*time.Second))
+		return
+	})
+
+	for {
+		_, data, e := conn.ReadMessage()
+		if e != nil {
+			if websocket.IsCloseError(e, websocket.CloseNormalClosure) {
+				return
+			}
+
+			err = &errortypes.ConnectionError{
+				errors.Wrap(e, "client: Event socket read failed"),
+			}
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(watchReadTimeout))
+
+		evt := &event.Event{
+			Data: &event.Dispatch{},
+		}
+		e = json.Unmarshal(data, evt)
+		if e != nil {
+			err = &errortypes.ParseError{
+				errors.Wrap(e, "client: Failed to parse event"),
+			}
+			return
+		}
+
+		if !handler(evt) {
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(
+					websocket.CloseNormalClosure, ""),
+				time.Now().Add(10*time.Second))
+			return
+		}
+	}
+}
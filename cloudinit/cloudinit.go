@@ -106,7 +106,7 @@ func getUserData(db *database.Database, inst *instance.Instance,
 		return
 	}
 
-	if len(authrs) == 0 {
+	if len(authrs) == 0 && inst.UserData == "" {
 		return
 	}
 
@@ -155,6 +155,10 @@ func getUserData(db *database.Database, inst *instance.Instance,
 		items = append(items, fmt.Sprintf(cloudScriptTmpl, cloudScript))
 	}
 
+	if inst.UserData != "" {
+		items = append(items, inst.UserData)
+	}
+
 	buffer := &bytes.Buffer{}
 	message := multipart.NewWriter(buffer)
 	for _, item := range items {
@@ -235,6 +239,13 @@ func getNetData(db *database.Database, inst *instance.Instance,
 		return
 	}
 
+	if inst.StaticIp != "" {
+		err = vc.ReserveIp(db, inst.Id, inst.StaticIp)
+		if err != nil {
+			return
+		}
+	}
+
 	addr, err := vc.GetIp(db, vpc.Instance, inst.Id)
 	if err != nil {
 		return
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/audit"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+)
+
+func VerifyAudit() (err error) {
+	db := database.GetDatabase()
+	defer db.Close()
+
+	verif, err := audit.Verify(db)
+	if err != nil {
+		return
+	}
+
+	for _, verifErr := range verif.Errors {
+		logrus.WithFields(logrus.Fields{
+			"sequence": verifErr.Sequence,
+			"audit_id": verifErr.Id.Hex(),
+		}).Error("cmd.audit: " + verifErr.Error)
+	}
+
+	if !verif.Valid {
+		err = &errortypes.VerificationError{
+			errors.New("cmd.audit: Audit chain verification failed"),
+		}
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"entries":  verif.Entries,
+		"sequence": verif.Sequence,
+		"hash":     verif.Hash,
+	}).Info("cmd.audit: Audit chain verified")
+
+	return
+}
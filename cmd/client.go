@@ -0,0 +1,460 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/client"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/image"
+	"github.com/pritunl/pritunl-cloud/instance"
+	"gopkg.in/mgo.v2/bson"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const clientHelp = `
+Usage: pritunl-cloud RESOURCE ACTION [OPTIONS] [ID...]
+
+Resources:
+  instance  list, create, start, stop
+  disk      list, snapshot
+  vpc       list
+  firewall  list
+  image     list
+
+Options:
+  -url           API URL (PRITUNL_CLOUD_URL)
+  -token         API token (PRITUNL_CLOUD_TOKEN)
+  -secret        API secret (PRITUNL_CLOUD_SECRET)
+  -organization  Organization ID (PRITUNL_CLOUD_ORGANIZATION)
+  -insecure      Skip TLS verification
+  -json          Output JSON
+`
+
+type clientCmd struct {
+	flags  *flag.FlagSet
+	client *client.Client
+	json   bool
+}
+
+func newClientCmd(name string) (cmd *clientCmd) {
+	cmd = &clientCmd{
+		flags:  flag.NewFlagSet(name, flag.ExitOnError),
+		client: &client.Client{},
+	}
+
+	cmd.flags.StringVar(&cmd.client.Url, "url",
+		os.Getenv("PRITUNL_CLOUD_URL"), "API URL")
+	cmd.flags.StringVar(&cmd.client.Token, "token",
+		os.Getenv("PRITUNL_CLOUD_TOKEN"), "API token")
+	cmd.flags.StringVar(&cmd.client.Secret, "secret",
+		os.Getenv("PRITUNL_CLOUD_SECRET"), "API secret")
+	cmd.flags.StringVar(&cmd.client.Organization, "organization",
+		os.Getenv("PRITUNL_CLOUD_ORGANIZATION"), "Organization ID")
+	cmd.flags.BoolVar(&cmd.client.Insecure, "insecure",
+		os.Getenv("PRITUNL_CLOUD_INSECURE") == "true",
+		"Skip TLS verification")
+	cmd.flags.BoolVar(&cmd.json, "json", false, "Output JSON")
+
+	return
+}
+
+func (c *clientCmd) parse(args []string) (err error) {
+	err = c.flags.Parse(args)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "cmd.client: Failed to parse options"),
+		}
+		return
+	}
+
+	return
+}
+
+func (c *clientCmd) ids() (ids []bson.ObjectId, err error) {
+	ids = []bson.ObjectId{}
+
+	for _, arg := range c.flags.Args() {
+		if !bson.IsObjectIdHex(arg) {
+			err = &errortypes.ParseError{
+				errors.Newf("cmd.client: Invalid ID '%s'", arg),
+			}
+			return
+		}
+		ids = append(ids, bson.ObjectIdHex(arg))
+	}
+
+	if len(ids) == 0 {
+		err = &errortypes.ParseError{
+			errors.New("cmd.client: Missing resource ID"),
+		}
+		return
+	}
+
+	return
+}
+
+func (c *clientCmd) output(data interface{}, header []string,
+	rows [][]string) (err error) {
+
+	if c.json {
+		output, e := json.MarshalIndent(data, "", "  ")
+		if e != nil {
+			err = &errortypes.ParseError{
+				errors.Wrap(e, "cmd.client: Failed to marshal output"),
+			}
+			return
+		}
+
+		fmt.Println(string(output))
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "cmd.client: Failed to write output"),
+		}
+		return
+	}
+
+	return
+}
+
+func instanceList(cmd *clientCmd) (err error) {
+	insts, err := cmd.client.Instances(nil)
+	if err != nil {
+		return
+	}
+
+	rows := [][]string{}
+	for _, inst := range insts {
+		rows = append(rows, []string{
+			inst.Id.Hex(),
+			inst.Name,
+			inst.Status,
+			strings.Join(inst.PublicIps, ","),
+			strings.Join(inst.PrivateIps, ","),
+			strconv.Itoa(inst.Processors),
+			strconv.Itoa(inst.Memory),
+		})
+	}
+
+	err = cmd.output(insts, []string{
+		"ID", "NAME", "STATUS", "PUBLIC IP", "PRIVATE IP", "CPU", "MEMORY",
+	}, rows)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+type instanceCreateData struct {
+	Organization string   `json:"organization,omitempty"`
+	Name         string   `json:"name"`
+	Zone         string   `json:"zone"`
+	Vpc          string   `json:"vpc"`
+	Node         string   `json:"node"`
+	Image        string   `json:"image"`
+	Domain       string   `json:"domain,omitempty"`
+	StaticIp     string   `json:"static_ip"`
+	InitDiskSize int      `json:"init_disk_size"`
+	Memory       int      `json:"memory"`
+	Processors   int      `json:"processors"`
+	NetworkRoles []string `json:"network_roles"`
+	UserData     string   `json:"user_data"`
+	Count        int      `json:"count"`
+}
+
+func instanceCreate(cmd *clientCmd, args []string) (err error) {
+	data := &instanceCreateData{}
+	roles := ""
+	userDataPath := ""
+
+	cmd.flags.StringVar(&data.Name, "name", "", "Instance name")
+	cmd.flags.StringVar(&data.Zone, "zone", "", "Zone ID")
+	cmd.flags.StringVar(&data.Node, "node", "", "Node ID")
+	cmd.flags.StringVar(&data.Vpc, "vpc", "", "VPC ID")
+	cmd.flags.StringVar(&data.Image, "image", "", "Image ID")
+	cmd.flags.StringVar(&data.Domain, "domain", "", "Domain ID")
+	cmd.flags.StringVar(&data.StaticIp, "static-ip", "", "Static IP")
+	cmd.flags.IntVar(&data.InitDiskSize, "disk-size", 10, "Disk size in GB")
+	cmd.flags.IntVar(&data.Memory, "memory", 1024, "Memory in MB")
+	cmd.flags.IntVar(&data.Processors, "processors", 1, "Processor count")
+	cmd.flags.IntVar(&data.Count, "count", 1, "Instance count")
+	cmd.flags.StringVar(&roles, "roles", "", "Comma separated network roles")
+	cmd.flags.StringVar(&userDataPath, "user-data", "",
+		"Path to cloud-init user data")
+
+	err = cmd.parse(args)
+	if err != nil {
+		return
+	}
+
+	data.Organization = cmd.client.Organization
+	data.NetworkRoles = []string{}
+	for _, role := range strings.Split(roles, ",") {
+		role = strings.TrimSpace(role)
+		if role != "" {
+			data.NetworkRoles = append(data.NetworkRoles, role)
+		}
+	}
+
+	if userDataPath != "" {
+		userData, e := ioutil.ReadFile(userDataPath)
+		if e != nil {
+			err = &errortypes.ReadError{
+				errors.Wrap(e, "cmd.client: Failed to read user data"),
+			}
+			return
+		}
+		data.UserData = string(userData)
+	}
+
+	insts := []*instance.Instance{}
+	if data.Count > 1 {
+		err = cmd.client.Post("/instance", data, &insts)
+	} else {
+		inst := &instance.Instance{}
+		err = cmd.client.Post("/instance", data, inst)
+		insts = append(insts, inst)
+	}
+	if err != nil {
+		return
+	}
+
+	rows := [][]string{}
+	for _, inst := range insts {
+		rows = append(rows, []string{
+			inst.Id.Hex(),
+			inst.Name,
+			inst.State,
+		})
+	}
+
+	err = cmd.output(insts, []string{"ID", "NAME", "STATE"}, rows)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func instanceState(cmd *clientCmd, state string) (err error) {
+	ids, err := cmd.ids()
+	if err != nil {
+		return
+	}
+
+	err = cmd.client.SetInstancesState(ids, state)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func diskList(cmd *clientCmd) (err error) {
+	dsks, err := cmd.client.Disks(nil)
+	if err != nil {
+		return
+	}
+
+	rows := [][]string{}
+	for _, dsk := range dsks {
+		inst := ""
+		if dsk.Instance != "" {
+			inst = dsk.Instance.Hex()
+		}
+
+		rows = append(rows, []string{
+			dsk.Id.Hex(),
+			dsk.Name,
+			dsk.State,
+			inst,
+			dsk.Index,
+			strconv.Itoa(dsk.Size),
+		})
+	}
+
+	err = cmd.output(dsks, []string{
+		"ID", "NAME", "STATE", "INSTANCE", "INDEX", "SIZE",
+	}, rows)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func diskSnapshot(cmd *clientCmd) (err error) {
+	ids, err := cmd.ids()
+	if err != nil {
+		return
+	}
+
+	err = cmd.client.SnapshotDisks(ids)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func vpcList(cmd *clientCmd) (err error) {
+	vpcs, err := cmd.client.Vpcs(nil)
+	if err != nil {
+		return
+	}
+
+	rows := [][]string{}
+	for _, vc := range vpcs {
+		rows = append(rows, []string{
+			vc.Id.Hex(),
+			vc.Name,
+			vc.Network,
+			vc.Network6,
+		})
+	}
+
+	err = cmd.output(vpcs, []string{
+		"ID", "NAME", "NETWORK", "NETWORK6",
+	}, rows)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func firewallList(cmd *clientCmd) (err error) {
+	fires, err := cmd.client.Firewalls(nil)
+	if err != nil {
+		return
+	}
+
+	rows := [][]string{}
+	for _, fire := range fires {
+		rules := []string{}
+		for _, rule := range fire.Ingress {
+			rules = append(rules, rule.Protocol+":"+rule.Port)
+		}
+
+		rows = append(rows, []string{
+			fire.Id.Hex(),
+			fire.Name,
+			strings.Join(fire.NetworkRoles, ","),
+			strings.Join(rules, ","),
+		})
+	}
+
+	err = cmd.output(fires, []string{
+		"ID", "NAME", "ROLES", "INGRESS",
+	}, rows)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func imageList(cmd *clientCmd) (err error) {
+	data := &struct {
+		Images []*image.Image `json:"images"`
+		Count  int            `json:"count"`
+	}{}
+
+	query := url.Values{}
+	query.Set("page", "0")
+	query.Set("page_count", "1000")
+
+	err = cmd.client.Get("/image", query, data)
+	if err != nil {
+		return
+	}
+
+	rows := [][]string{}
+	for _, img := range data.Images {
+		rows = append(rows, []string{
+			img.Id.Hex(),
+			img.Name,
+			img.Type,
+			strconv.FormatBool(img.Signed),
+		})
+	}
+
+	err = cmd.output(data.Images, []string{
+		"ID", "NAME", "TYPE", "SIGNED",
+	}, rows)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func Client() (err error) {
+	resource := flag.Arg(0)
+	action := flag.Arg(1)
+	args := []string{}
+	if flag.NArg() > 2 {
+		args = flag.Args()[2:]
+	}
+
+	cmd := newClientCmd(resource + " " + action)
+
+	switch resource + " " + action {
+	case "instance create":
+		err = instanceCreate(cmd, args)
+		return
+	}
+
+	err = cmd.parse(args)
+	if err != nil {
+		return
+	}
+
+	switch resource + " " + action {
+	case "instance list":
+		err = instanceList(cmd)
+		break
+	case "instance start":
+		err = instanceState(cmd, instance.Start)
+		break
+	case "instance stop":
+		err = instanceState(cmd, instance.Stop)
+		break
+	case "disk list":
+		err = diskList(cmd)
+		break
+	case "disk snapshot":
+		err = diskSnapshot(cmd)
+		break
+	case "vpc list":
+		err = vpcList(cmd)
+		break
+	case "firewall list":
+		err = firewallList(cmd)
+		break
+	case "image list":
+		err = imageList(cmd)
+		break
+	default:
+		fmt.Println(clientHelp)
+	}
+
+	return
+}
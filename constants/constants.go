@@ -14,6 +14,7 @@ const (
 	LogPath2        = "/var/log/pritunl-cloud.log.1"
 	StaticCache     = true
 	RetryDelay      = 3 * time.Second
+	ApiPrefix       = "/api/v1"
 )
 
 var (
@@ -2,6 +2,7 @@ package data
 
 import (
 	"fmt"
+	"github.com/Sirupsen/logrus"
 	"github.com/pritunl/pritunl-cloud/database"
 	"github.com/pritunl/pritunl-cloud/disk"
 	"github.com/pritunl/pritunl-cloud/paths"
@@ -12,7 +13,7 @@ func CreateDisk(db *database.Database, dsk *disk.Disk) (err error) {
 	diskPath := paths.GetDiskPath(dsk.Id)
 
 	if dsk.Image != "" {
-		err = WriteImage(db, dsk.Image, dsk.Id, dsk.Size)
+		err = WriteImage(db, dsk)
 		if err != nil {
 			return
 		}
@@ -26,3 +27,40 @@ func CreateDisk(db *database.Database, dsk *disk.Disk) (err error) {
 
 	return
 }
+
+func FlattenDisk(db *database.Database, dsk *disk.Disk) (err error) {
+	if dsk.Backing == "" {
+		return
+	}
+
+	diskPath := paths.GetDiskPath(dsk.Id)
+	diskTempPath := paths.GetDiskTempPath()
+
+	err = utils.ExistsMkdir(paths.GetTempPath(), 0755)
+	if err != nil {
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"disk_id":   dsk.Id.Hex(),
+		"disk_path": diskPath,
+		"backing":   dsk.Backing,
+	}).Info("data: Flattening linked clone disk")
+
+	err = utils.Exec("", "qemu-img", "convert", "-f", "qcow2",
+		"-O", "qcow2", diskPath, diskTempPath)
+	if err != nil {
+		utils.Remove(diskTempPath)
+		return
+	}
+
+	err = utils.Exec("", "mv", diskTempPath, diskPath)
+	if err != nil {
+		utils.Remove(diskTempPath)
+		return
+	}
+
+	dsk.Backing = ""
+
+	return
+}
@@ -5,7 +5,6 @@ import (
 	"github.com/Sirupsen/logrus"
 	"github.com/dropbox/godropbox/errors"
 	"github.com/minio/minio-go"
-	"github.com/pritunl/pritunl-cloud/constants"
 	"github.com/pritunl/pritunl-cloud/database"
 	"github.com/pritunl/pritunl-cloud/datacenter"
 	"github.com/pritunl/pritunl-cloud/disk"
@@ -16,12 +15,11 @@ import (
 	"github.com/pritunl/pritunl-cloud/paths"
 	"github.com/pritunl/pritunl-cloud/storage"
 	"github.com/pritunl/pritunl-cloud/utils"
+	"github.com/pritunl/pritunl-cloud/webhook"
 	"github.com/pritunl/pritunl-cloud/zone"
-	"golang.org/x/crypto/openpgp"
 	"gopkg.in/mgo.v2/bson"
 	"os"
 	"path"
-	"strings"
 	"time"
 )
 
@@ -29,6 +27,45 @@ var (
 	imageLock = utils.NewMultiTimeoutLock(10 * time.Minute)
 )
 
+type snapshotData struct {
+	Disk     bson.ObjectId `json:"disk"`
+	DiskName string        `json:"disk_name"`
+	Image    bson.ObjectId `json:"image"`
+	Name     string        `json:"name"`
+	Size     int64         `json:"size"`
+}
+
+func cachedImageReferenced(db *database.Database, img *image.Image) (
+	referenced bool, err error) {
+
+	backingKeys, err := disk.GetBackingKeys(db, node.Self.Id)
+	if err != nil {
+		return
+	}
+
+	referenced = backingKeys.Contains(
+		fmt.Sprintf("%s-%s", img.Id.Hex(), img.Etag))
+
+	return
+}
+
+func removeCachedImage(db *database.Database, img *image.Image,
+	pth string) {
+
+	referenced, err := cachedImageReferenced(db, img)
+	if err != nil || referenced {
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"id":   img.Id.Hex(),
+		"key":  img.Key,
+		"path": pth,
+	}).Info("data: Removing unverified cached image")
+
+	utils.Remove(pth)
+}
+
 func getImage(db *database.Database, img *image.Image,
 	pth string) (err error) {
 
@@ -43,12 +80,17 @@ func getImage(db *database.Database, img *image.Image,
 	lockId := imageLock.Lock(pth)
 	defer imageLock.Unlock(pth, lockId)
 
+	err = checkImage(img)
+	if err != nil {
+		return
+	}
+
 	exists, err := utils.Exists(pth)
 	if err != nil {
 		return
 	}
 
-	if exists {
+	if exists && !verifyRequired(img) {
 		return
 	}
 
@@ -86,73 +128,53 @@ func getImage(db *database.Database, img *image.Image,
 		return
 	}
 
-	if strings.Contains(store.Endpoint, "images.pritunl.com") {
-		sigPth := tmpPth + ".sig"
-		defer os.Remove(sigPth)
-
-		err = client.FGetObject(store.Bucket,
-			img.Key+".sig", sigPth, minio.GetObjectOptions{})
-		if err != nil {
-			os.Remove(tmpPth)
-
-			err = &errortypes.ReadError{
-				errors.Wrap(err, "data: Failed to download image signature"),
-			}
-			return
+	err = verifyImage(db, client, store, img, tmpPth)
+	if err != nil {
+		os.Remove(tmpPth)
+		if exists {
+			removeCachedImage(db, img, pth)
 		}
+		return
+	}
 
-		signature, e := os.Open(sigPth)
+	if exists {
+		referenced, e := cachedImageReferenced(db, img)
 		if e != nil {
 			os.Remove(tmpPth)
-
-			err = &errortypes.ReadError{
-				errors.Wrap(e, "data: Failed to open image signature"),
-			}
+			err = e
 			return
 		}
-		defer signature.Close()
 
-		tmpImg, e := os.Open(tmpPth)
-		if e != nil {
+		if referenced {
 			os.Remove(tmpPth)
-
-			err = &errortypes.ReadError{
-				errors.Wrap(e, "data: Failed to open image"),
-			}
 			return
 		}
-		defer tmpImg.Close()
+	}
 
-		keyring, e := openpgp.ReadArmoredKeyRing(
-			strings.NewReader(constants.PritunlKeyring))
-		if e != nil {
-			os.Remove(tmpPth)
+	err = utils.Exec("", "mv", tmpPth, pth)
+	if err != nil {
+		return
+	}
 
-			err = &errortypes.ParseError{
-				errors.Wrap(e, "data: Failed to parse Pritunl keyring"),
-			}
-			return
-		}
+	return
+}
 
-		entity, e := openpgp.CheckArmoredDetachedSignature(
-			keyring, tmpImg, signature)
-		if e != nil || entity == nil {
-			os.Remove(tmpPth)
+func cacheImage(db *database.Database, img *image.Image) (
+	imagePth string, err error) {
 
-			err = &errortypes.VerificationError{
-				errors.Wrap(e, "data: Image signature verification failed"),
-			}
-			return
-		}
+	cacheDir := node.Self.GetCachePath()
 
-		logrus.WithFields(logrus.Fields{
-			"id":         img.Id.Hex(),
-			"storage_id": store.Id.Hex(),
-			"key":        img.Key,
-		}).Info("data: Image signature successfully validated")
+	imagePth = path.Join(
+		cacheDir,
+		fmt.Sprintf("image-%s-%s", img.Id.Hex(), img.Etag),
+	)
+
+	err = utils.ExistsMkdir(cacheDir, 0755)
+	if err != nil {
+		return
 	}
 
-	err = utils.Exec("", "mv", tmpPth, pth)
+	err = getImage(db, img, imagePth)
 	if err != nil {
 		return
 	}
@@ -160,9 +182,28 @@ func getImage(db *database.Database, img *image.Image,
 	return
 }
 
-func WriteImage(db *database.Database, imgId, dskId bson.ObjectId,
-	size int) (err error) {
+func PrefetchImage(db *database.Database, img *image.Image) (err error) {
+	if img.Type != storage.Public {
+		return
+	}
+
+	err = utils.ExistsMkdir(paths.GetTempPath(), 0755)
+	if err != nil {
+		return
+	}
 
+	_, err = cacheImage(db, img)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func WriteImage(db *database.Database, dsk *disk.Disk) (err error) {
+	imgId := dsk.Image
+	dskId := dsk.Id
+	size := dsk.Size
 	diskPath := paths.GetDiskPath(dskId)
 	diskTempPath := paths.GetDiskTempPath()
 	disksPath := paths.GetDisksPath()
@@ -183,20 +224,9 @@ func WriteImage(db *database.Database, imgId, dskId bson.ObjectId,
 	}
 
 	if img.Type == storage.Public {
-		cacheDir := node.Self.GetCachePath()
-
-		imagePth := path.Join(
-			cacheDir,
-			fmt.Sprintf("image-%s-%s", img.Id.Hex(), img.Etag),
-		)
-
-		err = utils.ExistsMkdir(cacheDir, 0755)
-		if err != nil {
-			return
-		}
-
-		err = getImage(db, img, imagePth)
-		if err != nil {
+		imagePth, e := cacheImage(db, img)
+		if e != nil {
+			err = e
 			return
 		}
 
@@ -223,17 +253,35 @@ func WriteImage(db *database.Database, imgId, dskId bson.ObjectId,
 
 		utils.Exec("", "touch", imagePth)
 
-		err = utils.Exec("", "cp", imagePth, diskTempPath)
-		if err != nil {
-			return
-		}
+		if node.Self.LinkedClones {
+			args := []string{
+				"create", "-f", "qcow2",
+				"-b", imagePth, "-o", "backing_fmt=qcow2",
+				diskTempPath,
+			}
+			if size > 10 {
+				args = append(args, fmt.Sprintf("%dG", size))
+			}
 
-		if size > 10 {
-			_, err = utils.ExecCombinedOutputLogged(nil, "qemu-img",
-				"resize", diskTempPath, fmt.Sprintf("%dG", size))
+			_, err = utils.ExecCombinedOutputLogged(nil, "qemu-img", args...)
+			if err != nil {
+				return
+			}
+
+			dsk.Backing = fmt.Sprintf("%s-%s", img.Id.Hex(), img.Etag)
+		} else {
+			err = utils.Exec("", "cp", imagePth, diskTempPath)
 			if err != nil {
 				return
 			}
+
+			if size > 10 {
+				_, err = utils.ExecCombinedOutputLogged(nil, "qemu-img",
+					"resize", diskTempPath, fmt.Sprintf("%dG", size))
+				if err != nil {
+					return
+				}
+			}
 		}
 
 		err = utils.Exec("", "mv", diskTempPath, diskPath)
@@ -482,6 +530,7 @@ func CreateSnapshot(db *database.Database, dsk *disk.Disk) (err error) {
 
 	img.Etag = image.GetEtag(obj)
 	img.LastModified = obj.LastModified
+	img.Size = obj.Size
 
 	err = img.Insert(db)
 	if err != nil {
@@ -491,5 +540,17 @@ func CreateSnapshot(db *database.Database, dsk *disk.Disk) (err error) {
 
 	event.PublishDispatch(db, "image.change")
 
+	err = webhook.Publish(db, dsk.Organization, webhook.DiskSnapshot,
+		&snapshotData{
+			Disk:     dsk.Id,
+			DiskName: dsk.Name,
+			Image:    img.Id,
+			Name:     img.Name,
+			Size:     img.Size,
+		})
+	if err != nil {
+		return
+	}
+
 	return
 }
@@ -0,0 +1,138 @@
+package data
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/minio/minio-go"
+	"github.com/pritunl/pritunl-cloud/constants"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/event"
+	"github.com/pritunl/pritunl-cloud/image"
+	"github.com/pritunl/pritunl-cloud/settings"
+	"github.com/pritunl/pritunl-cloud/storage"
+	"github.com/pritunl/pritunl-cloud/verify"
+	"os"
+	"strings"
+)
+
+func checkImage(img *image.Image) (err error) {
+	if !img.Signed && img.Type == storage.Public &&
+		settings.Image.SignaturePolicy == verify.Required {
+
+		err = &errortypes.VerificationError{
+			errors.New("data: Unsigned image refused by signature policy"),
+		}
+		return
+	}
+
+	return
+}
+
+func verifyRequired(img *image.Image) bool {
+	return img.Signed &&
+		settings.Image.SignaturePolicy != verify.None &&
+		img.GetSignatureStatus() != verify.Verified
+}
+
+func verifyImage(db *database.Database, client *minio.Client,
+	store *storage.Storage, img *image.Image, pth string) (err error) {
+
+	sigType := img.Signature
+	keys := settings.Image.TrustedKeys
+
+	if strings.Contains(store.Endpoint, "images.pritunl.com") {
+		sigType = verify.Gpg
+		keys = append([]string{constants.PritunlKeyring}, keys...)
+	} else if settings.Image.SignaturePolicy == verify.None {
+		return
+	} else if sigType == "" {
+		if settings.Image.SignaturePolicy == verify.Required &&
+			img.Type == storage.Public {
+
+			err = &errortypes.VerificationError{
+				errors.New("data: Image signature missing"),
+			}
+		}
+		return
+	}
+
+	sigPth := pth + verify.Suffixes[sigType]
+	defer os.Remove(sigPth)
+
+	err = client.FGetObject(store.Bucket,
+		img.Key+verify.Suffixes[sigType], sigPth,
+		minio.GetObjectOptions{})
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "data: Failed to download image signature"),
+		}
+		return
+	}
+
+	sig, err := os.Open(sigPth)
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "data: Failed to open image signature"),
+		}
+		return
+	}
+	defer sig.Close()
+
+	imgFile, err := os.Open(pth)
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "data: Failed to open image"),
+		}
+		return
+	}
+	defer imgFile.Close()
+
+	keyring, err := verify.NewKeyring(keys...)
+	if err != nil {
+		return
+	}
+
+	keyId, err := keyring.Verify(sigType, imgFile, sig)
+	if err != nil {
+		e := image.SetInvalid(db, img.Id, img.Etag)
+		if e != nil {
+			logrus.WithFields(logrus.Fields{
+				"id":    img.Id.Hex(),
+				"error": e,
+			}).Error("data: Failed to update image signature status")
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"id":         img.Id.Hex(),
+			"storage_id": store.Id.Hex(),
+			"key":        img.Key,
+			"signature":  sigType,
+			"error":      err,
+		}).Error("data: Image signature verification failed")
+
+		event.PublishDispatch(db, "image.change")
+
+		err = &errortypes.VerificationError{
+			errors.Wrap(err, "data: Image signature verification failed"),
+		}
+		return
+	}
+
+	err = image.SetVerified(db, img.Id, img.Etag, keyId)
+	if err != nil {
+		return
+	}
+
+	event.PublishDispatch(db, "image.change")
+
+	logrus.WithFields(logrus.Fields{
+		"id":         img.Id.Hex(),
+		"storage_id": store.Id.Hex(),
+		"key":        img.Key,
+		"signature":  sigType,
+		"signer":     keyId,
+	}).Info("data: Image signature successfully validated")
+
+	return
+}
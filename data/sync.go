@@ -1,14 +1,18 @@
 package data
 
 import (
+	"github.com/Sirupsen/logrus"
 	"github.com/dropbox/godropbox/container/set"
 	"github.com/dropbox/godropbox/errors"
 	"github.com/minio/minio-go"
 	"github.com/pritunl/pritunl-cloud/database"
 	"github.com/pritunl/pritunl-cloud/errortypes"
 	"github.com/pritunl/pritunl-cloud/image"
+	"github.com/pritunl/pritunl-cloud/settings"
 	"github.com/pritunl/pritunl-cloud/storage"
 	"github.com/pritunl/pritunl-cloud/utils"
+	"github.com/pritunl/pritunl-cloud/verify"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -34,11 +38,20 @@ func Sync(db *database.Database, store *storage.Storage) (err error) {
 		return
 	}
 
+	familyReg, e := regexp.Compile(settings.Image.FamilyPattern)
+	if e != nil {
+		familyReg = nil
+		logrus.WithFields(logrus.Fields{
+			"pattern": settings.Image.FamilyPattern,
+			"error":   e,
+		}).Error("data: Invalid image family pattern")
+	}
+
 	done := make(chan struct{})
 	defer close(done)
 
 	images := []*image.Image{}
-	signedKeys := set.NewSet()
+	signedKeys := map[string]string{}
 	remoteKeys := set.NewSet()
 	for object := range client.ListObjects(store.Bucket, "", true, done) {
 		if object.Err != nil {
@@ -48,8 +61,16 @@ func Sync(db *database.Database, store *storage.Storage) (err error) {
 			return
 		}
 
-		if strings.HasSuffix(object.Key, ".qcow2.sig") {
-			signedKeys.Add(strings.TrimRight(object.Key, ".sig"))
+		if strings.HasSuffix(object.Key, ".qcow2"+verify.GpgSuffix) {
+			signedKeys[strings.TrimSuffix(
+				object.Key, verify.GpgSuffix)] = verify.Gpg
+		} else if strings.HasSuffix(
+			object.Key, ".qcow2"+verify.MinisignSuffix) {
+
+			key := strings.TrimSuffix(object.Key, verify.MinisignSuffix)
+			if signedKeys[key] == "" {
+				signedKeys[key] = verify.Minisign
+			}
 		} else if strings.HasSuffix(object.Key, ".qcow2") {
 			etag := image.GetEtag(object)
 			remoteKeys.Add(object.Key)
@@ -60,14 +81,17 @@ func Sync(db *database.Database, store *storage.Storage) (err error) {
 				Etag:         etag,
 				Type:         store.Type,
 				LastModified: object.LastModified,
+				Size:         object.Size,
 			}
+			img.Family, img.Version = image.ParseKey(familyReg, object.Key)
 
 			images = append(images, img)
 		}
 	}
 
 	for _, img := range images {
-		img.Signed = signedKeys.Contains(img.Key)
+		img.Signature = signedKeys[img.Key]
+		img.Signed = img.Signature != ""
 
 		err = img.Upsert(db)
 		if err != nil {
@@ -0,0 +1,373 @@
+package data
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/Sirupsen/logrus"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/minio/minio-go"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/datacenter"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/event"
+	"github.com/pritunl/pritunl-cloud/image"
+	"github.com/pritunl/pritunl-cloud/paths"
+	"github.com/pritunl/pritunl-cloud/storage"
+	"github.com/pritunl/pritunl-cloud/upload"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"github.com/pritunl/pritunl-cloud/vmdk"
+	"gopkg.in/mgo.v2/bson"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"time"
+)
+
+type imageInfo struct {
+	Format          string `json:"format"`
+	BackingFilename string `json:"backing-filename"`
+	FormatSpecific  *struct {
+		Type string `json:"type"`
+		Data *struct {
+			CreateType string `json:"create-type"`
+			DataFile   string `json:"data-file"`
+		} `json:"data"`
+	} `json:"format-specific"`
+}
+
+func downloadUpload(db *database.Database, upld *upload.Upload) (
+	err error) {
+
+	transport := &http.Transport{
+		ResponseHeaderTimeout: 60 * time.Second,
+	}
+	client := &http.Client{
+		Transport: transport,
+	}
+
+	if upld.Restricted {
+		transport.Dial = utils.RestrictedDial
+		client.CheckRedirect = utils.RestrictedRedirect
+	}
+
+	resp, err := client.Get(upld.Url)
+	if err != nil {
+		err = &errortypes.RequestError{
+			errors.Wrap(err, "data: Failed to request import url"),
+		}
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		err = &errortypes.RequestError{
+			errors.Newf("data: Import url returned status %d",
+				resp.StatusCode),
+		}
+		return
+	}
+
+	err = utils.ExistsMkdir(paths.GetTempPath(), 0755)
+	if err != nil {
+		return
+	}
+
+	file, err := os.OpenFile(upld.Path(),
+		os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "data: Failed to open import file"),
+		}
+		return
+	}
+	defer file.Close()
+
+	n, err := io.Copy(file, io.LimitReader(resp.Body, upload.MaxSize+1))
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "data: Failed to download import"),
+		}
+		return
+	}
+
+	if n > upload.MaxSize {
+		err = &errortypes.ReadError{
+			errors.New("data: Import exceeds maximum image size"),
+		}
+		return
+	}
+
+	upld.Size = n
+	upld.Received = n
+
+	err = upld.SetState(db, upload.Processing, "")
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func checksumUpload(upld *upload.Upload) (sum string, err error) {
+	file, err := os.Open(upld.Path())
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "data: Failed to open upload file"),
+		}
+		return
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "data: Failed to read upload file"),
+		}
+		return
+	}
+
+	sum = hex.EncodeToString(hash.Sum(nil))
+
+	return
+}
+
+func inspectUpload(sourcePath, format string) (err error) {
+	output, err := utils.ExecCombinedOutputLogged(nil, "qemu-img", "info",
+		"--output=json", "-U", "-f", format, sourcePath)
+	if err != nil {
+		return
+	}
+
+	info := &imageInfo{}
+	err = json.Unmarshal([]byte(output), info)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "data: Failed to parse image info"),
+		}
+		return
+	}
+
+	if info.Format != format {
+		err = &errortypes.VerificationError{
+			errors.Newf("data: Image format '%s' does not match '%s'",
+				info.Format, format),
+		}
+		return
+	}
+
+	if info.BackingFilename != "" {
+		err = &errortypes.VerificationError{
+			errors.New("data: Image with backing file not allowed"),
+		}
+		return
+	}
+
+	if info.FormatSpecific != nil && info.FormatSpecific.Data != nil {
+		data := info.FormatSpecific.Data
+
+		if data.DataFile != "" {
+			err = &errortypes.VerificationError{
+				errors.New("data: Image with data file not allowed"),
+			}
+			return
+		}
+
+		if format == upload.Formats[upload.Vmdk] &&
+			!upload.VmdkCreateTypes[data.CreateType] {
+
+			err = &errortypes.VerificationError{
+				errors.Newf("data: Vmdk create type '%s' not allowed",
+					data.CreateType),
+			}
+			return
+		}
+	} else if format == upload.Formats[upload.Vmdk] {
+		err = &errortypes.VerificationError{
+			errors.New("data: Vmdk create type unknown"),
+		}
+		return
+	}
+
+	return
+}
+
+func importUpload(db *database.Database, upld *upload.Upload) (err error) {
+	if upld.Source == upload.ImportSource {
+		err = downloadUpload(db, upld)
+		if err != nil {
+			return
+		}
+	}
+
+	sum, err := checksumUpload(upld)
+	if err != nil {
+		return
+	}
+
+	if upld.Checksum != "" && upld.Checksum != sum {
+		err = &errortypes.VerificationError{
+			errors.New("data: Image checksum mismatch"),
+		}
+		return
+	}
+	upld.Checksum = sum
+
+	format := upload.Formats[upld.Format]
+	sandboxDir := paths.GetTempDir()
+	sourcePath := path.Join(sandboxDir, "source")
+
+	defer os.RemoveAll(sandboxDir)
+
+	err = utils.ExistsMkdir(sandboxDir, 0700)
+	if err != nil {
+		return
+	}
+
+	err = os.Rename(upld.Path(), sourcePath)
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "data: Failed to move upload file"),
+		}
+		return
+	}
+
+	err = inspectUpload(sourcePath, format)
+	if err != nil {
+		return
+	}
+
+	if upld.Format == upload.Vmdk {
+		err = vmdk.SetRandUuid(sourcePath)
+		if err != nil {
+			if _, ok := err.(*errortypes.ParseError); !ok {
+				return
+			}
+			err = nil
+		}
+	}
+
+	dc, err := datacenter.Get(db, upld.Datacenter)
+	if err != nil {
+		return
+	}
+
+	store, err := storage.Get(db, dc.PrivateStorage)
+	if err != nil {
+		return
+	}
+
+	imgId := bson.NewObjectId()
+	tmpPath := paths.GetImageTempPath()
+	img := &image.Image{
+		Id:           imgId,
+		Name:         upld.Name,
+		Organization: upld.Organization,
+		Type:         storage.Private,
+		Storage:      store.Id,
+		Key:          fmt.Sprintf("upload/%s.qcow2", imgId.Hex()),
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"upload_id": upld.Id.Hex(),
+		"format":    upld.Format,
+		"path":      sourcePath,
+	}).Info("data: Converting image upload")
+
+	defer utils.Remove(tmpPath)
+	_, err = utils.ExecCombinedOutputLoggedDir(nil, sandboxDir,
+		"qemu-img", "convert", "-U", "-f", format, "-O", "qcow2", "-c",
+		sourcePath, tmpPath)
+	if err != nil {
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"upload_id":  upld.Id.Hex(),
+		"storage_id": store.Id.Hex(),
+		"object_key": img.Key,
+	}).Info("data: Uploading image")
+
+	client, err := minio.New(
+		store.Endpoint, store.AccessKey, store.SecretKey, !store.Insecure)
+	if err != nil {
+		err = &errortypes.ConnectionError{
+			errors.Wrap(err, "data: Failed to connect to storage"),
+		}
+		return
+	}
+
+	_, err = client.FPutObject(store.Bucket, img.Key, tmpPath,
+		minio.PutObjectOptions{})
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "data: Failed to write object"),
+		}
+		return
+	}
+
+	obj, err := client.StatObject(store.Bucket, img.Key,
+		minio.StatObjectOptions{})
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "data: Failed to stat object"),
+		}
+		return
+	}
+
+	img.Etag = image.GetEtag(obj)
+	img.LastModified = obj.LastModified
+	img.Size = obj.Size
+
+	err = img.Insert(db)
+	if err != nil {
+		client.RemoveObject(store.Bucket, img.Key)
+		return
+	}
+
+	upld.Image = img.Id
+
+	event.PublishDispatch(db, "image.change")
+
+	return
+}
+
+// ImportUpload converts a received upload or url import to qcow2 and
+// stores it in the datacenter private storage
+func ImportUpload(db *database.Database, upld *upload.Upload) (err error) {
+	err = importUpload(db, upld)
+	os.Remove(upld.Path())
+
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"upload_id": upld.Id.Hex(),
+			"error":     err,
+		}).Error("data: Failed to import image")
+
+		msg := err.Error()
+		if dropboxErr, ok := err.(errors.DropboxError); ok {
+			msg = dropboxErr.GetMessage()
+		}
+
+		e := upld.SetState(db, upload.Failed, msg)
+		if e != nil {
+			err = e
+			return
+		}
+
+		event.PublishDispatch(db, "image_upload.change")
+		return
+	}
+
+	err = upld.SetState(db, upload.Complete, "")
+	if err != nil {
+		return
+	}
+
+	event.PublishDispatch(db, "image_upload.change")
+
+	return
+}
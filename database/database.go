@@ -147,6 +147,31 @@ func (d *Database) VpcsIp() (coll *Collection) {
 	return
 }
 
+func (d *Database) VpcsLink() (coll *Collection) {
+	coll = d.getCollection("vpcs_link")
+	return
+}
+
+func (d *Database) Meters() (coll *Collection) {
+	coll = d.getCollection("meters")
+	return
+}
+
+func (d *Database) MetersSample() (coll *Collection) {
+	coll = d.getCollection("meters_sample")
+	return
+}
+
+func (d *Database) Metrics() (coll *Collection) {
+	coll = d.getCollection("metrics")
+	return
+}
+
+func (d *Database) MetricsHourly() (coll *Collection) {
+	coll = d.getCollection("metrics_hourly")
+	return
+}
+
 func (d *Database) Authorities() (coll *Collection) {
 	coll = d.getCollection("authorities")
 	return
@@ -162,6 +187,36 @@ func (d *Database) Domains() (coll *Collection) {
 	return
 }
 
+func (d *Database) Webhooks() (coll *Collection) {
+	coll = d.getCollection("webhooks")
+	return
+}
+
+func (d *Database) WebhooksDelivery() (coll *Collection) {
+	coll = d.getCollection("webhooks_delivery")
+	return
+}
+
+func (d *Database) Groups() (coll *Collection) {
+	coll = d.getCollection("groups")
+	return
+}
+
+func (d *Database) Templates() (coll *Collection) {
+	coll = d.getCollection("templates")
+	return
+}
+
+func (d *Database) TemplatesVersion() (coll *Collection) {
+	coll = d.getCollection("templates_version")
+	return
+}
+
+func (d *Database) ImagesUpload() (coll *Collection) {
+	coll = d.getCollection("images_upload")
+	return
+}
+
 func (d *Database) DomainsRecord() (coll *Collection) {
 	coll = d.getCollection("domains_record")
 	return
@@ -182,6 +237,11 @@ func (d *Database) Audits() (coll *Collection) {
 	return
 }
 
+func (d *Database) AuditsExport() (coll *Collection) {
+	coll = d.getCollection("audits_export")
+	return
+}
+
 func (d *Database) Geo() (coll *Collection) {
 	coll = d.getCollection("geo")
 	return
@@ -367,6 +427,53 @@ func addIndexes() (err error) {
 			errors.Wrap(err, "database: Index error"),
 		}
 	}
+	err = coll.EnsureIndex(mgo.Index{
+		Key:        []string{"u"},
+		Background: true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
+	err = coll.EnsureIndex(mgo.Index{
+		Key:        []string{"t"},
+		Background: true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
+	err = coll.EnsureIndex(mgo.Index{
+		Key:        []string{"y"},
+		Background: true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
+	err = coll.EnsureIndex(mgo.Index{
+		Key:        []string{"a.ip"},
+		Background: true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
+	err = coll.EnsureIndex(mgo.Index{
+		Key:        []string{"s"},
+		Unique:     true,
+		Sparse:     true,
+		Background: true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
 
 	coll = db.Policies()
 	err = coll.EnsureIndex(mgo.Index{
@@ -521,6 +628,24 @@ func addIndexes() (err error) {
 			errors.Wrap(err, "database: Index error"),
 		}
 	}
+	err = coll.EnsureIndex(mgo.Index{
+		Key:        []string{"shares"},
+		Background: true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
+	err = coll.EnsureIndex(mgo.Index{
+		Key:        []string{"family", "storage"},
+		Background: true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
 
 	coll = db.Disks()
 	err = coll.EnsureIndex(mgo.Index{
@@ -555,6 +680,110 @@ func addIndexes() (err error) {
 		}
 	}
 
+	coll = db.Webhooks()
+	err = coll.EnsureIndex(mgo.Index{
+		Key:        []string{"organization"},
+		Background: true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
+	err = coll.EnsureIndex(mgo.Index{
+		Key:        []string{"events"},
+		Background: true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
+
+	coll = db.WebhooksDelivery()
+	err = coll.EnsureIndex(mgo.Index{
+		Key:        []string{"webhook", "timestamp"},
+		Background: true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
+	err = coll.EnsureIndex(mgo.Index{
+		Key:        []string{"state", "next_attempt"},
+		Background: true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
+	err = coll.EnsureIndex(mgo.Index{
+		Key:         []string{"timestamp"},
+		ExpireAfter: 720 * time.Hour,
+		Background:  true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
+
+	coll = db.Groups()
+	err = coll.EnsureIndex(mgo.Index{
+		Key:        []string{"organization"},
+		Background: true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
+
+	coll = db.Templates()
+	err = coll.EnsureIndex(mgo.Index{
+		Key:        []string{"organization"},
+		Background: true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
+
+	coll = db.TemplatesVersion()
+	err = coll.EnsureIndex(mgo.Index{
+		Key:        []string{"template", "version"},
+		Unique:     true,
+		Background: true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
+
+	coll = db.ImagesUpload()
+	err = coll.EnsureIndex(mgo.Index{
+		Key:        []string{"organization"},
+		Background: true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
+	err = coll.EnsureIndex(mgo.Index{
+		Key:        []string{"timestamp"},
+		Background: true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
+
 	coll = db.Domains()
 	err = coll.EnsureIndex(mgo.Index{
 		Key:        []string{"domain"},
@@ -652,6 +881,93 @@ func addIndexes() (err error) {
 		}
 	}
 
+	coll = db.Meters()
+	err = coll.EnsureIndex(mgo.Index{
+		Key:        []string{"resource_id", "timestamp"},
+		Unique:     true,
+		Background: true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
+	err = coll.EnsureIndex(mgo.Index{
+		Key:        []string{"organization", "timestamp"},
+		Background: true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
+
+	coll = db.Metrics()
+	err = coll.EnsureIndex(mgo.Index{
+		Key:        []string{"resource_id", "timestamp"},
+		Unique:     true,
+		Background: true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
+	err = coll.EnsureIndex(mgo.Index{
+		Key:         []string{"timestamp"},
+		ExpireAfter: 48 * time.Hour,
+		Background:  true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
+
+	coll = db.MetricsHourly()
+	err = coll.EnsureIndex(mgo.Index{
+		Key:        []string{"resource_id", "timestamp"},
+		Unique:     true,
+		Background: true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
+	err = coll.EnsureIndex(mgo.Index{
+		Key:         []string{"timestamp"},
+		ExpireAfter: 2160 * time.Hour,
+		Background:  true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
+
+	coll = db.VpcsLink()
+	err = coll.EnsureIndex(mgo.Index{
+		Key:        []string{"vpc", "node"},
+		Unique:     true,
+		Background: true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
+	err = coll.EnsureIndex(mgo.Index{
+		Key:        []string{"vpc", "priority"},
+		Unique:     true,
+		Background: true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
+
 	coll = db.Sessions()
 	err = coll.EnsureIndex(mgo.Index{
 		Key:        []string{"user"},
@@ -772,6 +1088,16 @@ func addIndexes() (err error) {
 			errors.Wrap(err, "database: Index error"),
 		}
 	}
+	err = coll.EnsureIndex(mgo.Index{
+		Key:        []string{"group"},
+		Background: true,
+		Sparse:     true,
+	})
+	if err != nil {
+		err = &IndexError{
+			errors.Wrap(err, "database: Index error"),
+		}
+	}
 	err = coll.EnsureIndex(mgo.Index{
 		Key:        []string{"name"},
 		Background: true,
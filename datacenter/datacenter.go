@@ -17,6 +17,16 @@ type Datacenter struct {
 	PrivateStorage     bson.ObjectId   `bson:"private_storage,omitempty" json:"private_storage"`
 }
 
+func (d *Datacenter) Storages() (storages []bson.ObjectId) {
+	storages = []bson.ObjectId{}
+	storages = append(storages, d.PublicStorages...)
+	if d.PrivateStorage != "" {
+		storages = append(storages, d.PrivateStorage)
+	}
+
+	return
+}
+
 func (d *Datacenter) Validate(db *database.Database) (
 	errData *errortypes.ErrorData, err error) {
 
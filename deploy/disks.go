@@ -3,6 +3,7 @@ package deploy
 import (
 	"github.com/Sirupsen/logrus"
 	"github.com/dropbox/godropbox/container/set"
+	"github.com/dropbox/godropbox/errors"
 	"github.com/pritunl/pritunl-cloud/data"
 	"github.com/pritunl/pritunl-cloud/database"
 	"github.com/pritunl/pritunl-cloud/disk"
@@ -38,7 +39,7 @@ func (d *Disks) provision(dsk *disk.Disk) {
 		}
 
 		dsk.State = disk.Available
-		err = dsk.CommitFields(db, set.NewSet("state"))
+		err = dsk.CommitFields(db, set.NewSet("state", "backing"))
 		if err != nil {
 			return
 		}
@@ -47,6 +48,51 @@ func (d *Disks) provision(dsk *disk.Disk) {
 	}()
 }
 
+func (d *Disks) flatten(dsk *disk.Disk) {
+	if d.stat.DiskInUse(dsk.Instance, dsk.Id) ||
+		disksLock.Locked(dsk.Id.Hex()) ||
+		(dsk.Instance != "" && instancesLock.Locked(dsk.Instance.Hex())) {
+
+		return
+	}
+
+	lockId := disksLock.LockTimeout(dsk.Id.Hex(), 30*time.Minute)
+	go func() {
+		defer disksLock.Unlock(dsk.Id.Hex(), lockId)
+
+		db := database.GetDatabase()
+		defer db.Close()
+
+		dsk.Message = ""
+
+		err := data.FlattenDisk(db, dsk)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"disk_id": dsk.Id.Hex(),
+				"error":   err,
+			}).Error("deploy: Failed to flatten disk")
+
+			dsk.Message = err.Error()
+			if dropboxErr, ok := err.(errors.DropboxError); ok {
+				dsk.Message = dropboxErr.GetMessage()
+			}
+		}
+
+		dsk.State = disk.Available
+		err = dsk.CommitFields(db,
+			set.NewSet("state", "backing", "message"))
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("deploy: Failed update disk state")
+			time.Sleep(5 * time.Second)
+			return
+		}
+
+		event.PublishDispatch(db, "disk.change")
+	}()
+}
+
 func (d *Disks) snapshot(dsk *disk.Disk) {
 	if disksLock.Locked(dsk.Id.Hex()) {
 		return
@@ -118,6 +164,9 @@ func (d *Disks) Deploy() (err error) {
 		case disk.Snapshot:
 			d.snapshot(dsk)
 			break
+		case disk.Flatten:
+			d.flatten(dsk)
+			break
 		case disk.Destroy:
 			d.destroy(dsk)
 			break
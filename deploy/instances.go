@@ -16,6 +16,7 @@ import (
 	"github.com/pritunl/pritunl-cloud/utils"
 	"github.com/pritunl/pritunl-cloud/vm"
 	"github.com/pritunl/pritunl-cloud/vpc"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -183,6 +184,8 @@ func (s *Instances) destroy(inst *instance.Instance) {
 			return
 		}
 
+		setMultipath(inst.Id, nil)
+
 		event.PublishDispatch(db, "instance.change")
 		event.PublishDispatch(db, "disk.change")
 	}()
@@ -322,25 +325,46 @@ func (s *Instances) routes(inst *instance.Instance) (err error) {
 			routes6 = routesStore.Routes6
 		}
 
+		singleRoutes, newMultipath := groupRoutes(vc.Routes)
+		curMultipath := getMultipath(inst.Id)
+		curKeys := set.NewSet()
+
 		for _, route := range routes {
+			key := routeKey(route.Destination, route.Priority)
+			curKeys.Add(key)
+			if newMultipath[key] != nil || curMultipath[key] != nil {
+				continue
+			}
 			curRoutes.Add(route)
 		}
 
 		for _, route := range routes6 {
+			key := routeKey(route.Destination, route.Priority)
+			curKeys.Add(key)
+			if newMultipath[key] != nil || curMultipath[key] != nil {
+				continue
+			}
 			curRoutes6.Add(route)
 		}
 
-		if vc.Routes != nil {
-			for _, route := range vc.Routes {
-				if !strings.Contains(route.Destination, ":") {
-					newRoutes.Add(*route)
-				} else {
-					newRoutes6.Add(*route)
-				}
+		for _, route := range singleRoutes {
+			if !strings.Contains(route.Destination, ":") {
+				newRoutes.Add(route)
+			} else {
+				newRoutes6.Add(route)
 			}
 		}
 
 		changed := false
+
+		for key, route := range curMultipath {
+			if newMultipath[key] != nil {
+				continue
+			}
+			changed = true
+
+			route.Remove(namespace)
+		}
 		addRoutes := newRoutes.Copy()
 		addRoutes6 := newRoutes6.Copy()
 		remRoutes := curRoutes.Copy()
@@ -361,7 +385,7 @@ func (s *Instances) routes(inst *instance.Instance) (err error) {
 				"ip", "route",
 				"del", route.Destination,
 				"via", route.Target,
-				"metric", "97",
+				"metric", strconv.Itoa(vpc.RouteMetric+route.Priority),
 			)
 		}
 
@@ -375,7 +399,7 @@ func (s *Instances) routes(inst *instance.Instance) (err error) {
 				"ip", "-6", "route",
 				"del", route.Destination,
 				"via", route.Target,
-				"metric", "97",
+				"metric", strconv.Itoa(vpc.RouteMetric+route.Priority),
 			)
 		}
 
@@ -391,7 +415,7 @@ func (s *Instances) routes(inst *instance.Instance) (err error) {
 				"ip", "route",
 				"add", route.Destination,
 				"via", route.Target,
-				"metric", "97",
+				"metric", strconv.Itoa(vpc.RouteMetric+route.Priority),
 			)
 		}
 
@@ -407,10 +431,24 @@ func (s *Instances) routes(inst *instance.Instance) (err error) {
 				"ip", "-6", "route",
 				"add", route.Destination,
 				"via", route.Target,
-				"metric", "97",
+				"metric", strconv.Itoa(vpc.RouteMetric+route.Priority),
 			)
 		}
 
+		for key, route := range newMultipath {
+			if curKeys.Contains(key) && route.Equal(curMultipath[key]) {
+				continue
+			}
+			changed = true
+
+			e := route.Add(namespace)
+			if e != nil {
+				delete(newMultipath, key)
+			}
+		}
+
+		setMultipath(inst.Id, newMultipath)
+
 		if changed {
 			store.RemRoutes(inst.Id)
 		}
@@ -453,6 +491,11 @@ func (s *Instances) Deploy() (err error) {
 		switch inst.State {
 		case instance.Start:
 			if curVirt.State == vm.Stopped || curVirt.State == vm.Failed {
+				// Disk files are replaced when flattening completes
+				if s.stat.DisksFlattening(inst.Id) {
+					continue
+				}
+
 				s.start(inst)
 				continue
 			}
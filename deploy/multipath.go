@@ -0,0 +1,159 @@
+package deploy
+
+import (
+	"fmt"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"github.com/pritunl/pritunl-cloud/vpc"
+	"gopkg.in/mgo.v2/bson"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	multipathRoutes     = map[bson.ObjectId]map[string]*multipathRoute{}
+	multipathRoutesLock = sync.Mutex{}
+)
+
+type multipathRoute struct {
+	Destination string
+	Priority    int
+	Targets     []string
+}
+
+func (r *multipathRoute) Equal(route *multipathRoute) bool {
+	if route == nil || len(r.Targets) != len(route.Targets) {
+		return false
+	}
+
+	for i := range r.Targets {
+		if r.Targets[i] != route.Targets[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *multipathRoute) args(namespace string) []string {
+	args := []string{
+		"ip", "netns", "exec", namespace,
+		"ip",
+	}
+
+	if strings.Contains(r.Destination, ":") {
+		args = append(args, "-6")
+	}
+
+	return args
+}
+
+func (r *multipathRoute) Add(namespace string) (err error) {
+	args := append(r.args(namespace),
+		"route", "replace", r.Destination,
+		"metric", strconv.Itoa(vpc.RouteMetric+r.Priority),
+	)
+
+	for _, target := range r.Targets {
+		args = append(args, "nexthop", "via", target)
+	}
+
+	_, err = utils.ExecCombinedOutputLogged(nil, args[0], args[1:]...)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (r *multipathRoute) Remove(namespace string) (err error) {
+	args := append(r.args(namespace),
+		"route", "del", r.Destination,
+		"metric", strconv.Itoa(vpc.RouteMetric+r.Priority),
+	)
+
+	_, err = utils.ExecCombinedOutputLogged(
+		[]string{
+			"No such process",
+		},
+		args[0], args[1:]...,
+	)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func routeKey(destination string, priority int) string {
+	return fmt.Sprintf("%s-%d", destination, priority)
+}
+
+func groupRoutes(routes []*vpc.Route) (single []vpc.Route,
+	multipath map[string]*multipathRoute) {
+
+	single = []vpc.Route{}
+	multipath = map[string]*multipathRoute{}
+	grouped := map[string][]vpc.Route{}
+	keys := []string{}
+
+	for _, route := range routes {
+		rte := vpc.Route{
+			Destination: route.Destination,
+			Target:      route.Target,
+			Priority:    route.Priority,
+		}
+
+		key := routeKey(rte.Destination, rte.Priority)
+		if _, ok := grouped[key]; !ok {
+			keys = append(keys, key)
+		}
+		grouped[key] = append(grouped[key], rte)
+	}
+
+	for _, key := range keys {
+		rtes := grouped[key]
+
+		if len(rtes) == 1 {
+			single = append(single, rtes[0])
+			continue
+		}
+
+		targets := []string{}
+		for _, rte := range rtes {
+			targets = append(targets, rte.Target)
+		}
+		sort.Strings(targets)
+
+		multipath[key] = &multipathRoute{
+			Destination: rtes[0].Destination,
+			Priority:    rtes[0].Priority,
+			Targets:     targets,
+		}
+	}
+
+	return
+}
+
+func getMultipath(instId bson.ObjectId) (routes map[string]*multipathRoute) {
+	multipathRoutesLock.Lock()
+	routes = multipathRoutes[instId]
+	multipathRoutesLock.Unlock()
+
+	if routes == nil {
+		routes = map[string]*multipathRoute{}
+	}
+
+	return
+}
+
+func setMultipath(instId bson.ObjectId, routes map[string]*multipathRoute) {
+	multipathRoutesLock.Lock()
+	if len(routes) == 0 {
+		delete(multipathRoutes, instId)
+	} else {
+		multipathRoutes[instId] = routes
+	}
+	multipathRoutesLock.Unlock()
+}
@@ -4,5 +4,6 @@ const (
 	Provision = "provision"
 	Available = "available"
 	Snapshot  = "snapshot"
+	Flatten   = "flatten"
 	Destroy   = "destroy"
 )
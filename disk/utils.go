@@ -2,6 +2,7 @@ package disk
 
 import (
 	"fmt"
+	"github.com/dropbox/godropbox/container/set"
 	"github.com/pritunl/pritunl-cloud/database"
 	"github.com/pritunl/pritunl-cloud/utils"
 	"gopkg.in/mgo.v2/bson"
@@ -159,6 +160,38 @@ func GetNode(db *database.Database, nodeId bson.ObjectId) (
 	return
 }
 
+func GetBackingKeys(db *database.Database, nodeId bson.ObjectId) (
+	keys set.Set, err error) {
+
+	coll := db.Disks()
+	keys = set.NewSet()
+
+	cursor := coll.Find(&bson.M{
+		"node": nodeId,
+		"backing": &bson.M{
+			"$exists": true,
+		},
+	}).Select(&bson.M{
+		"backing": 1,
+	}).Iter()
+
+	dsk := &Disk{}
+	for cursor.Next(dsk) {
+		if dsk.Backing != "" {
+			keys.Add(dsk.Backing)
+		}
+		dsk = &Disk{}
+	}
+
+	err = cursor.Close()
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
 func Remove(db *database.Database, diskId bson.ObjectId) (err error) {
 	coll := db.Disks()
 
@@ -69,8 +69,11 @@ func (d *Domain) Insert(db *database.Database) (err error) {
 		return
 	}
 
+	d.Id = bson.NewObjectId()
+
 	err = coll.Insert(d)
 	if err != nil {
+		d.Id = ""
 		err = database.ParseError(err)
 		return
 	}
@@ -5,6 +5,7 @@ import (
 	"github.com/Sirupsen/logrus"
 	"github.com/pritunl/pritunl-cloud/constants"
 	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/prometheus"
 	"github.com/pritunl/pritunl-cloud/requires"
 	"gopkg.in/mgo.v2/bson"
 	"time"
@@ -30,6 +31,12 @@ type Dispatch struct {
 	Type string `bson:"type" json:"type"`
 }
 
+func observe(id bson.ObjectId) {
+	prometheus.Add(prometheus.EventsReceivedTotal, nil, 1)
+	prometheus.Set(prometheus.EventLag, nil,
+		time.Since(id.Time()).Seconds())
+}
+
 func getCursorId(coll *database.Collection, channels []string) (
 	id bson.ObjectId, err error) {
 
@@ -181,6 +188,8 @@ func Subscribe(channels []string, duration time.Duration,
 				continue
 			}
 
+			observe(msg.Id)
+
 			if !onMsg(msg, nil) {
 				return
 			}
@@ -259,6 +268,8 @@ func SubscribeType(channels []string, duration time.Duration,
 				continue
 			}
 
+			observe(msg.GetId())
+
 			if !onMsg(msg, nil) {
 				return
 			}
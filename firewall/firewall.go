@@ -164,8 +164,11 @@ func (f *Firewall) Insert(db *database.Database) (err error) {
 		return
 	}
 
+	f.Id = bson.NewObjectId()
+
 	err = coll.Insert(f)
 	if err != nil {
+		f.Id = ""
 		err = database.ParseError(err)
 		return
 	}
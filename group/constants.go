@@ -0,0 +1,10 @@
+package group
+
+const (
+	Stable   = "stable"
+	Scaling  = "scaling"
+	Updating = "updating"
+	Degraded = "degraded"
+
+	MaxCount = 100
+)
@@ -0,0 +1,247 @@
+package group
+
+import (
+	"github.com/dropbox/godropbox/container/set"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/template"
+	"gopkg.in/mgo.v2/bson"
+	"time"
+)
+
+type AutoScaling struct {
+	Enabled  bool    `bson:"enabled" json:"enabled"`
+	CpuHigh  float64 `bson:"cpu_high" json:"cpu_high"`
+	CpuLow   float64 `bson:"cpu_low" json:"cpu_low"`
+	Period   int     `bson:"period" json:"period"`
+	Cooldown int     `bson:"cooldown" json:"cooldown"`
+	Step     int     `bson:"step" json:"step"`
+}
+
+type Schedule struct {
+	Hour     int   `bson:"hour" json:"hour"`
+	Minute   int   `bson:"minute" json:"minute"`
+	Weekdays []int `bson:"weekdays" json:"weekdays"`
+	Count    int   `bson:"count" json:"count"`
+}
+
+func (s *Schedule) Match(now time.Time) bool {
+	now = now.UTC()
+
+	if now.Hour() != s.Hour || now.Minute() != s.Minute {
+		return false
+	}
+
+	if len(s.Weekdays) == 0 {
+		return true
+	}
+
+	for _, day := range s.Weekdays {
+		if time.Weekday(day) == now.Weekday() {
+			return true
+		}
+	}
+
+	return false
+}
+
+type Group struct {
+	Id              bson.ObjectId `bson:"_id,omitempty" json:"id"`
+	Name            string        `bson:"name" json:"name"`
+	Organization    bson.ObjectId `bson:"organization" json:"organization"`
+	Disabled        bool          `bson:"disabled" json:"disabled"`
+	Zone            bson.ObjectId `bson:"zone" json:"zone"`
+	Template        bson.ObjectId `bson:"template" json:"template"`
+	TemplateVersion int           `bson:"template_version" json:"template_version"`
+	Image           bson.ObjectId `bson:"image,omitempty" json:"image"`
+	Count           int           `bson:"count" json:"count"`
+	MinCount        int           `bson:"min_count" json:"min_count"`
+	MaxCount        int           `bson:"max_count" json:"max_count"`
+	Surge           int           `bson:"surge" json:"surge"`
+	MaxUnavailable  int           `bson:"max_unavailable" json:"max_unavailable"`
+	AutoScaling     *AutoScaling  `bson:"auto_scaling" json:"auto_scaling"`
+	Schedules       []*Schedule   `bson:"schedules" json:"schedules"`
+	Scaled          time.Time     `bson:"scaled" json:"scaled"`
+	Status          string        `bson:"status" json:"status"`
+	Message         string        `bson:"message" json:"message"`
+}
+
+func (g *Group) Validate(db *database.Database) (
+	errData *errortypes.ErrorData, err error) {
+
+	if g.Organization == "" {
+		errData = &errortypes.ErrorData{
+			Error:   "organization_required",
+			Message: "Missing required organization",
+		}
+		return
+	}
+
+	if g.Zone == "" {
+		errData = &errortypes.ErrorData{
+			Error:   "zone_required",
+			Message: "Missing required zone",
+		}
+		return
+	}
+
+	if g.Template == "" {
+		errData = &errortypes.ErrorData{
+			Error:   "template_required",
+			Message: "Missing required template",
+		}
+		return
+	}
+
+	exists, err := template.ExistsOrg(db, g.Organization, g.Template)
+	if err != nil {
+		return
+	}
+	if !exists {
+		errData = &errortypes.ErrorData{
+			Error:   "template_invalid",
+			Message: "Template not found in organization",
+		}
+		return
+	}
+
+	if g.MaxCount == 0 {
+		g.MaxCount = MaxCount
+	}
+
+	if g.MinCount < 0 || g.MaxCount > MaxCount || g.MinCount > g.MaxCount {
+		errData = &errortypes.ErrorData{
+			Error:   "count_invalid",
+			Message: "Group instance count range invalid",
+		}
+		return
+	}
+
+	if g.Count < g.MinCount {
+		g.Count = g.MinCount
+	}
+	if g.Count > g.MaxCount {
+		g.Count = g.MaxCount
+	}
+
+	if g.Surge < 0 || g.MaxUnavailable < 0 {
+		errData = &errortypes.ErrorData{
+			Error:   "rollout_invalid",
+			Message: "Surge and max unavailable cannot be negative",
+		}
+		return
+	}
+
+	if g.Surge == 0 && g.MaxUnavailable == 0 {
+		g.Surge = 1
+	}
+
+	if g.AutoScaling == nil {
+		g.AutoScaling = &AutoScaling{}
+	}
+
+	if g.AutoScaling.CpuHigh <= 0 {
+		g.AutoScaling.CpuHigh = 75
+	}
+	if g.AutoScaling.CpuLow <= 0 {
+		g.AutoScaling.CpuLow = 25
+	}
+	if g.AutoScaling.Period < 1 {
+		g.AutoScaling.Period = 5
+	}
+	if g.AutoScaling.Cooldown < 1 {
+		g.AutoScaling.Cooldown = 10
+	}
+	if g.AutoScaling.Step < 1 {
+		g.AutoScaling.Step = 1
+	}
+
+	if g.AutoScaling.CpuLow >= g.AutoScaling.CpuHigh {
+		errData = &errortypes.ErrorData{
+			Error:   "auto_scaling_invalid",
+			Message: "Scale down threshold must be below scale up threshold",
+		}
+		return
+	}
+
+	if g.Schedules == nil {
+		g.Schedules = []*Schedule{}
+	}
+
+	for _, sched := range g.Schedules {
+		if sched.Hour < 0 || sched.Hour > 23 ||
+			sched.Minute < 0 || sched.Minute > 59 {
+
+			errData = &errortypes.ErrorData{
+				Error:   "schedule_invalid",
+				Message: "Schedule time invalid",
+			}
+			return
+		}
+
+		if sched.Count < g.MinCount || sched.Count > g.MaxCount {
+			errData = &errortypes.ErrorData{
+				Error:   "schedule_invalid",
+				Message: "Schedule count outside of group range",
+			}
+			return
+		}
+
+		if sched.Weekdays == nil {
+			sched.Weekdays = []int{}
+		}
+	}
+
+	if g.Status == "" {
+		g.Status = Stable
+	}
+
+	return
+}
+
+func (g *Group) Commit(db *database.Database) (err error) {
+	coll := db.Groups()
+
+	err = coll.Commit(g.Id, g)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (g *Group) CommitFields(db *database.Database, fields set.Set) (
+	err error) {
+
+	coll := db.Groups()
+
+	err = coll.CommitFields(g.Id, g, fields)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (g *Group) Insert(db *database.Database) (err error) {
+	coll := db.Groups()
+
+	if g.Id != "" {
+		err = &errortypes.DatabaseError{
+			errors.New("group: Group already exists"),
+		}
+		return
+	}
+
+	g.Id = bson.NewObjectId()
+
+	err = coll.Insert(g)
+	if err != nil {
+		g.Id = ""
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
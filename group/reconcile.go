@@ -0,0 +1,357 @@
+package group
+
+import (
+	"fmt"
+	"github.com/Sirupsen/logrus"
+	"github.com/dropbox/godropbox/container/set"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/event"
+	"github.com/pritunl/pritunl-cloud/image"
+	"github.com/pritunl/pritunl-cloud/instance"
+	"github.com/pritunl/pritunl-cloud/metric"
+	"github.com/pritunl/pritunl-cloud/node"
+	"github.com/pritunl/pritunl-cloud/template"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"github.com/pritunl/pritunl-cloud/vm"
+	"gopkg.in/mgo.v2/bson"
+	"strings"
+	"time"
+)
+
+func (g *Group) scale(db *database.Database, now time.Time,
+	running []bson.ObjectId) (err error) {
+
+	count := g.Count
+
+	for _, sched := range g.Schedules {
+		if sched.Match(now) {
+			count = sched.Count
+		}
+	}
+
+	scaling := g.AutoScaling
+	cooldown := time.Duration(scaling.Cooldown) * time.Minute
+	if count == g.Count && scaling.Enabled && len(running) > 0 &&
+		now.Sub(g.Scaled) >= cooldown {
+
+		period := time.Duration(scaling.Period) * time.Minute
+		cpu, samples, e := metric.GetCpuAverage(db, running, now.Add(-period))
+		if e != nil {
+			err = e
+			return
+		}
+
+		if samples > 0 {
+			if cpu >= scaling.CpuHigh {
+				count += scaling.Step
+			} else if cpu <= scaling.CpuLow {
+				count -= scaling.Step
+			}
+		}
+	}
+
+	count = utils.Min(utils.Max(count, g.MinCount), g.MaxCount)
+	if count == g.Count {
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"group_id":  g.Id.Hex(),
+		"cur_count": g.Count,
+		"new_count": count,
+	}).Info("group: Scaling instance group")
+
+	g.Count = count
+	g.Scaled = now
+
+	err = g.CommitFields(db, set.NewSet("count", "scaled"))
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (g *Group) launch(db *database.Database, spec *template.Spec,
+	version, count int, insts []*instance.Instance) (err error) {
+
+	nodes, err := node.GetAllHypervisors(db, &bson.M{
+		"zone": g.Zone,
+	})
+	if err != nil {
+		return
+	}
+
+	if len(nodes) == 0 {
+		g.Status = Degraded
+		g.Message = "No hypervisor nodes available in zone"
+		return
+	}
+
+	nodeCount := map[bson.ObjectId]int{}
+	for _, nde := range nodes {
+		nodeCount[nde.Id] = 0
+	}
+	for _, inst := range insts {
+		if _, ok := nodeCount[inst.Node]; ok {
+			nodeCount[inst.Node] += 1
+		}
+	}
+
+	for i := 0; i < count; i++ {
+		var nde *node.Node
+		for _, n := range nodes {
+			if nde == nil || nodeCount[n.Id] < nodeCount[nde.Id] {
+				nde = n
+			}
+		}
+		nodeCount[nde.Id] += 1
+
+		suffix, e := utils.RandStr(6)
+		if e != nil {
+			err = e
+			return
+		}
+
+		instSpec := spec.Copy()
+		instSpec.Zone = g.Zone
+		instSpec.Node = nde.Id
+		// Group instances share the template, a static address can only
+		// be held by one instance
+		instSpec.StaticIp = ""
+
+		lnch := &template.Launch{
+			Organization: g.Organization,
+			Template:     g.Template,
+			Version:      version,
+			Group:        g.Id,
+			Spec:         instSpec,
+			Name:         fmt.Sprintf("%s-%s", g.Name, strings.ToLower(suffix)),
+			Count:        1,
+		}
+
+		errData, e := lnch.Validate(db)
+		if e != nil {
+			err = e
+			return
+		}
+
+		if errData == nil {
+			_, errData, err = lnch.Create(db)
+			if err != nil {
+				return
+			}
+		}
+
+		if errData != nil {
+			g.Status = Degraded
+			g.Message = errData.Message
+			return
+		}
+	}
+
+	return
+}
+
+func (g *Group) Reconcile(db *database.Database, now time.Time) (
+	changed bool, err error) {
+
+	curStatus := g.Status
+	curMessage := g.Message
+	g.Status = Stable
+	g.Message = ""
+
+	tmpl, err := template.GetOrg(db, g.Organization, g.Template)
+	if err != nil {
+		return
+	}
+
+	spec, version, err := tmpl.GetSpec(db, g.TemplateVersion)
+	if err != nil {
+		return
+	}
+
+	spec.Zone = g.Zone
+
+	if g.Image != "" {
+		spec.Image = g.Image
+		spec.ImageFamily = ""
+	} else {
+		errData, e := spec.ResolveImage(db, g.Organization)
+		if e != nil {
+			err = e
+			return
+		}
+
+		if errData != nil {
+			g.Status = Degraded
+			g.Message = errData.Message
+
+			if g.Status != curStatus || g.Message != curMessage {
+				err = g.CommitFields(db, set.NewSet("status", "message"))
+				if err != nil {
+					return
+				}
+			}
+
+			return
+		}
+	}
+
+	// Shared images can be revoked after the group is created
+	exists, err := image.ExistsOrg(db, g.Organization, spec.Image)
+	if err != nil {
+		return
+	}
+
+	if !exists {
+		g.Status = Degraded
+		g.Message = "Image not available to organization"
+
+		if g.Status != curStatus || g.Message != curMessage {
+			err = g.CommitFields(db, set.NewSet("status", "message"))
+			if err != nil {
+				return
+			}
+		}
+
+		return
+	}
+
+	insts, err := instance.GetAll(db, &bson.M{
+		"group": g.Id,
+	})
+	if err != nil {
+		return
+	}
+
+	active := []*instance.Instance{}
+	updated := []*instance.Instance{}
+	outdated := []*instance.Instance{}
+	running := []bson.ObjectId{}
+	remove := []bson.ObjectId{}
+
+	for _, inst := range insts {
+		if inst.State == instance.Destroy {
+			continue
+		}
+
+		// Failed instances are replaced during the launch below
+		if inst.VmState == vm.Failed {
+			remove = append(remove, inst.Id)
+			continue
+		}
+
+		active = append(active, inst)
+		if inst.VmState == vm.Running {
+			running = append(running, inst.Id)
+		}
+
+		if inst.TemplateVersion == version && inst.Image == spec.Image {
+			updated = append(updated, inst)
+		} else {
+			outdated = append(outdated, inst)
+		}
+	}
+
+	err = g.scale(db, now, running)
+	if err != nil {
+		return
+	}
+
+	create := utils.Min(g.Count-len(updated), g.Count+g.Surge-len(active))
+	if create > 0 {
+		g.Status = Scaling
+
+		err = g.launch(db, spec, version, create, active)
+		if err != nil {
+			return
+		}
+
+		changed = true
+	}
+
+	available := len(running)
+	minAvailable := g.Count - g.MaxUnavailable
+
+	for _, inst := range outdated {
+		if inst.VmState == vm.Running {
+			if available-1 < minAvailable {
+				continue
+			}
+			available -= 1
+		}
+		remove = append(remove, inst.Id)
+	}
+
+	if len(outdated) > 0 && g.Status != Degraded {
+		g.Status = Updating
+	}
+
+	// Remove excess instances that have not started first
+	excess := len(updated) - g.Count
+	for i := len(updated) - 1; i >= 0 && excess > 0; i-- {
+		if updated[i].VmState != vm.Running {
+			remove = append(remove, updated[i].Id)
+			excess -= 1
+		}
+	}
+	for i := len(updated) - 1; i >= 0 && excess > 0; i-- {
+		if updated[i].VmState == vm.Running {
+			remove = append(remove, updated[i].Id)
+			excess -= 1
+		}
+	}
+
+	if len(remove) > 0 {
+		err = instance.DeleteMulti(db, remove)
+		if err != nil {
+			return
+		}
+
+		changed = true
+	}
+
+	if g.Status != curStatus || g.Message != curMessage {
+		err = g.CommitFields(db, set.NewSet("status", "message"))
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func Reconcile(db *database.Database) (err error) {
+	now := time.Now()
+	changed := false
+
+	grps, err := GetAll(db, &bson.M{
+		"disabled": false,
+	})
+	if err != nil {
+		return
+	}
+
+	for _, grp := range grps {
+		grpChanged, e := grp.Reconcile(db, now)
+		if e != nil {
+			logrus.WithFields(logrus.Fields{
+				"group_id":   grp.Id.Hex(),
+				"group_name": grp.Name,
+				"error":      e,
+			}).Error("group: Failed to reconcile instance group")
+			continue
+		}
+
+		if grpChanged {
+			changed = true
+		}
+	}
+
+	if changed {
+		event.PublishDispatch(db, "instance.change")
+	}
+
+	return
+}
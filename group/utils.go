@@ -0,0 +1,239 @@
+package group
+
+import (
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/instance"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func Get(db *database.Database, grpId bson.ObjectId) (
+	grp *Group, err error) {
+
+	coll := db.Groups()
+	grp = &Group{}
+
+	err = coll.FindOneId(grpId, grp)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func GetOrg(db *database.Database, orgId, grpId bson.ObjectId) (
+	grp *Group, err error) {
+
+	coll := db.Groups()
+	grp = &Group{}
+
+	err = coll.FindOne(&bson.M{
+		"_id":          grpId,
+		"organization": orgId,
+	}, grp)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func ExistsOrg(db *database.Database, orgId, grpId bson.ObjectId) (
+	exists bool, err error) {
+
+	coll := db.Groups()
+
+	n, err := coll.Find(&bson.M{
+		"_id":          grpId,
+		"organization": orgId,
+	}).Count()
+	if err != nil {
+		return
+	}
+
+	if n > 0 {
+		exists = true
+	}
+
+	return
+}
+
+func GetAll(db *database.Database, query *bson.M) (
+	grps []*Group, err error) {
+
+	coll := db.Groups()
+	grps = []*Group{}
+
+	cursor := coll.Find(query).Iter()
+
+	grp := &Group{}
+	for cursor.Next(grp) {
+		grps = append(grps, grp)
+		grp = &Group{}
+	}
+
+	err = cursor.Close()
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+func GetAllPaged(db *database.Database, query *bson.M, page, pageCount int) (
+	grps []*Group, count int, err error) {
+
+	coll := db.Groups()
+	grps = []*Group{}
+
+	qury := coll.Find(query)
+
+	count, err = qury.Count()
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	skip := utils.Min(page*pageCount, utils.Max(0, count-pageCount))
+
+	cursor := qury.Sort("name").Skip(skip).Limit(pageCount).Iter()
+
+	grp := &Group{}
+	for cursor.Next(grp) {
+		grps = append(grps, grp)
+		grp = &Group{}
+	}
+
+	err = cursor.Close()
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+func destroyInstances(db *database.Database, query *bson.M) (err error) {
+	coll := db.Instances()
+
+	_, err = coll.UpdateAll(query, &bson.M{
+		"$set": &bson.M{
+			"state": instance.Destroy,
+		},
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+func Remove(db *database.Database, grpId bson.ObjectId) (err error) {
+	coll := db.Groups()
+
+	err = coll.Remove(&bson.M{
+		"_id": grpId,
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		switch err.(type) {
+		case *database.NotFoundError:
+			err = nil
+		default:
+			return
+		}
+	}
+
+	err = destroyInstances(db, &bson.M{
+		"group": grpId,
+	})
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func RemoveOrg(db *database.Database, orgId, grpId bson.ObjectId) (
+	err error) {
+
+	coll := db.Groups()
+
+	err = coll.Remove(&bson.M{
+		"_id":          grpId,
+		"organization": orgId,
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		switch err.(type) {
+		case *database.NotFoundError:
+			err = nil
+		}
+		return
+	}
+
+	err = destroyInstances(db, &bson.M{
+		"group": grpId,
+	})
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func RemoveMulti(db *database.Database, grpIds []bson.ObjectId) (err error) {
+	coll := db.Groups()
+
+	_, err = coll.RemoveAll(&bson.M{
+		"_id": &bson.M{
+			"$in": grpIds,
+		},
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	err = destroyInstances(db, &bson.M{
+		"group": &bson.M{
+			"$in": grpIds,
+		},
+	})
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func RemoveMultiOrg(db *database.Database, orgId bson.ObjectId,
+	grpIds []bson.ObjectId) (err error) {
+
+	coll := db.Groups()
+
+	_, err = coll.RemoveAll(&bson.M{
+		"_id": &bson.M{
+			"$in": grpIds,
+		},
+		"organization": orgId,
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	err = destroyInstances(db, &bson.M{
+		"group": &bson.M{
+			"$in": grpIds,
+		},
+		"organization": orgId,
+	})
+	if err != nil {
+		return
+	}
+
+	return
+}
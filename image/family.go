@@ -0,0 +1,182 @@
+package image
+
+import (
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"gopkg.in/mgo.v2/bson"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	versionReg = regexp.MustCompile("[0-9]+|[^0-9._-]+")
+)
+
+func ParseKey(reg *regexp.Regexp, key string) (family, version string) {
+	if reg == nil {
+		return
+	}
+
+	match := reg.FindStringSubmatch(key)
+	if match == nil {
+		return
+	}
+
+	for i, name := range reg.SubexpNames() {
+		switch name {
+		case "family":
+			family = strings.ToLower(match[i])
+		case "version":
+			version = match[i]
+		}
+	}
+
+	if family == "" || version == "" {
+		family = ""
+		version = ""
+	}
+
+	return
+}
+
+func CompareVersion(x, y string) int {
+	xParts := versionReg.FindAllString(x, -1)
+	yParts := versionReg.FindAllString(y, -1)
+
+	for i := 0; i < len(xParts) && i < len(yParts); i++ {
+		xNum, xErr := strconv.ParseUint(xParts[i], 10, 64)
+		yNum, yErr := strconv.ParseUint(yParts[i], 10, 64)
+
+		if xErr == nil && yErr == nil {
+			if xNum != yNum {
+				if xNum > yNum {
+					return 1
+				}
+				return -1
+			}
+		} else if xErr == nil {
+			return 1
+		} else if yErr == nil {
+			return -1
+		} else if c := strings.Compare(xParts[i], yParts[i]); c != 0 {
+			return c
+		}
+	}
+
+	if len(xParts) > len(yParts) {
+		return 1
+	} else if len(xParts) < len(yParts) {
+		return -1
+	}
+
+	return 0
+}
+
+func (i *Image) Launchable() (errData *errortypes.ErrorData) {
+	if i.Obsolete {
+		errData = &errortypes.ErrorData{
+			Error:   "image_obsolete",
+			Message: "Image version is obsolete",
+		}
+		return
+	}
+
+	if i.Deprecated {
+		errData = &errortypes.ErrorData{
+			Error:   "image_deprecated",
+			Message: "Image version is deprecated",
+		}
+		return
+	}
+
+	return
+}
+
+func getFamily(db *database.Database, query *bson.M) (
+	img *Image, err error) {
+
+	coll := db.Images()
+
+	cursor := coll.Find(query).Iter()
+
+	cur := &Image{}
+	for cursor.Next(cur) {
+		if img == nil {
+			img = cur
+		} else if c := CompareVersion(cur.Version, img.Version); c > 0 ||
+			(c == 0 && cur.LastModified.After(img.LastModified)) {
+
+			img = cur
+		}
+		cur = &Image{}
+	}
+
+	err = cursor.Close()
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+func GetFamily(db *database.Database, orgId bson.ObjectId,
+	family string, storages []bson.ObjectId) (img *Image, err error) {
+
+	img, err = getFamily(db, &bson.M{
+		"family": strings.ToLower(family),
+		"storage": &bson.M{
+			"$in": storages,
+		},
+		"deprecated": &bson.M{
+			"$ne": true,
+		},
+		"obsolete": &bson.M{
+			"$ne": true,
+		},
+		"$or": []*bson.M{
+			&bson.M{
+				"organization": orgId,
+			},
+			&bson.M{
+				"organization": &bson.M{
+					"$exists": false,
+				},
+			},
+			&bson.M{
+				"shares": orgId,
+			},
+		},
+	})
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func GetFamilyPublic(db *database.Database, family string,
+	storages []bson.ObjectId) (img *Image, err error) {
+
+	img, err = getFamily(db, &bson.M{
+		"family": strings.ToLower(family),
+		"storage": &bson.M{
+			"$in": storages,
+		},
+		"organization": &bson.M{
+			"$exists": false,
+		},
+		"deprecated": &bson.M{
+			"$ne": true,
+		},
+		"obsolete": &bson.M{
+			"$ne": true,
+		},
+	})
+	if err != nil {
+		return
+	}
+
+	return
+}
@@ -0,0 +1,62 @@
+package image
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestCompareVersion(t *testing.T) {
+	tests := []struct {
+		x      string
+		y      string
+		result int
+	}{
+		{"1.0", "1.0", 0},
+		{"", "", 0},
+		{"1.10", "1.9", 1},
+		{"1.9", "1.10", -1},
+		{"20.04", "18.04", 1},
+		{"7", "7.1", -1},
+		{"7.1", "7", 1},
+		{"2018.01", "2018.1", 0},
+		{"8-20200101", "8-20191231", 1},
+		{"1.0a", "1.0b", -1},
+		{"1.0b", "1.0a", 1},
+		{"2", "a", 1},
+		{"a", "2", -1},
+	}
+
+	for _, test := range tests {
+		result := CompareVersion(test.x, test.y)
+		if result != test.result {
+			t.Errorf("CompareVersion(%q, %q) = %d, expected %d",
+				test.x, test.y, result, test.result)
+		}
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	reg := regexp.MustCompile(
+		`^(?P<family>[a-zA-Z]+)_(?P<version>[0-9.]+)\.qcow2$`)
+
+	tests := []struct {
+		reg     *regexp.Regexp
+		key     string
+		family  string
+		version string
+	}{
+		{reg, "Ubuntu_20.04.qcow2", "ubuntu", "20.04"},
+		{reg, "centos_8.qcow2", "centos", "8"},
+		{reg, "centos_.qcow2", "", ""},
+		{reg, "centos.qcow2", "", ""},
+		{nil, "centos_8.qcow2", "", ""},
+	}
+
+	for _, test := range tests {
+		family, version := ParseKey(test.reg, test.key)
+		if family != test.family || version != test.version {
+			t.Errorf("ParseKey(%q) = (%q, %q), expected (%q, %q)",
+				test.key, family, version, test.family, test.version)
+		}
+	}
+}
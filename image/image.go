@@ -4,32 +4,93 @@ import (
 	"github.com/dropbox/godropbox/container/set"
 	"github.com/pritunl/pritunl-cloud/database"
 	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/verify"
 	"gopkg.in/mgo.v2/bson"
 	"time"
 )
 
 type Image struct {
-	Id           bson.ObjectId `bson:"_id,omitempty" json:"id"`
-	Name         string        `bson:"name" json:"name"`
-	Organization bson.ObjectId `bson:"organization" json:"organization"`
-	Signed       bool          `bson:"signed" json:"signed"`
-	Type         string        `bson:"type" json:"type"`
-	Storage      bson.ObjectId `bson:"storage" json:"storage"`
-	Key          string        `bson:"key" json:"key"`
-	LastModified time.Time     `bson:"last_modified" json:"last_modified"`
-	Etag         string        `bson:"etag" json:"etag"`
+	Id              bson.ObjectId   `bson:"_id,omitempty" json:"id"`
+	Name            string          `bson:"name" json:"name"`
+	Organization    bson.ObjectId   `bson:"organization" json:"organization"`
+	Shares          []bson.ObjectId `bson:"shares" json:"shares"`
+	Shared          bool            `bson:"-" json:"shared"`
+	Family          string          `bson:"family" json:"family"`
+	Version         string          `bson:"version" json:"version"`
+	Deprecated      bool            `bson:"deprecated" json:"deprecated"`
+	Obsolete        bool            `bson:"obsolete" json:"obsolete"`
+	Signed          bool            `bson:"signed" json:"signed"`
+	Signature       string          `bson:"signature" json:"signature"`
+	SignatureStatus string          `bson:"-" json:"signature_status"`
+	SignatureKey    string          `bson:"signature_key" json:"signature_key"`
+	VerifiedEtag    string          `bson:"verified_etag" json:"-"`
+	InvalidEtag     string          `bson:"invalid_etag" json:"-"`
+	Type            string          `bson:"type" json:"type"`
+	Storage         bson.ObjectId   `bson:"storage" json:"storage"`
+	Key             string          `bson:"key" json:"key"`
+	LastModified    time.Time       `bson:"last_modified" json:"last_modified"`
+	Etag            string          `bson:"etag" json:"etag"`
+	Size            int64           `bson:"size" json:"size"`
 }
 
 func (i *Image) Validate(db *database.Database) (
 	errData *errortypes.ErrorData, err error) {
 
+	if i.Shares == nil {
+		i.Shares = []bson.ObjectId{}
+	}
+
+	if len(i.Shares) > 0 && i.Organization == "" {
+		errData = &errortypes.ErrorData{
+			Error:   "image_share_invalid",
+			Message: "Only organization images can be shared",
+		}
+		return
+	}
+
+	shares := []bson.ObjectId{}
+	sharesSet := set.NewSet()
+	for _, orgId := range i.Shares {
+		if orgId == i.Organization || sharesSet.Contains(orgId) {
+			continue
+		}
+		sharesSet.Add(orgId)
+		shares = append(shares, orgId)
+	}
+	i.Shares = shares
+
 	return
 }
 
+func (i *Image) GetSignatureStatus() string {
+	if i.InvalidEtag != "" && i.InvalidEtag == i.Etag {
+		return verify.Invalid
+	}
+	if !i.Signed {
+		return verify.Unsigned
+	}
+	if i.VerifiedEtag != "" && i.VerifiedEtag == i.Etag {
+		return verify.Verified
+	}
+	return verify.Unverified
+}
+
 func (i *Image) Json() {
 	if i.Name == "" {
 		i.Name = i.Key
 	}
+	i.SignatureStatus = i.GetSignatureStatus()
+	if i.SignatureStatus != verify.Verified {
+		i.SignatureKey = ""
+	}
+}
+
+func (i *Image) JsonOrg(orgId bson.ObjectId) {
+	i.Json()
+	if i.Organization != orgId {
+		i.Shared = i.Organization != ""
+		i.Shares = nil
+	}
 }
 
 func (i *Image) Commit(db *database.Database) (err error) {
@@ -79,9 +140,13 @@ func (i *Image) Upsert(db *database.Database) (err error) {
 			"storage":       i.Storage,
 			"key":           i.Key,
 			"signed":        i.Signed,
+			"signature":     i.Signature,
+			"family":        i.Family,
+			"version":       i.Version,
 			"type":          i.Type,
 			"etag":          i.Etag,
 			"last_modified": i.LastModified,
+			"size":          i.Size,
 		},
 	})
 	if err != nil {
@@ -58,6 +58,30 @@ func GetOrg(db *database.Database, orgId, imgId bson.ObjectId) (
 	return
 }
 
+func GetOrgShared(db *database.Database, orgId, imgId bson.ObjectId) (
+	img *Image, err error) {
+
+	coll := db.Images()
+	img = &Image{}
+
+	err = coll.FindOne(&bson.M{
+		"_id": imgId,
+		"$or": []*bson.M{
+			&bson.M{
+				"organization": orgId,
+			},
+			&bson.M{
+				"shares": orgId,
+			},
+		},
+	}, img)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
 func Distinct(db *database.Database, storeId bson.ObjectId) (
 	keys []string, err error) {
 
@@ -90,6 +114,9 @@ func ExistsOrg(db *database.Database, orgId, imgId bson.ObjectId) (
 					"$exists": false,
 				},
 			},
+			&bson.M{
+				"shares": orgId,
+			},
 		},
 	}).Count()
 	if err != nil {
@@ -142,8 +169,17 @@ func GetAllNames(db *database.Database, query *bson.M) (
 	images = []*Image{}
 
 	cursor := coll.Find(query).Sort("key").Select(&bson.M{
-		"name": 1,
-		"key":  1,
+		"name":          1,
+		"organization":  1,
+		"key":           1,
+		"family":        1,
+		"version":       1,
+		"deprecated":    1,
+		"obsolete":      1,
+		"etag":          1,
+		"signed":        1,
+		"verified_etag": 1,
+		"invalid_etag":  1,
 	}).Iter()
 
 	img := &Image{}
@@ -203,6 +239,68 @@ func Remove(db *database.Database, imgId bson.ObjectId) (err error) {
 	return
 }
 
+func SetVerified(db *database.Database, imgId bson.ObjectId,
+	etag, keyId string) (err error) {
+
+	coll := db.Images()
+
+	err = coll.Update(&bson.M{
+		"_id": imgId,
+	}, &bson.M{
+		"$set": &bson.M{
+			"verified_etag": etag,
+			"invalid_etag":  "",
+			"signature_key": keyId,
+		},
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+func SetInvalid(db *database.Database, imgId bson.ObjectId,
+	etag string) (err error) {
+
+	coll := db.Images()
+
+	err = coll.Update(&bson.M{
+		"_id": imgId,
+	}, &bson.M{
+		"$set": &bson.M{
+			"invalid_etag":  etag,
+			"signature_key": "",
+		},
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+// ClearVerified resets the signature status of all images
+func ClearVerified(db *database.Database) (err error) {
+	coll := db.Images()
+
+	_, err = coll.UpdateAll(&bson.M{}, &bson.M{
+		"$set": &bson.M{
+			"verified_etag": "",
+			"invalid_etag":  "",
+			"signature_key": "",
+		},
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
 func RemoveKeys(db *database.Database, storeId bson.ObjectId,
 	keys []string) (err error) {
 	coll := db.Images()
@@ -6,36 +6,45 @@ import (
 	"github.com/pritunl/pritunl-cloud/database"
 	"github.com/pritunl/pritunl-cloud/disk"
 	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/image"
 	"github.com/pritunl/pritunl-cloud/paths"
 	"github.com/pritunl/pritunl-cloud/vm"
 	"github.com/pritunl/pritunl-cloud/vpc"
 	"gopkg.in/mgo.v2/bson"
+	"net"
 	"strconv"
+	"strings"
 )
 
 type Instance struct {
-	Id           bson.ObjectId      `bson:"_id,omitempty" json:"id"`
-	Organization bson.ObjectId      `bson:"organization" json:"organization"`
-	Zone         bson.ObjectId      `bson:"zone" json:"zone"`
-	Vpc          bson.ObjectId      `bson:"vpc" json:"vpc"`
-	Image        bson.ObjectId      `bson:"image" json:"image"`
-	Status       string             `bson:"-" json:"status"`
-	State        string             `bson:"state" json:"state"`
-	VmState      string             `bson:"vm_state" json:"vm_state"`
-	Restart      bool               `bson:"restart" json:"restart"`
-	PublicIps    []string           `bson:"public_ips" json:"public_ips"`
-	PublicIps6   []string           `bson:"public_ips6" json:"public_ips6"`
-	PrivateIps   []string           `bson:"private_ips" json:"private_ips"`
-	PrivateIps6  []string           `bson:"private_ips6" json:"private_ips6"`
-	Node         bson.ObjectId      `bson:"node" json:"node"`
-	Domain       bson.ObjectId      `bson:"domain,omitempty" json:"domain"`
-	Name         string             `bson:"name" json:"name"`
-	InitDiskSize int                `bson:"init_disk_size" json:"init_disk_size"`
-	Memory       int                `bson:"memory" json:"memory"`
-	Processors   int                `bson:"processors" json:"processors"`
-	NetworkRoles []string           `bson:"network_roles" json:"network_roles"`
-	Virt         *vm.VirtualMachine `bson:"-" json:"-"`
-	curVpc       bson.ObjectId      `bson:"-" json:"-"`
+	Id              bson.ObjectId      `bson:"_id,omitempty" json:"id"`
+	Organization    bson.ObjectId      `bson:"organization" json:"organization"`
+	Zone            bson.ObjectId      `bson:"zone" json:"zone"`
+	Vpc             bson.ObjectId      `bson:"vpc" json:"vpc"`
+	Image           bson.ObjectId      `bson:"image" json:"image"`
+	Status          string             `bson:"-" json:"status"`
+	State           string             `bson:"state" json:"state"`
+	VmState         string             `bson:"vm_state" json:"vm_state"`
+	Restart         bool               `bson:"restart" json:"restart"`
+	PublicIps       []string           `bson:"public_ips" json:"public_ips"`
+	PublicIps6      []string           `bson:"public_ips6" json:"public_ips6"`
+	PrivateIps      []string           `bson:"private_ips" json:"private_ips"`
+	PrivateIps6     []string           `bson:"private_ips6" json:"private_ips6"`
+	StaticIp        string             `bson:"static_ip" json:"static_ip"`
+	Node            bson.ObjectId      `bson:"node" json:"node"`
+	Domain          bson.ObjectId      `bson:"domain,omitempty" json:"domain"`
+	Name            string             `bson:"name" json:"name"`
+	InitDiskSize    int                `bson:"init_disk_size" json:"init_disk_size"`
+	Memory          int                `bson:"memory" json:"memory"`
+	Processors      int                `bson:"processors" json:"processors"`
+	NetworkRoles    []string           `bson:"network_roles" json:"network_roles"`
+	UserData        string             `bson:"user_data" json:"user_data"`
+	Template        bson.ObjectId      `bson:"template,omitempty" json:"template"`
+	TemplateVersion int                `bson:"template_version,omitempty" json:"template_version"`
+	Group           bson.ObjectId      `bson:"group,omitempty" json:"group"`
+	Virt            *vm.VirtualMachine `bson:"-" json:"-"`
+	curVpc          bson.ObjectId      `bson:"-" json:"-"`
+	curStaticIp     string             `bson:"-" json:"-"`
 }
 
 func (i *Instance) Validate(db *database.Database) (
@@ -103,6 +112,29 @@ func (i *Instance) Validate(db *database.Database) (
 		i.NetworkRoles = []string{}
 	}
 
+	if i.UserData != "" && !strings.HasPrefix(i.UserData, "#cloud-config") &&
+		!strings.HasPrefix(i.UserData, "#!") {
+
+		errData = &errortypes.ErrorData{
+			Error:   "user_data_invalid",
+			Message: "User data must be a cloud-config or script",
+		}
+		return
+	}
+
+	if i.Id == "" && i.Image != "" {
+		img, e := image.Get(db, i.Image)
+		if e != nil {
+			err = e
+			return
+		}
+
+		errData = img.Launchable()
+		if errData != nil {
+			return
+		}
+	}
+
 	if i.PublicIps == nil {
 		i.PublicIps = []string{}
 	}
@@ -119,6 +151,45 @@ func (i *Instance) Validate(db *database.Database) (
 		i.PrivateIps6 = []string{}
 	}
 
+	if i.StaticIp != "" && i.Vpc != "" {
+		vc, e := vpc.Get(db, i.Vpc)
+		if e != nil {
+			err = e
+			return
+		}
+
+		errData, err = vc.ValidateIp(db, i.StaticIp, i.Id)
+		if err != nil || errData != nil {
+			return
+		}
+
+		i.StaticIp = net.ParseIP(i.StaticIp).To4().String()
+
+		query := bson.M{
+			"vpc":       i.Vpc,
+			"static_ip": i.StaticIp,
+		}
+		if i.Id != "" {
+			query["_id"] = &bson.M{
+				"$ne": i.Id,
+			}
+		}
+
+		n, e := db.Instances().Find(query).Count()
+		if e != nil {
+			err = database.ParseError(e)
+			return
+		}
+
+		if n > 0 {
+			errData = &errortypes.ErrorData{
+				Error:   "static_ip_in_use",
+				Message: "Static IP address in use by another instance",
+			}
+			return
+		}
+	}
+
 	return
 }
 
@@ -198,6 +269,7 @@ func (i *Instance) IsActive() bool {
 
 func (i *Instance) PreCommit() {
 	i.curVpc = i.Vpc
+	i.curStaticIp = i.StaticIp
 }
 
 func (i *Instance) PostCommit(db *database.Database) (err error) {
@@ -208,6 +280,26 @@ func (i *Instance) PostCommit(db *database.Database) (err error) {
 		}
 	}
 
+	if i.StaticIp == "" {
+		if i.curStaticIp != "" && i.curVpc == i.Vpc {
+			err = vpc.ReleaseStaticIp(db, i.Id, i.Vpc)
+			if err != nil {
+				return
+			}
+		}
+	} else if i.StaticIp != i.curStaticIp || i.curVpc != i.Vpc {
+		vc, e := vpc.Get(db, i.Vpc)
+		if e != nil {
+			err = e
+			return
+		}
+
+		err = vc.ReserveIp(db, i.Id, i.StaticIp)
+		if err != nil {
+			return
+		}
+	}
+
 	return
 }
 
@@ -245,12 +337,42 @@ func (i *Instance) Insert(db *database.Database) (err error) {
 		return
 	}
 
+	i.Id = bson.NewObjectId()
+
 	err = coll.Insert(i)
 	if err != nil {
+		i.Id = ""
 		err = database.ParseError(err)
 		return
 	}
 
+	// Reserve static address before the instance is deployed to prevent
+	// another instance from being assigned the address
+	if i.StaticIp != "" && i.Vpc != "" {
+		err = i.reserveStaticIp(db)
+		if err != nil {
+			coll.Remove(&bson.M{
+				"_id": i.Id,
+			})
+			i.Id = ""
+			return
+		}
+	}
+
+	return
+}
+
+func (i *Instance) reserveStaticIp(db *database.Database) (err error) {
+	vc, err := vpc.Get(db, i.Vpc)
+	if err != nil {
+		return
+	}
+
+	err = vc.ReserveIp(db, i.Id, i.StaticIp)
+	if err != nil {
+		return
+	}
+
 	return
 }
 
@@ -87,7 +87,9 @@ func GetAllVirt(db *database.Database, query *bson.M, disks []*disk.Disk) (
 
 	instanceDisks := map[bson.ObjectId][]*disk.Disk{}
 	for _, dsk := range disks {
-		if dsk.State != disk.Available && dsk.State != disk.Snapshot {
+		if dsk.State != disk.Available && dsk.State != disk.Snapshot &&
+			dsk.State != disk.Flatten {
+
 			continue
 		}
 
@@ -0,0 +1,200 @@
+package ipsec
+
+import (
+	"fmt"
+	"github.com/Sirupsen/logrus"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/certificate"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/node"
+	"github.com/pritunl/pritunl-cloud/settings"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"github.com/pritunl/pritunl-cloud/vm"
+	"github.com/pritunl/pritunl-cloud/vpc"
+	"gopkg.in/mgo.v2/bson"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	certName       = "vpc.pem"
+	certKeyName    = "vpc.key"
+	caName         = "vpc-ca.pem"
+	remoteCaPrefix = "remote-"
+)
+
+func getIpsecDir(vpcId bson.ObjectId) string {
+	namespace := vm.GetLinkNamespace(vpcId, 0)
+	return path.Join("/", "etc", "netns", namespace, "ipsec.d")
+}
+
+func getCertPath(vpcId bson.ObjectId) string {
+	return path.Join(getIpsecDir(vpcId), "certs", certName)
+}
+
+func getKeyPath(vpcId bson.ObjectId) string {
+	return path.Join(getIpsecDir(vpcId), "private", certKeyName)
+}
+
+func getCaPath(vpcId bson.ObjectId) string {
+	return path.Join(getIpsecDir(vpcId), "cacerts", caName)
+}
+
+func writeCert(vpcId bson.ObjectId, certPem, keyPem, caPem string) (
+	err error) {
+
+	baseDir := getIpsecDir(vpcId)
+
+	for _, dir := range []string{"certs", "private", "cacerts"} {
+		err = utils.ExistsMkdir(path.Join(baseDir, dir), 0700)
+		if err != nil {
+			return
+		}
+	}
+
+	err = ioutil.WriteFile(getKeyPath(vpcId), []byte(keyPem), 0600)
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "ipsec: Failed to write link key"),
+		}
+		return
+	}
+
+	err = ioutil.WriteFile(getCertPath(vpcId), []byte(certPem), 0644)
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "ipsec: Failed to write link certificate"),
+		}
+		return
+	}
+
+	if caPem != "" {
+		err = ioutil.WriteFile(getCaPath(vpcId), []byte(caPem), 0644)
+		if err != nil {
+			err = &errortypes.WriteError{
+				errors.Wrap(err, "ipsec: Failed to write link CA"),
+			}
+			return
+		}
+	} else {
+		err = utils.RemoveAll(getCaPath(vpcId))
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func writeRemoteCa(vpcId bson.ObjectId, id, caPem string) (
+	subject string, err error) {
+
+	subject, err = certificate.GetSubject(caPem)
+	if err != nil {
+		return
+	}
+
+	pth := path.Join(getIpsecDir(vpcId), "cacerts",
+		fmt.Sprintf("%s%s.pem", remoteCaPrefix, id))
+
+	err = ioutil.WriteFile(pth, []byte(caPem), 0644)
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "ipsec: Failed to write remote CA"),
+		}
+		return
+	}
+
+	return
+}
+
+func clearRemoteCas(vpcId bson.ObjectId) (err error) {
+	caDir := path.Join(getIpsecDir(vpcId), "cacerts")
+
+	items, err := ioutil.ReadDir(caDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+			return
+		}
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "ipsec: Failed to read CA directory"),
+		}
+		return
+	}
+
+	for _, item := range items {
+		if strings.HasPrefix(item.Name(), remoteCaPrefix) {
+			os.Remove(path.Join(caDir, item.Name()))
+		}
+	}
+
+	return
+}
+
+func syncCert(db *database.Database, vc *vpc.Vpc) (
+	certPem, caPem string, err error) {
+
+	if vc.LinkCert != "" {
+		cert, e := certificate.Get(db, vc.LinkCert)
+		if e != nil {
+			err = e
+			return
+		}
+
+		certPem = cert.Certificate
+
+		curCert, _ := ioutil.ReadFile(getCertPath(vc.Id))
+		if string(curCert) != certPem {
+			err = writeCert(vc.Id, cert.Certificate, cert.Key, "")
+			if err != nil {
+				return
+			}
+		}
+
+		return
+	}
+
+	caPem, caKey, err := vc.GetLinkCa(db)
+	if err != nil {
+		return
+	}
+
+	curCert, _ := ioutil.ReadFile(getCertPath(vc.Id))
+	if curCert != nil && len(curCert) > 0 &&
+		certificate.IsIssuer(string(curCert), caPem) {
+
+		expires, e := certificate.GetExpiration(string(curCert))
+		if e == nil && time.Until(expires) > time.Duration(
+			settings.Ipsec.CertRenew)*time.Hour {
+
+			certPem = string(curCert)
+			return
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"vpc_id": vc.Id.Hex(),
+	}).Info("ipsec: Generating link certificate")
+
+	certPem, keyPem, err := certificate.NewCert(
+		caPem,
+		caKey,
+		fmt.Sprintf("%s.%s", node.Self.Id.Hex(), vc.Id.Hex()),
+		time.Duration(settings.Ipsec.CertLifetime)*time.Hour,
+	)
+	if err != nil {
+		return
+	}
+
+	err = writeCert(vc.Id, certPem, keyPem, caPem)
+	if err != nil {
+		return
+	}
+
+	return
+}
@@ -11,7 +11,9 @@ const (
 	rekeymargin=9m
 	keyingtries=%forever
 	authby=secret
-	keyexchange=ikev2
+	keyexchange=ikev2{{if .Ike}}
+	ike={{.Ike}}{{end}}{{if .Esp}}
+	esp={{.Esp}}{{end}}
 	mobike=no
 	dpddelay=5s
 	dpdtimeout=20s
@@ -21,12 +23,50 @@ const (
 	leftsubnet={{.LeftSubnets}}
 	leftfirewall=yes
 	right={{.Right}}
-	rightid={{.Right}}
+	rightid="{{.RightId}}"
 	rightsubnet={{.RightSubnets}}
 	auto=start
 `
-	secretsTemplateStr = `{{.Left}} {{.Right}} : PSK "{{.PreSharedKey}}"
+	secretsTemplateStr = `{{.Left}} "{{.RightId}}" : PSK "{{.PreSharedKey}}"
 `
+	confCertTemplateStr = `conn {{.Id}}
+	ikelifetime=8h
+	keylife=1h
+	rekeymargin=9m
+	keyingtries=%forever
+	leftauth=pubkey
+	rightauth=pubkey
+	keyexchange=ikev2{{if .Ike}}
+	ike={{.Ike}}{{end}}{{if .Esp}}
+	esp={{.Esp}}{{end}}
+	mobike=no
+	dpddelay=5s
+	dpdtimeout=20s
+	dpdaction=restart
+	left=%defaultroute
+	leftid="{{.LeftId}}"
+	leftcert={{.LeftCert}}
+	leftsendcert=always
+	leftsubnet={{.LeftSubnets}}
+	leftfirewall=yes
+	right={{.Right}}
+	rightid="{{.RightId}}"
+	rightsubnet={{.RightSubnets}}{{if .RightCa}}
+	rightca="{{.RightCa}}"{{end}}
+	auto=start
+`
+	secretsCertTemplateStr = `: {{.KeyType}} {{.LeftKey}}
+`
+	wgConfTemplateStr = `[Interface]
+PrivateKey = {{.PrivateKey}}
+ListenPort = {{.ListenPort}}
+{{range .Peers}}
+[Peer]
+PublicKey = {{.PublicKey}}
+Endpoint = {{.Endpoint}}
+AllowedIPs = {{.AllowedIps}}
+PersistentKeepalive = 25
+{{end}}`
 )
 
 var (
@@ -34,4 +74,10 @@ var (
 		template.New("conf").Parse(confTemplateStr))
 	secretsTemplate = template.Must(
 		template.New("secrets").Parse(secretsTemplateStr))
+	confCertTemplate = template.Must(
+		template.New("conf_cert").Parse(confCertTemplateStr))
+	secretsCertTemplate = template.Must(
+		template.New("secrets_cert").Parse(secretsCertTemplateStr))
+	wgConfTemplate = template.Must(
+		template.New("wg_conf").Parse(wgConfTemplateStr))
 )
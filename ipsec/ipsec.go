@@ -42,7 +42,7 @@ func deploy(vpcId bson.ObjectId, states []*link.State) (err error) {
 		return
 	}
 
-	netAddr, err := vc.GetIp(db, vpc.Gateway, vc.Id)
+	netAddr, err := vc.GetLinkIp(db)
 	if err != nil {
 		return
 	}
@@ -58,7 +58,12 @@ func deploy(vpcId bson.ObjectId, states []*link.State) (err error) {
 		return
 	}
 
-	err = writeTemplates(vpcId, states)
+	err = writeTemplates(vc, states)
+	if err != nil {
+		return
+	}
+
+	err = deployWg(vpcId, states)
 	if err != nil {
 		return
 	}
@@ -17,7 +17,7 @@ import (
 )
 
 var (
-	networkStates     = map[bson.ObjectId]bool{}
+	networkStates     = map[bson.ObjectId]string{}
 	networkStatesLock = sync.Mutex{}
 	networkLock       = utils.NewMultiTimeoutLock(2 * time.Minute)
 )
@@ -31,7 +31,7 @@ func networkConf(vc *vpc.Vpc,
 	networkStatesLock.Lock()
 	networkState := networkStates[vc.Id]
 	networkStatesLock.Unlock()
-	if networkState {
+	if networkState == netAddr {
 		return
 	}
 
@@ -294,6 +294,19 @@ func networkConf(vc *vpc.Vpc,
 		return
 	}
 
+	if networkState != "" {
+		_, err = utils.ExecCombinedOutputLogged(
+			nil,
+			"ip", "netns", "exec", namespace,
+			"ip", "addr",
+			"flush", "dev", "br0",
+			"scope", "global",
+		)
+		if err != nil {
+			return
+		}
+	}
+
 	_, err = utils.ExecCombinedOutputLogged(
 		[]string{"File exists"},
 		"ip", "netns", "exec", namespace,
@@ -402,7 +415,7 @@ func networkConf(vc *vpc.Vpc,
 	}
 
 	networkStatesLock.Lock()
-	networkStates[vc.Id] = true
+	networkStates[vc.Id] = netAddr
 	networkStatesLock.Unlock()
 
 	return
@@ -0,0 +1,51 @@
+package ipsec
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"github.com/pritunl/pritunl-cloud/link"
+	"github.com/pritunl/pritunl-cloud/vpc"
+	"strings"
+)
+
+func getPeerStates(vc *vpc.Vpc, pubAddr, pubAddr6 string) (
+	states []*link.State) {
+
+	states = []*link.State{}
+
+	if vc.LinkPeers == nil {
+		return
+	}
+
+	for _, peer := range vc.LinkPeers {
+		peerHash := md5.New()
+		peerData, _ := json.Marshal(peer)
+		peerHash.Write(peerData)
+
+		states = append(states, &link.State{
+			Id:          peer.Id.Hex(),
+			VpcId:       vc.Id,
+			Ipv6:        strings.Contains(peer.Remote, ":"),
+			Type:        link.Ipsec,
+			Hash:        fmt.Sprintf("%x", peerHash.Sum(nil)),
+			PublicAddr:  pubAddr,
+			PublicAddr6: pubAddr6,
+			Links: []*link.Link{
+				&link.Link{
+					PreSharedKey: peer.PreSharedKey,
+					Right:        peer.Remote,
+					RightId:      peer.RemoteId,
+					RightCa:      peer.RemoteCa,
+					LeftSubnets:  peer.LocalSubnets,
+					RightSubnets: peer.RemoteSubnets,
+					Auth:         peer.Auth,
+					Ike:          peer.IkeProposal,
+					Esp:          peer.EspProposal,
+				},
+			},
+		})
+	}
+
+	return
+}
@@ -44,7 +44,7 @@ func syncStates(vc *vpc.Vpc) {
 		return
 	}
 
-	netAddr, err := vc.GetIp(db, vpc.Gateway, vc.Id)
+	netAddr, err := vc.GetLinkIp(db)
 	if err != nil {
 		return
 	}
@@ -86,9 +86,39 @@ func syncStates(vc *vpc.Vpc) {
 		return
 	}
 
-	states := link.GetStates(vc.Id, vc.LinkUris,
-		netAddr.String(), pubAddr, pubAddr6)
+	wgPubKey, err := getWgPublicKey(vc.Id)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"vpc_id": vc.Id.Hex(),
+			"error":  err,
+		}).Error("ipsec: Failed to load WireGuard key")
+		return
+	}
+
+	local := &link.Local{
+		LocalAddr:   netAddr.String(),
+		PublicAddr:  pubAddr,
+		PublicAddr6: pubAddr6,
+		PublicKey:   wgPubKey,
+		Auth:        vc.LinkAuth,
+	}
+
+	if vc.HasLinkCert() {
+		local.Cert, local.CaCert, err = syncCert(db, vc)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"vpc_id": vc.Id.Hex(),
+				"error":  err,
+			}).Error("ipsec: Failed to sync link certificate")
+			return
+		}
+	}
+
+	states := link.GetStates(vc.Id, vc.LinkUris, local)
+	states = append(states, getPeerStates(vc, pubAddr, pubAddr6)...)
 	hsh := md5.New()
+	io.WriteString(hsh, vc.LinkAuth)
+	io.WriteString(hsh, local.Cert)
 
 	names := set.NewSet()
 	for _, stat := range states {
@@ -144,18 +174,24 @@ func SyncStates(vpcs []*vpc.Vpc) {
 	sync := []*vpc.Vpc{}
 
 	for _, vc := range vpcs {
-		if vc.LinkUris == nil || len(vc.LinkUris) == 0 {
+		if !vc.HasLinks() {
 			continue
 		}
 
-		if vc.LinkNode != node.Self.Id &&
-			time.Since(vc.LinkTimestamp) < time.Duration(
-				settings.Ipsec.LinkTimeout)*time.Second {
+		held := false
+		var err error
+		if vc.IsLinkMulti() {
+			held, err = vc.PingLinkHolder(db)
+		} else {
+			if vc.LinkNode != node.Self.Id &&
+				time.Since(vc.LinkTimestamp) < time.Duration(
+					settings.Ipsec.LinkTimeout)*time.Second {
 
-			continue
-		}
+				continue
+			}
 
-		held, err := vc.PingLink(db)
+			held, err = vc.PingLink(db)
+		}
 		if err != nil {
 			logrus.WithFields(logrus.Fields{
 				"error": err,
@@ -4,10 +4,11 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/certificate"
 	"github.com/pritunl/pritunl-cloud/errortypes"
 	"github.com/pritunl/pritunl-cloud/link"
 	"github.com/pritunl/pritunl-cloud/vm"
-	"gopkg.in/mgo.v2/bson"
+	"github.com/pritunl/pritunl-cloud/vpc"
 	"io/ioutil"
 	"path"
 	"strings"
@@ -16,20 +17,81 @@ import (
 type templateData struct {
 	Id           string
 	Left         string
+	LeftId       string
+	LeftCert     string
+	LeftKey      string
+	KeyType      string
 	LeftSubnets  string
 	Right        string
+	RightId      string
+	RightCa      string
 	RightSubnets string
 	PreSharedKey string
+	Ike          string
+	Esp          string
 }
 
-func writeTemplates(vpcId bson.ObjectId, states []*link.State) (err error) {
-	namespace := vm.GetLinkNamespace(vpcId, 0)
+func writeTemplates(vc *vpc.Vpc, states []*link.State) (err error) {
+	namespace := vm.GetLinkNamespace(vc.Id, 0)
 	baseDir := path.Join("/", "etc", "netns", namespace)
 
 	confBuf := &bytes.Buffer{}
 	secretsBuf := &bytes.Buffer{}
 
+	leftId := ""
+	keyType := ""
+
+	if vc.HasLinkCert() {
+		certPem, e := ioutil.ReadFile(getCertPath(vc.Id))
+		if e != nil {
+			err = &errortypes.ReadError{
+				errors.Wrap(e, "ipsec: Failed to read link certificate"),
+			}
+			return
+		}
+
+		leftId, err = certificate.GetSubject(string(certPem))
+		if err != nil {
+			return
+		}
+
+		keyPem, e := ioutil.ReadFile(getKeyPath(vc.Id))
+		if e != nil {
+			err = &errortypes.ReadError{
+				errors.Wrap(e, "ipsec: Failed to read link key"),
+			}
+			return
+		}
+
+		if strings.Contains(string(keyPem), "EC PRIVATE KEY") {
+			keyType = "ECDSA"
+		} else {
+			keyType = "RSA"
+		}
+
+		err = clearRemoteCas(vc.Id)
+		if err != nil {
+			return
+		}
+
+		err = secretsCertTemplate.Execute(secretsBuf, &templateData{
+			LeftKey: certKeyName,
+			KeyType: keyType,
+		})
+		if err != nil {
+			err = &errortypes.ParseError{
+				errors.Wrap(err,
+					"ipsec: Failed to execute secrets template"),
+			}
+			return
+		}
+	}
+
 	for _, stat := range states {
+		if stat.Type == link.WireGuard {
+			continue
+		}
+
 		for i, lnk := range stat.Links {
 			leftSubnets := strings.Join(lnk.LeftSubnets, ",")
 			rightSubnets := strings.Join(lnk.RightSubnets, ",")
@@ -48,6 +110,49 @@ func writeTemplates(vpcId bson.ObjectId, states []*link.State) (err error) {
 				Right:        lnk.Right,
 				RightSubnets: rightSubnets,
 				PreSharedKey: lnk.PreSharedKey,
+				Ike:          lnk.Ike,
+				Esp:          lnk.Esp,
+			}
+
+			auth := lnk.Auth
+			if auth == "" {
+				auth = vc.LinkAuth
+			}
+
+			if auth == vpc.Certificate {
+				data.LeftId = leftId
+				data.LeftCert = certName
+
+				if lnk.RightId != "" {
+					data.RightId = lnk.RightId
+				} else {
+					data.RightId = "%any"
+				}
+
+				if lnk.RightCa != "" {
+					data.RightCa, err = writeRemoteCa(
+						vc.Id, data.Id, lnk.RightCa)
+					if err != nil {
+						return
+					}
+				}
+
+				err = confCertTemplate.Execute(confBuf, data)
+				if err != nil {
+					err = &errortypes.ParseError{
+						errors.Wrap(err,
+							"ipsec: Failed to execute conf template"),
+					}
+					return
+				}
+
+				continue
+			}
+
+			if lnk.RightId != "" {
+				data.RightId = lnk.RightId
+			} else {
+				data.RightId = lnk.Right
 			}
 
 			err = confTemplate.Execute(confBuf, data)
@@ -0,0 +1,332 @@
+package ipsec
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"github.com/Sirupsen/logrus"
+	"github.com/dropbox/godropbox/container/set"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/link"
+	"github.com/pritunl/pritunl-cloud/settings"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"github.com/pritunl/pritunl-cloud/vm"
+	"golang.org/x/crypto/curve25519"
+	"gopkg.in/mgo.v2/bson"
+	"io/ioutil"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	wgRoutes     = map[bson.ObjectId]set.Set{}
+	wgRoutesLock = sync.Mutex{}
+)
+
+type wgPeerData struct {
+	PublicKey  string
+	Endpoint   string
+	AllowedIps string
+}
+
+type wgConfData struct {
+	PrivateKey string
+	ListenPort int
+	Peers      []*wgPeerData
+}
+
+func getWgDir(vpcId bson.ObjectId) string {
+	namespace := vm.GetLinkNamespace(vpcId, 0)
+	return path.Join("/", "etc", "netns", namespace, "wireguard")
+}
+
+func getWgPrivateKey(vpcId bson.ObjectId) (privKey [32]byte, err error) {
+	baseDir := getWgDir(vpcId)
+	keyPath := path.Join(baseDir, "private.key")
+
+	err = utils.ExistsMkdir(baseDir, 0700)
+	if err != nil {
+		return
+	}
+
+	exists, err := utils.ExistsFile(keyPath)
+	if err != nil {
+		return
+	}
+
+	if exists {
+		data, e := ioutil.ReadFile(keyPath)
+		if e != nil {
+			err = &errortypes.ReadError{
+				errors.Wrap(e, "ipsec: Failed to read WireGuard key"),
+			}
+			return
+		}
+
+		key, e := base64.StdEncoding.DecodeString(
+			strings.TrimSpace(string(data)))
+		if e == nil && len(key) == 32 {
+			copy(privKey[:], key)
+			return
+		}
+	}
+
+	_, err = rand.Read(privKey[:])
+	if err != nil {
+		err = &errortypes.UnknownError{
+			errors.Wrap(err, "ipsec: Failed to generate WireGuard key"),
+		}
+		return
+	}
+
+	privKey[0] &= 248
+	privKey[31] &= 127
+	privKey[31] |= 64
+
+	err = ioutil.WriteFile(keyPath,
+		[]byte(base64.StdEncoding.EncodeToString(privKey[:])), 0600)
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "ipsec: Failed to write WireGuard key"),
+		}
+		return
+	}
+
+	return
+}
+
+func getWgPublicKey(vpcId bson.ObjectId) (pubKey string, err error) {
+	privKey, err := getWgPrivateKey(vpcId)
+	if err != nil {
+		return
+	}
+
+	var pubKeyByt [32]byte
+	curve25519.ScalarBaseMult(&pubKeyByt, &privKey)
+
+	pubKey = base64.StdEncoding.EncodeToString(pubKeyByt[:])
+
+	return
+}
+
+func clearWg(vpcId bson.ObjectId) (err error) {
+	namespace := vm.GetLinkNamespace(vpcId, 0)
+
+	link.WgPeersLock.Lock()
+	delete(link.WgPeers, vpcId)
+	link.WgPeersLock.Unlock()
+
+	wgRoutesLock.Lock()
+	delete(wgRoutes, vpcId)
+	wgRoutesLock.Unlock()
+
+	_, err = utils.ExecCombinedOutputLogged(
+		[]string{
+			"Cannot find device",
+		},
+		"ip", "netns", "exec", namespace,
+		"ip", "link", "del", link.WgIface,
+	)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func getWgAllowedIps(subnets []string) (allowedIps []string) {
+	allowedIps = []string{}
+
+	for _, subnet := range subnets {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(subnet))
+		if err != nil {
+			continue
+		}
+
+		allowedIps = append(allowedIps, network.String())
+	}
+
+	return
+}
+
+func deployWg(vpcId bson.ObjectId, states []*link.State) (err error) {
+	namespace := vm.GetLinkNamespace(vpcId, 0)
+	confPath := path.Join(getWgDir(vpcId), fmt.Sprintf(
+		"%s.conf", link.WgIface))
+
+	privKey, err := getWgPrivateKey(vpcId)
+	if err != nil {
+		return
+	}
+
+	data := &wgConfData{
+		PrivateKey: base64.StdEncoding.EncodeToString(privKey[:]),
+		ListenPort: settings.Ipsec.WgPort,
+		Peers:      []*wgPeerData{},
+	}
+	peers := map[string]string{}
+	routes := set.NewSet()
+
+	for _, stat := range states {
+		if stat.Type != link.WireGuard {
+			continue
+		}
+
+		for i, lnk := range stat.Links {
+			if lnk.PublicKey == "" || lnk.Right == "" {
+				continue
+			}
+
+			allowedIps := getWgAllowedIps(lnk.RightSubnets)
+			if len(allowedIps) == 0 {
+				logrus.WithFields(logrus.Fields{
+					"vpc_id": vpcId.Hex(),
+					"right":  lnk.Right,
+				}).Warning("ipsec: Skipping WireGuard peer without subnets")
+				continue
+			}
+
+			port := lnk.Port
+			if port == 0 {
+				port = settings.Ipsec.WgPort
+			}
+
+			data.Peers = append(data.Peers, &wgPeerData{
+				PublicKey:  lnk.PublicKey,
+				Endpoint:   net.JoinHostPort(lnk.Right, strconv.Itoa(port)),
+				AllowedIps: strings.Join(allowedIps, ","),
+			})
+			peers[lnk.PublicKey] = fmt.Sprintf("%s-%d", stat.Id, i)
+
+			for _, dst := range allowedIps {
+				routes.Add(dst)
+			}
+		}
+	}
+
+	if len(data.Peers) == 0 {
+		err = clearWg(vpcId)
+		if err != nil {
+			return
+		}
+		return
+	}
+
+	confBuf := &bytes.Buffer{}
+	err = wgConfTemplate.Execute(confBuf, data)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "ipsec: Failed to execute WireGuard template"),
+		}
+		return
+	}
+
+	err = ioutil.WriteFile(confPath, confBuf.Bytes(), 0600)
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "ipsec: Failed to write WireGuard conf"),
+		}
+		return
+	}
+
+	_, err = utils.ExecCombinedOutputLogged(
+		[]string{"File exists"},
+		"ip", "netns", "exec", namespace,
+		"ip", "link",
+		"add", "dev", link.WgIface,
+		"type", "wireguard",
+	)
+	if err != nil {
+		return
+	}
+
+	_, err = utils.ExecCombinedOutputLogged(
+		nil,
+		"ip", "netns", "exec", namespace,
+		"wg", "setconf", link.WgIface, confPath,
+	)
+	if err != nil {
+		return
+	}
+
+	_, err = utils.ExecCombinedOutputLogged(
+		nil,
+		"ip", "netns", "exec", namespace,
+		"ip", "link",
+		"set", "dev", link.WgIface, "up",
+	)
+	if err != nil {
+		return
+	}
+
+	wgRoutesLock.Lock()
+	curRoutes := wgRoutes[vpcId]
+	wgRoutesLock.Unlock()
+
+	if curRoutes != nil {
+		remRoutes := curRoutes.Copy()
+		remRoutes.Subtract(routes)
+
+		for routeInf := range remRoutes.Iter() {
+			dst := routeInf.(string)
+
+			if strings.Contains(dst, ":") {
+				utils.ExecCombinedOutputLogged(
+					nil,
+					"ip", "netns", "exec", namespace,
+					"ip", "-6", "route",
+					"del", dst,
+					"dev", link.WgIface,
+				)
+			} else {
+				utils.ExecCombinedOutputLogged(
+					nil,
+					"ip", "netns", "exec", namespace,
+					"ip", "route",
+					"del", dst,
+					"dev", link.WgIface,
+				)
+			}
+		}
+	}
+
+	for routeInf := range routes.Iter() {
+		dst := routeInf.(string)
+
+		if strings.Contains(dst, ":") {
+			_, err = utils.ExecCombinedOutputLogged(
+				nil,
+				"ip", "netns", "exec", namespace,
+				"ip", "-6", "route",
+				"replace", dst,
+				"dev", link.WgIface,
+			)
+		} else {
+			_, err = utils.ExecCombinedOutputLogged(
+				nil,
+				"ip", "netns", "exec", namespace,
+				"ip", "route",
+				"replace", dst,
+				"dev", link.WgIface,
+			)
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	wgRoutesLock.Lock()
+	wgRoutes[vpcId] = routes
+	wgRoutesLock.Unlock()
+
+	link.WgPeersLock.Lock()
+	link.WgPeers[vpcId] = peers
+	link.WgPeersLock.Unlock()
+
+	return
+}
@@ -3,3 +3,9 @@ package link
 const (
 	Version = "1.0.918.10"
 )
+
+const (
+	Ipsec     = "ipsec"
+	WireGuard = "wireguard"
+	WgIface   = "wg0"
+)
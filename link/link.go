@@ -6,10 +6,12 @@ import (
 )
 
 var (
-	Hashes     = map[bson.ObjectId]string{}
-	HashesLock = sync.Mutex{}
-	LinkStatus = map[bson.ObjectId]Status{}
+	Hashes         = map[bson.ObjectId]string{}
+	HashesLock     = sync.Mutex{}
+	LinkStatus     = map[bson.ObjectId]Status{}
 	LinkStatusLock = sync.Mutex{}
+	WgPeers        = map[bson.ObjectId]map[string]string{}
+	WgPeersLock    = sync.Mutex{}
 )
 
 type Status map[string]map[string]string
@@ -26,8 +28,25 @@ type State struct {
 	PublicAddr6 string        `json:"-"`
 }
 
+type Local struct {
+	LocalAddr   string
+	PublicAddr  string
+	PublicAddr6 string
+	PublicKey   string
+	Auth        string
+	Cert        string
+	CaCert      string
+}
+
 type Link struct {
 	PreSharedKey string   `json:"pre_shared_key"`
+	PublicKey    string   `json:"public_key"`
+	Port         int      `json:"port"`
+	RightId      string   `json:"right_id"`
+	RightCa      string   `json:"right_ca"`
+	Auth         string   `json:"auth"`
+	Ike          string   `json:"ike"`
+	Esp          string   `json:"esp"`
 	Right        string   `json:"right"`
 	LeftSubnets  []string `json:"left_subnets"`
 	RightSubnets []string `json:"right_subnets"`
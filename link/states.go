@@ -32,6 +32,10 @@ type stateData struct {
 	PublicAddress string            `json:"public_address"`
 	LocalAddress  string            `json:"local_address"`
 	Address6      string            `json:"address6"`
+	PublicKey     string            `json:"public_key"`
+	Auth          string            `json:"auth"`
+	Certificate   string            `json:"certificate"`
+	CaCertificate string            `json:"ca_certificate"`
 	Status        map[string]string `json:"status"`
 	Errors        []string          `json:"errors"`
 }
@@ -56,7 +60,7 @@ func getStateCache(vpcId bson.ObjectId, uri string) (state *State) {
 	return
 }
 
-func getState(vpcId bson.ObjectId, uri, localAddr, pubAddr, pubAddr6 string) (
+func getState(vpcId bson.ObjectId, uri string, local *Local) (
 	state *State, err error) {
 
 	if constants.Interrupt {
@@ -67,8 +71,8 @@ func getState(vpcId bson.ObjectId, uri, localAddr, pubAddr, pubAddr6 string) (
 	}
 
 	state = &State{
-		PublicAddr:  pubAddr,
-		PublicAddr6: pubAddr6,
+		PublicAddr:  local.PublicAddr,
+		PublicAddr6: local.PublicAddr6,
 	}
 
 	uriData, err := url.ParseRequestURI(uri)
@@ -90,9 +94,13 @@ func getState(vpcId bson.ObjectId, uri, localAddr, pubAddr, pubAddr6 string) (
 
 	data := &stateData{
 		Version:       Version,
-		PublicAddress: pubAddr,
-		LocalAddress:  localAddr,
-		Address6:      pubAddr6,
+		PublicAddress: local.PublicAddr,
+		LocalAddress:  local.LocalAddr,
+		Address6:      local.PublicAddr6,
+		PublicKey:     local.PublicKey,
+		Auth:          local.Auth,
+		Certificate:   local.Cert,
+		CaCertificate: local.CaCert,
 		Status:        linkStatus,
 	}
 	dataBuf := &bytes.Buffer{}
@@ -240,8 +248,8 @@ func getState(vpcId bson.ObjectId, uri, localAddr, pubAddr, pubAddr6 string) (
 	return
 }
 
-func GetStates(vpcId bson.ObjectId, uris []string,
-	localAddr, pubAddr, pubAddr6 string) (states []*State) {
+func GetStates(vpcId bson.ObjectId, uris []string, local *Local) (
+	states []*State) {
 
 	states = []*State{}
 	urisSet := set.NewSet()
@@ -249,7 +257,7 @@ func GetStates(vpcId bson.ObjectId, uris []string,
 	for _, uri := range uris {
 		urisSet.Add(uri)
 
-		state, err := getState(vpcId, uri, localAddr, pubAddr, pubAddr6)
+		state, err := getState(vpcId, uri, local)
 		if err != nil {
 			logrus.WithFields(logrus.Fields{
 				"uri":   uri,
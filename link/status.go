@@ -7,6 +7,7 @@ import (
 	"github.com/pritunl/pritunl-cloud/utils"
 	"github.com/pritunl/pritunl-cloud/vm"
 	"gopkg.in/mgo.v2/bson"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -19,6 +20,8 @@ func GetStatus(vpcId bson.ObjectId) (status Status, err error) {
 	status = Status{}
 	namespace := vm.GetLinkNamespace(vpcId, 0)
 
+	getWgStatus(vpcId, namespace, status)
+
 	output, err := utils.ExecOutput(
 		"",
 		"ip", "netns", "exec", namespace,
@@ -75,6 +78,66 @@ func GetStatus(vpcId bson.ObjectId) (status Status, err error) {
 	return
 }
 
+func getWgStatus(vpcId bson.ObjectId, namespace string, status Status) {
+	WgPeersLock.Lock()
+	peers := WgPeers[vpcId]
+	WgPeersLock.Unlock()
+
+	if peers == nil || len(peers) == 0 {
+		return
+	}
+
+	output, err := utils.ExecOutput(
+		"",
+		"ip", "netns", "exec", namespace,
+		"wg", "show", WgIface, "latest-handshakes",
+	)
+	if err != nil {
+		return
+	}
+
+	handshakeTimeout := time.Duration(
+		settings.Ipsec.WgHandshakeTimeout) * time.Second
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+
+		peerId, ok := peers[fields[0]]
+		if !ok {
+			continue
+		}
+
+		connId := strings.SplitN(peerId, "-", 2)
+		if len(connId) != 2 {
+			continue
+		}
+
+		handshake, e := strconv.ParseInt(fields[1], 10, 64)
+		if e != nil {
+			continue
+		}
+
+		connState := ""
+		if handshake == 0 {
+			connState = "connecting"
+		} else if time.Since(time.Unix(handshake, 0)) < handshakeTimeout {
+			connState = "connected"
+		} else {
+			connState = "disconnected"
+		}
+
+		if _, ok := status[connId[0]]; !ok {
+			status[connId[0]] = map[string]string{}
+		}
+		status[connId[0]][connId[1]] = connState
+	}
+
+	return
+}
+
 func Update(vpcId bson.ObjectId, names set.Set) (
 	resetLinks []string, err error) {
 
@@ -0,0 +1,244 @@
+package logger
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"github.com/Sirupsen/logrus"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/constants"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/settings"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	httpBuffer = make(chan *logrus.Entry, 512)
+	httpClient = &http.Client{
+		Timeout: 20 * time.Second,
+	}
+	httpClientSkip = &http.Client{
+		Timeout: 20 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+		},
+	}
+)
+
+type httpEntry struct {
+	Timestamp time.Time         `json:"@timestamp"`
+	Host      string            `json:"host"`
+	Level     string            `json:"level"`
+	Message   string            `json:"message"`
+	Fields    map[string]string `json:"fields,omitempty"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][]string        `json:"values"`
+}
+
+type lokiPush struct {
+	Streams []*lokiStream `json:"streams"`
+}
+
+type httpSender struct{}
+
+func (s *httpSender) Init() {
+	go func() {
+		batch := []*httpEntry{}
+		ticker := time.NewTicker(1 * time.Second)
+		lastSend := time.Now()
+
+		for {
+			select {
+			case entry := <-httpBuffer:
+				batch = append(batch, &httpEntry{
+					Timestamp: entry.Time,
+					Host:      getHostname(),
+					Level:     entry.Level.String(),
+					Message:   entry.Message,
+					Fields:    getFields(entry),
+				})
+			case <-ticker.C:
+			}
+
+			if constants.Interrupt {
+				ticker.Stop()
+				return
+			}
+
+			if len(batch) == 0 {
+				lastSend = time.Now()
+				continue
+			}
+
+			interval := time.Duration(
+				settings.Logger.HttpBatchInterval) * time.Second
+			if len(batch) < settings.Logger.HttpBatchSize &&
+				time.Since(lastSend) < interval {
+
+				continue
+			}
+
+			err := s.send(batch)
+			if err != nil {
+				logrus.WithFields(logrus.Fields{
+					"error": err,
+				}).Error("logger: HTTP send error")
+			}
+
+			batch = []*httpEntry{}
+			lastSend = time.Now()
+		}
+	}()
+}
+
+func (s *httpSender) Parse(entry *logrus.Entry) {
+	if settings.Logger == nil || settings.Logger.HttpUrl == "" {
+		return
+	}
+
+	if len(httpBuffer) <= 384 {
+		httpBuffer <- entry
+	}
+}
+
+func (s *httpSender) marshal(batch []*httpEntry) (
+	body []byte, contentType string, err error) {
+
+	switch settings.Logger.HttpFormat {
+	case "loki":
+		streams := map[string]*lokiStream{}
+		push := &lokiPush{
+			Streams: []*lokiStream{},
+		}
+
+		for _, entry := range batch {
+			stream := streams[entry.Level]
+			if stream == nil {
+				stream = &lokiStream{
+					Stream: map[string]string{
+						"app":   "pritunl-cloud",
+						"host":  entry.Host,
+						"level": entry.Level,
+					},
+					Values: [][]string{},
+				}
+				streams[entry.Level] = stream
+				push.Streams = append(push.Streams, stream)
+			}
+
+			line, e := json.Marshal(entry)
+			if e != nil {
+				err = e
+				break
+			}
+
+			stream.Values = append(stream.Values, []string{
+				strconv.FormatInt(entry.Timestamp.UnixNano(), 10),
+				string(line),
+			})
+		}
+
+		if err == nil {
+			body, err = json.Marshal(push)
+		}
+		contentType = "application/json"
+		break
+	case "elasticsearch":
+		buf := &bytes.Buffer{}
+		header := fmt.Sprintf(`{"index":{"_index":%q}}`,
+			settings.Logger.HttpIndex)
+
+		for _, entry := range batch {
+			line, e := json.Marshal(entry)
+			if e != nil {
+				err = e
+				break
+			}
+
+			buf.WriteString(header)
+			buf.WriteByte('\n')
+			buf.Write(line)
+			buf.WriteByte('\n')
+		}
+
+		body = buf.Bytes()
+		contentType = "application/x-ndjson"
+		break
+	default:
+		body, err = json.Marshal(batch)
+		contentType = "application/json"
+	}
+
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "logger: Failed to marshal log entries"),
+		}
+		return
+	}
+
+	return
+}
+
+func (s *httpSender) send(batch []*httpEntry) (err error) {
+	body, contentType, err := s.marshal(batch)
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequest(
+		"POST",
+		settings.Logger.HttpUrl,
+		bytes.NewBuffer(body),
+	)
+	if err != nil {
+		err = &errortypes.RequestError{
+			errors.Wrap(err, "logger: Failed to create request"),
+		}
+		return
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "pritunl-cloud")
+	if settings.Logger.HttpAuthorization != "" {
+		req.Header.Set("Authorization", settings.Logger.HttpAuthorization)
+	}
+
+	client := httpClient
+	if settings.Logger.HttpSkipVerify {
+		client = httpClientSkip
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		err = &errortypes.RequestError{
+			errors.Wrap(err, "logger: Request failed"),
+		}
+		return
+	}
+	defer resp.Body.Close()
+
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 65536))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err = &errortypes.RequestError{
+			errors.Newf("logger: Bad status %d", resp.StatusCode),
+		}
+		return
+	}
+
+	return
+}
+
+func init() {
+	senders = append(senders, &httpSender{})
+}
@@ -0,0 +1,141 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/Sirupsen/logrus"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/constants"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/settings"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const journalSocket = "/run/systemd/journal/socket"
+
+var (
+	journaldBuffer = make(chan *logrus.Entry, 128)
+)
+
+type journaldSender struct {
+	conn *net.UnixConn
+}
+
+func (s *journaldSender) Init() {
+	go func() {
+		for {
+			entry := <-journaldBuffer
+
+			if constants.Interrupt {
+				return
+			}
+
+			err := s.send(entry)
+			if err != nil {
+				if s.conn != nil {
+					s.conn.Close()
+					s.conn = nil
+				}
+
+				logrus.WithFields(logrus.Fields{
+					"error": err,
+				}).Error("logger: Journald send error")
+			}
+		}
+	}()
+}
+
+func (s *journaldSender) Parse(entry *logrus.Entry) {
+	if settings.Logger == nil || !settings.Logger.Journald {
+		return
+	}
+
+	if len(journaldBuffer) <= 32 {
+		journaldBuffer <- entry
+	}
+}
+
+func journaldKey(key string) string {
+	key = strings.ToUpper(key)
+
+	name := []byte{}
+	for _, c := range []byte(key) {
+		if (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' {
+			name = append(name, c)
+		} else {
+			name = append(name, '_')
+		}
+	}
+
+	key = strings.TrimLeft(string(name), "_0123456789")
+	if len(key) > 64 {
+		key = key[:64]
+	}
+
+	return key
+}
+
+func journaldWrite(buf *bytes.Buffer, key, val string) {
+	if !strings.Contains(val, "\n") {
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		buf.WriteString(val)
+		buf.WriteByte('\n')
+		return
+	}
+
+	buf.WriteString(key)
+	buf.WriteByte('\n')
+	binary.Write(buf, binary.LittleEndian, uint64(len(val)))
+	buf.WriteString(val)
+	buf.WriteByte('\n')
+}
+
+func (s *journaldSender) send(entry *logrus.Entry) (err error) {
+	if s.conn == nil {
+		s.conn, err = net.DialUnix("unixgram", nil, &net.UnixAddr{
+			Name: journalSocket,
+			Net:  "unixgram",
+		})
+		if err != nil {
+			s.conn = nil
+			err = &errortypes.ConnectionError{
+				errors.Wrap(err, "logger: Failed to connect to journald"),
+			}
+			return
+		}
+	}
+
+	buf := &bytes.Buffer{}
+
+	journaldWrite(buf, "MESSAGE", entry.Message)
+	journaldWrite(buf, "PRIORITY", strconv.Itoa(getSeverity(entry.Level)))
+	journaldWrite(buf, "SYSLOG_IDENTIFIER", "pritunl-cloud")
+
+	for key, val := range getFields(entry) {
+		key = journaldKey(key)
+		if key == "" || key == "MESSAGE" || key == "PRIORITY" ||
+			key == "SYSLOG_IDENTIFIER" {
+
+			continue
+		}
+
+		journaldWrite(buf, key, val)
+	}
+
+	_, err = s.conn.Write(buf.Bytes())
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "logger: Failed to write to journald"),
+		}
+		return
+	}
+
+	return
+}
+
+func init() {
+	senders = append(senders, &journaldSender{})
+}
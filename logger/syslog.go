@@ -0,0 +1,173 @@
+package logger
+
+import (
+	"crypto/tls"
+	"fmt"
+	"github.com/Sirupsen/logrus"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/constants"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/settings"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	syslogBuffer  = make(chan *logrus.Entry, 128)
+	syslogEscape  = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+	syslogInvalid = strings.NewReplacer(
+		"=", "_", " ", "_", "]", "_", `"`, "_")
+)
+
+type syslogSender struct {
+	conn     net.Conn
+	address  string
+	protocol string
+}
+
+func (s *syslogSender) Init() {
+	go func() {
+		for {
+			entry := <-syslogBuffer
+
+			if constants.Interrupt {
+				return
+			}
+
+			err := s.send(entry)
+			if err != nil {
+				s.close()
+
+				logrus.WithFields(logrus.Fields{
+					"error": err,
+				}).Error("logger: Syslog send error")
+			}
+		}
+	}()
+}
+
+func (s *syslogSender) Parse(entry *logrus.Entry) {
+	if settings.Logger == nil || settings.Logger.SyslogAddress == "" {
+		return
+	}
+
+	if len(syslogBuffer) <= 32 {
+		syslogBuffer <- entry
+	}
+}
+
+func (s *syslogSender) close() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+func (s *syslogSender) connect() (err error) {
+	address := settings.Logger.SyslogAddress
+	protocol := settings.Logger.SyslogProtocol
+
+	if s.conn != nil && s.address == address && s.protocol == protocol {
+		return
+	}
+	s.close()
+
+	switch protocol {
+	case "tls":
+		s.conn, err = tls.DialWithDialer(&net.Dialer{
+			Timeout: 10 * time.Second,
+		}, "tcp", address, &tls.Config{
+			InsecureSkipVerify: settings.Logger.SyslogSkipVerify,
+			MinVersion:         tls.VersionTLS12,
+		})
+		break
+	case "tcp":
+		s.conn, err = net.DialTimeout("tcp", address, 10*time.Second)
+		break
+	default:
+		s.conn, err = net.DialTimeout("udp", address, 10*time.Second)
+	}
+	if err != nil {
+		s.conn = nil
+		err = &errortypes.ConnectionError{
+			errors.Wrap(err, "logger: Failed to connect to syslog"),
+		}
+		return
+	}
+
+	s.address = address
+	s.protocol = protocol
+
+	return
+}
+
+func (s *syslogSender) format(entry *logrus.Entry) string {
+	pri := settings.Logger.SyslogFacility*8 + getSeverity(entry.Level)
+
+	appName := settings.Logger.SyslogAppName
+	if appName == "" {
+		appName = "-"
+	}
+
+	fields := getFields(entry)
+	keys := []string{}
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	data := "-"
+	if len(keys) > 0 {
+		data = "[fields@32473"
+		for _, key := range keys {
+			name := syslogInvalid.Replace(key)
+			if len(name) > 32 {
+				name = name[:32]
+			}
+			data += fmt.Sprintf(` %s="%s"`,
+				name, syslogEscape.Replace(fields[key]))
+		}
+		data += "]"
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s %d - %s %s",
+		pri,
+		entry.Time.UTC().Format(time.RFC3339Nano),
+		getHostname(),
+		appName,
+		os.Getpid(),
+		data,
+		entry.Message,
+	)
+}
+
+func (s *syslogSender) send(entry *logrus.Entry) (err error) {
+	err = s.connect()
+	if err != nil {
+		return
+	}
+
+	msg := s.format(entry)
+	if s.protocol == "tcp" || s.protocol == "tls" {
+		msg = fmt.Sprintf("%d %s", len(msg), msg)
+	}
+
+	s.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+
+	_, err = s.conn.Write([]byte(msg))
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "logger: Failed to write to syslog"),
+		}
+		return
+	}
+
+	return
+}
+
+func init() {
+	senders = append(senders, &syslogSender{})
+}
@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"fmt"
+	"github.com/Sirupsen/logrus"
+	"os"
+)
+
+var (
+	hostname = ""
+)
+
+func getHostname() string {
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+		if hostname == "" {
+			hostname = "-"
+		}
+	}
+	return hostname
+}
+
+func getSeverity(lvl logrus.Level) int {
+	switch lvl {
+	case logrus.PanicLevel:
+		return 0
+	case logrus.FatalLevel:
+		return 2
+	case logrus.ErrorLevel:
+		return 3
+	case logrus.WarnLevel:
+		return 4
+	case logrus.InfoLevel:
+		return 6
+	default:
+		return 7
+	}
+}
+
+func getFields(entry *logrus.Entry) (fields map[string]string) {
+	fields = map[string]string{}
+
+	for key, val := range entry.Data {
+		if key == "error" {
+			fields[key] = fmt.Sprintf("%s", val)
+		} else {
+			fields[key] = fmt.Sprintf("%v", val)
+		}
+	}
+
+	return
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/pritunl/pritunl-cloud/constants"
 	"github.com/pritunl/pritunl-cloud/logger"
 	"github.com/pritunl/pritunl-cloud/requires"
+	"os"
 	"time"
 )
 
@@ -20,7 +21,13 @@ Commands:
   unset           Unset a setting
   start           Start node
   clear-logs      Clear logs
+  verify-audit    Verify audit log hash chain
   reset-password  Reset administrator password
+  instance        Manage instances with API client
+  disk            Manage disks with API client
+  vpc             List VPCs with API client
+  firewall        List firewalls with API client
+  image           List images with API client
 `
 
 func Init() {
@@ -83,6 +90,20 @@ func main() {
 			panic(err)
 		}
 		return
+	case "verify-audit":
+		Init()
+		err := cmd.VerifyAudit()
+		if err != nil {
+			panic(err)
+		}
+		return
+	case "instance", "disk", "vpc", "firewall", "image":
+		err := cmd.Client()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
 	}
 
 	fmt.Println(help)
@@ -0,0 +1,9 @@
+package meter
+
+const (
+	Instance = "instance"
+	Disk     = "disk"
+	Snapshot = "snapshot"
+
+	Interval = 5
+)
@@ -0,0 +1,47 @@
+package meter
+
+import (
+	"github.com/pritunl/pritunl-cloud/database"
+	"gopkg.in/mgo.v2/bson"
+	"time"
+)
+
+type Meter struct {
+	Id             bson.ObjectId `bson:"_id,omitempty" json:"id"`
+	Organization   bson.ObjectId `bson:"organization" json:"organization"`
+	Resource       string        `bson:"resource" json:"resource"`
+	ResourceId     bson.ObjectId `bson:"resource_id" json:"resource_id"`
+	Name           string        `bson:"name" json:"name"`
+	Timestamp      time.Time     `bson:"timestamp" json:"timestamp"`
+	Hours          float64       `bson:"hours" json:"hours"`
+	ProcessorHours float64       `bson:"processor_hours" json:"processor_hours"`
+	MemoryHours    float64       `bson:"memory_hours" json:"memory_hours"`
+	SizeHours      float64       `bson:"size_hours" json:"size_hours"`
+}
+
+func (m *Meter) Record(db *database.Database) (err error) {
+	coll := db.Meters()
+
+	_, err = coll.Upsert(&bson.M{
+		"resource_id": m.ResourceId,
+		"timestamp":   m.Timestamp,
+	}, &bson.M{
+		"$set": &bson.M{
+			"organization": m.Organization,
+			"resource":     m.Resource,
+			"name":         m.Name,
+		},
+		"$inc": &bson.M{
+			"hours":           m.Hours,
+			"processor_hours": m.ProcessorHours,
+			"memory_hours":    m.MemoryHours,
+			"size_hours":      m.SizeHours,
+		},
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
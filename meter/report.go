@@ -0,0 +1,171 @@
+package meter
+
+import (
+	"encoding/csv"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"gopkg.in/mgo.v2/bson"
+	"io"
+	"strconv"
+	"time"
+)
+
+type Report struct {
+	Organization   bson.ObjectId `bson:"organization" json:"organization"`
+	Resource       string        `bson:"resource" json:"resource"`
+	ResourceId     bson.ObjectId `bson:"resource_id" json:"resource_id"`
+	Name           string        `bson:"name" json:"name"`
+	Hours          float64       `bson:"hours" json:"hours"`
+	ProcessorHours float64       `bson:"processor_hours" json:"processor_hours"`
+	MemoryHours    float64       `bson:"memory_hours" json:"memory_hours"`
+	SizeHours      float64       `bson:"size_hours" json:"size_hours"`
+}
+
+type reportPipe struct {
+	Id struct {
+		Organization bson.ObjectId `bson:"organization"`
+		Resource     string        `bson:"resource"`
+		ResourceId   bson.ObjectId `bson:"resource_id"`
+	} `bson:"_id"`
+	Name           string  `bson:"name"`
+	Hours          float64 `bson:"hours"`
+	ProcessorHours float64 `bson:"processor_hours"`
+	MemoryHours    float64 `bson:"memory_hours"`
+	SizeHours      float64 `bson:"size_hours"`
+}
+
+func GetReport(db *database.Database, orgId bson.ObjectId,
+	start, end time.Time) (reports []*Report, err error) {
+
+	coll := db.Meters()
+	reports = []*Report{}
+
+	query := bson.M{
+		"timestamp": &bson.M{
+			"$gte": start,
+			"$lt":  end,
+		},
+	}
+	if orgId != "" {
+		query["organization"] = orgId
+	}
+
+	resp := []*reportPipe{}
+	err = coll.Pipe([]*bson.M{
+		&bson.M{
+			"$match": query,
+		},
+		&bson.M{
+			"$sort": &bson.M{
+				"timestamp": 1,
+			},
+		},
+		&bson.M{
+			"$group": &bson.M{
+				"_id": &bson.M{
+					"organization": "$organization",
+					"resource":     "$resource",
+					"resource_id":  "$resource_id",
+				},
+				"name": &bson.M{
+					"$last": "$name",
+				},
+				"hours": &bson.M{
+					"$sum": "$hours",
+				},
+				"processor_hours": &bson.M{
+					"$sum": "$processor_hours",
+				},
+				"memory_hours": &bson.M{
+					"$sum": "$memory_hours",
+				},
+				"size_hours": &bson.M{
+					"$sum": "$size_hours",
+				},
+			},
+		},
+		&bson.M{
+			"$sort": &bson.M{
+				"_id.organization": 1,
+				"_id.resource":     1,
+				"name":             1,
+			},
+		},
+	}).All(&resp)
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	for _, item := range resp {
+		reports = append(reports, &Report{
+			Organization:   item.Id.Organization,
+			Resource:       item.Id.Resource,
+			ResourceId:     item.Id.ResourceId,
+			Name:           item.Name,
+			Hours:          item.Hours,
+			ProcessorHours: item.ProcessorHours,
+			MemoryHours:    item.MemoryHours,
+			SizeHours:      item.SizeHours,
+		})
+	}
+
+	return
+}
+
+func formatFloat(val float64) string {
+	return strconv.FormatFloat(val, 'f', 4, 64)
+}
+
+func WriteCsv(w io.Writer, reports []*Report) (err error) {
+	writer := csv.NewWriter(w)
+
+	err = writer.Write([]string{
+		"organization",
+		"resource",
+		"resource_id",
+		"name",
+		"hours",
+		"processor_hours",
+		"memory_gb_hours",
+		"size_gb_hours",
+	})
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "meter: Failed to write csv"),
+		}
+		return
+	}
+
+	for _, report := range reports {
+		err = writer.Write([]string{
+			report.Organization.Hex(),
+			report.Resource,
+			report.ResourceId.Hex(),
+			report.Name,
+			formatFloat(report.Hours),
+			formatFloat(report.ProcessorHours),
+			formatFloat(report.MemoryHours),
+			formatFloat(report.SizeHours),
+		})
+		if err != nil {
+			err = &errortypes.WriteError{
+				errors.Wrap(err, "meter: Failed to write csv"),
+			}
+			return
+		}
+	}
+
+	writer.Flush()
+
+	err = writer.Error()
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "meter: Failed to write csv"),
+		}
+		return
+	}
+
+	return
+}
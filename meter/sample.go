@@ -0,0 +1,101 @@
+package meter
+
+import (
+	"github.com/pritunl/pritunl-cloud/database"
+	"gopkg.in/mgo.v2/bson"
+	"time"
+)
+
+type sample struct {
+	Id        bson.ObjectId `bson:"_id"`
+	Timestamp time.Time     `bson:"timestamp"`
+}
+
+type samples struct {
+	timestamp time.Time
+	interval  time.Duration
+	last      map[bson.ObjectId]time.Time
+}
+
+func getSamples(db *database.Database, timestamp time.Time,
+	interval time.Duration) (smpls *samples, err error) {
+
+	coll := db.MetersSample()
+	smpls = &samples{
+		timestamp: timestamp,
+		interval:  interval,
+		last:      map[bson.ObjectId]time.Time{},
+	}
+
+	cursor := coll.Find(&bson.M{}).Iter()
+
+	smpl := &sample{}
+	for cursor.Next(smpl) {
+		smpls.last[smpl.Id] = smpl.Timestamp
+		smpl = &sample{}
+	}
+
+	err = cursor.Close()
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+// Hours since the resource was last sampled, resources not yet sampled
+// are limited to the time since creation
+func (s *samples) elapsed(resourceId bson.ObjectId) (hours float64) {
+	last, ok := s.last[resourceId]
+	if !ok {
+		last = s.timestamp.Add(-s.interval)
+		created := resourceId.Time()
+		if created.After(last) {
+			last = created
+		}
+	}
+
+	elapsed := s.timestamp.Sub(last)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+
+	hours = elapsed.Hours()
+	return
+}
+
+func (s *samples) commit(db *database.Database,
+	resourceId bson.ObjectId) (err error) {
+
+	coll := db.MetersSample()
+
+	_, err = coll.UpsertId(resourceId, &bson.M{
+		"$set": &bson.M{
+			"timestamp": s.timestamp,
+		},
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+// Remove samples of deleted resources
+func (s *samples) clean(db *database.Database) (err error) {
+	coll := db.MetersSample()
+
+	_, err = coll.RemoveAll(&bson.M{
+		"timestamp": &bson.M{
+			"$lt": s.timestamp.Add(-24 * time.Hour),
+		},
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
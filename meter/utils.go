@@ -0,0 +1,153 @@
+package meter
+
+import (
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/disk"
+	"github.com/pritunl/pritunl-cloud/image"
+	"github.com/pritunl/pritunl-cloud/instance"
+	"github.com/pritunl/pritunl-cloud/vm"
+	"gopkg.in/mgo.v2/bson"
+	"time"
+)
+
+func Sample(db *database.Database, timestamp time.Time,
+	interval time.Duration) (err error) {
+
+	smpls, err := getSamples(db, timestamp, interval)
+	if err != nil {
+		return
+	}
+
+	timestamp = timestamp.UTC().Truncate(time.Hour)
+
+	// Stopped instances are sampled without usage to reset elapsed time
+	insts, err := instance.GetAll(db, &bson.M{
+		"organization": &bson.M{
+			"$exists": true,
+		},
+	})
+	if err != nil {
+		return
+	}
+
+	for _, inst := range insts {
+		if inst.Organization == "" {
+			continue
+		}
+
+		hours := smpls.elapsed(inst.Id)
+
+		err = smpls.commit(db, inst.Id)
+		if err != nil {
+			return
+		}
+
+		if inst.VmState != vm.Running {
+			continue
+		}
+
+		mtr := &Meter{
+			Organization:   inst.Organization,
+			Resource:       Instance,
+			ResourceId:     inst.Id,
+			Name:           inst.Name,
+			Timestamp:      timestamp,
+			Hours:          hours,
+			ProcessorHours: float64(inst.Processors) * hours,
+			MemoryHours:    float64(inst.Memory) / 1024 * hours,
+		}
+
+		err = mtr.Record(db)
+		if err != nil {
+			return
+		}
+	}
+
+	disks, err := disk.GetAll(db, &bson.M{
+		"organization": &bson.M{
+			"$exists": true,
+		},
+	})
+	if err != nil {
+		return
+	}
+
+	for _, dsk := range disks {
+		if dsk.Organization == "" {
+			continue
+		}
+
+		hours := smpls.elapsed(dsk.Id)
+
+		err = smpls.commit(db, dsk.Id)
+		if err != nil {
+			return
+		}
+
+		mtr := &Meter{
+			Organization: dsk.Organization,
+			Resource:     Disk,
+			ResourceId:   dsk.Id,
+			Name:         dsk.Name,
+			Timestamp:    timestamp,
+			Hours:        hours,
+			SizeHours:    float64(dsk.Size) * hours,
+		}
+
+		err = mtr.Record(db)
+		if err != nil {
+			return
+		}
+	}
+
+	cursor := db.Images().Find(&bson.M{
+		"organization": &bson.M{
+			"$exists": true,
+		},
+	}).Iter()
+
+	img := &image.Image{}
+	for cursor.Next(img) {
+		if img.Organization != "" {
+			hours := smpls.elapsed(img.Id)
+
+			err = smpls.commit(db, img.Id)
+			if err != nil {
+				cursor.Close()
+				return
+			}
+
+			mtr := &Meter{
+				Organization: img.Organization,
+				Resource:     Snapshot,
+				ResourceId:   img.Id,
+				Name:         img.Name,
+				Timestamp:    timestamp,
+				Hours:        hours,
+				SizeHours: float64(img.Size) /
+					(1024 * 1024 * 1024) * hours,
+			}
+
+			err = mtr.Record(db)
+			if err != nil {
+				cursor.Close()
+				return
+			}
+		}
+
+		img = &image.Image{}
+	}
+
+	err = cursor.Close()
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	err = smpls.clean(db)
+	if err != nil {
+		return
+	}
+
+	return
+}
@@ -0,0 +1,232 @@
+package metric
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/dropbox/godropbox/container/set"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/instance"
+	"github.com/pritunl/pritunl-cloud/node"
+	"github.com/pritunl/pritunl-cloud/paths"
+	"github.com/pritunl/pritunl-cloud/qms"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"github.com/pritunl/pritunl-cloud/vm"
+	"gopkg.in/mgo.v2/bson"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	counters = map[bson.ObjectId]*counter{}
+)
+
+type counter struct {
+	Timestamp time.Time
+	CpuTime   float64
+	DiskRead  uint64
+	DiskWrite uint64
+	NetRx     uint64
+	NetTx     uint64
+}
+
+func rate(cur, prev uint64, seconds float64) float64 {
+	if cur < prev || seconds <= 0 {
+		return 0
+	}
+	return utils.ToFixed(float64(cur-prev)/seconds, 2)
+}
+
+func getNetStats(instId bson.ObjectId) (rx, tx uint64, err error) {
+	namespace := vm.GetNamespace(instId, 0)
+	iface := vm.GetIface(instId, 0)
+
+	output, err := utils.ExecCombinedOutputLogged(
+		nil,
+		"ip", "netns", "exec", namespace,
+		"cat", "/proc/net/dev",
+	)
+	if err != nil {
+		return
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		lineSpl := strings.SplitN(line, ":", 2)
+		if len(lineSpl) != 2 || strings.TrimSpace(lineSpl[0]) != iface {
+			continue
+		}
+
+		fields := strings.Fields(lineSpl[1])
+		if len(fields) < 9 {
+			continue
+		}
+
+		// Counters are from the host side of the tap interface
+		tx, _ = strconv.ParseUint(fields[0], 10, 64)
+		rx, _ = strconv.ParseUint(fields[8], 10, 64)
+		break
+	}
+
+	return
+}
+
+func collectInstance(db *database.Database, inst *instance.Instance,
+	now time.Time) (err error) {
+
+	pidData, err := ioutil.ReadFile(paths.GetPidPath(inst.Id))
+	if err != nil {
+		err = nil
+		return
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(pidData)))
+	if err != nil {
+		err = nil
+		return
+	}
+
+	procStat, err := utils.ProcessUsage(pid)
+	if err != nil {
+		return
+	}
+
+	cur := &counter{
+		Timestamp: now,
+		CpuTime:   procStat.CpuTime,
+	}
+
+	blockStats, err := qms.GetBlockStats(inst.Id)
+	if err != nil {
+		return
+	}
+
+	for _, stat := range blockStats {
+		cur.DiskRead += stat.ReadBytes
+		cur.DiskWrite += stat.WriteBytes
+	}
+
+	cur.NetRx, cur.NetTx, err = getNetStats(inst.Id)
+	if err != nil {
+		return
+	}
+
+	prev := counters[inst.Id]
+	counters[inst.Id] = cur
+
+	if prev == nil {
+		return
+	}
+
+	seconds := cur.Timestamp.Sub(prev.Timestamp).Seconds()
+	if seconds <= 0 {
+		return
+	}
+
+	cpu := 0.0
+	if cur.CpuTime > prev.CpuTime {
+		cpu = utils.ToFixed((cur.CpuTime-prev.CpuTime)/seconds/
+			float64(utils.Max(inst.Processors, 1))*100, 2)
+	}
+
+	mtr := &Metric{
+		Resource:     Instance,
+		ResourceId:   inst.Id,
+		Organization: inst.Organization,
+		Timestamp:    now,
+		Cpu:          cpu,
+		Memory: utils.ToFixed(
+			float64(procStat.Memory)/float64(1048576), 2),
+		DiskRead:  rate(cur.DiskRead, prev.DiskRead, seconds),
+		DiskWrite: rate(cur.DiskWrite, prev.DiskWrite, seconds),
+		NetRx:     rate(cur.NetRx, prev.NetRx, seconds),
+		NetTx:     rate(cur.NetTx, prev.NetTx, seconds),
+	}
+
+	err = mtr.Record(db)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func collectNode(db *database.Database, now time.Time) (err error) {
+	cpu, err := utils.CpuUsed()
+	if err != nil {
+		return
+	}
+
+	mem, _, err := utils.MemoryUsed()
+	if err != nil {
+		return
+	}
+
+	load, err := utils.LoadAverage()
+	if err != nil {
+		return
+	}
+
+	mtr := &Metric{
+		Resource:   Node,
+		ResourceId: node.Self.Id,
+		Timestamp:  now,
+		Cpu:        cpu,
+		Memory:     mem,
+		Load1:      load.Load1,
+	}
+
+	err = mtr.Record(db)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func Collect() {
+	db := database.GetDatabase()
+	defer db.Close()
+
+	now := time.Now()
+
+	err := collectNode(db, now)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("metric: Failed to collect node metrics")
+	}
+
+	if !node.Self.IsHypervisor() {
+		return
+	}
+
+	insts, err := instance.GetAll(db, &bson.M{
+		"node":     node.Self.Id,
+		"vm_state": vm.Running,
+	})
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("metric: Failed to get instances")
+		return
+	}
+
+	curInsts := set.NewSet()
+	for _, inst := range insts {
+		curInsts.Add(inst.Id)
+
+		err = collectInstance(db, inst, now)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"instance_id": inst.Id.Hex(),
+				"error":       err,
+			}).Error("metric: Failed to collect instance metrics")
+		}
+	}
+
+	for instId := range counters {
+		if !curInsts.Contains(instId) {
+			delete(counters, instId)
+		}
+	}
+}
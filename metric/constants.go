@@ -0,0 +1,9 @@
+package metric
+
+const (
+	Node     = "node"
+	Instance = "instance"
+
+	Minute = "minute"
+	Hour   = "hour"
+)
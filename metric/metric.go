@@ -0,0 +1,71 @@
+package metric
+
+import (
+	"github.com/pritunl/pritunl-cloud/database"
+	"gopkg.in/mgo.v2/bson"
+	"time"
+)
+
+type Metric struct {
+	Resource     string        `bson:"resource"`
+	ResourceId   bson.ObjectId `bson:"resource_id"`
+	Organization bson.ObjectId `bson:"organization,omitempty"`
+	Timestamp    time.Time     `bson:"timestamp"`
+	Cpu          float64       `bson:"cpu"`
+	Memory       float64       `bson:"memory"`
+	Load1        float64       `bson:"load1"`
+	DiskRead     float64       `bson:"disk_read"`
+	DiskWrite    float64       `bson:"disk_write"`
+	NetRx        float64       `bson:"net_rx"`
+	NetTx        float64       `bson:"net_tx"`
+}
+
+func (m *Metric) record(coll *database.Collection,
+	timestamp time.Time) (err error) {
+
+	set := bson.M{
+		"resource": m.Resource,
+	}
+	if m.Organization != "" {
+		set["organization"] = m.Organization
+	}
+
+	_, err = coll.Upsert(&bson.M{
+		"resource_id": m.ResourceId,
+		"timestamp":   timestamp,
+	}, &bson.M{
+		"$set": set,
+		"$inc": &bson.M{
+			"samples":    1,
+			"cpu":        m.Cpu,
+			"memory":     m.Memory,
+			"load1":      m.Load1,
+			"disk_read":  m.DiskRead,
+			"disk_write": m.DiskWrite,
+			"net_rx":     m.NetRx,
+			"net_tx":     m.NetTx,
+		},
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+func (m *Metric) Record(db *database.Database) (err error) {
+	timestamp := m.Timestamp.UTC()
+
+	err = m.record(db.Metrics(), timestamp.Truncate(time.Minute))
+	if err != nil {
+		return
+	}
+
+	err = m.record(db.MetricsHourly(), timestamp.Truncate(time.Hour))
+	if err != nil {
+		return
+	}
+
+	return
+}
@@ -0,0 +1,176 @@
+package metric
+
+import (
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"gopkg.in/mgo.v2/bson"
+	"time"
+)
+
+type Point struct {
+	Timestamp time.Time `json:"timestamp"`
+	Cpu       float64   `json:"cpu"`
+	Memory    float64   `json:"memory"`
+	Load1     float64   `json:"load1"`
+	DiskRead  float64   `json:"disk_read"`
+	DiskWrite float64   `json:"disk_write"`
+	NetRx     float64   `json:"net_rx"`
+	NetTx     float64   `json:"net_tx"`
+}
+
+type pointDoc struct {
+	Timestamp time.Time `bson:"timestamp"`
+	Samples   int       `bson:"samples"`
+	Cpu       float64   `bson:"cpu"`
+	Memory    float64   `bson:"memory"`
+	Load1     float64   `bson:"load1"`
+	DiskRead  float64   `bson:"disk_read"`
+	DiskWrite float64   `bson:"disk_write"`
+	NetRx     float64   `bson:"net_rx"`
+	NetTx     float64   `bson:"net_tx"`
+}
+
+func avg(val float64, samples int) float64 {
+	return utils.ToFixed(val/float64(samples), 2)
+}
+
+func GetSeries(db *database.Database, resourceId bson.ObjectId,
+	resolution string, start, end time.Time) (points []*Point, err error) {
+
+	var coll *database.Collection
+	if resolution == Hour {
+		coll = db.MetricsHourly()
+	} else {
+		coll = db.Metrics()
+	}
+
+	points = []*Point{}
+
+	cursor := coll.Find(&bson.M{
+		"resource_id": resourceId,
+		"timestamp": &bson.M{
+			"$gte": start,
+			"$lt":  end,
+		},
+	}).Sort("timestamp").Iter()
+
+	doc := &pointDoc{}
+	for cursor.Next(doc) {
+		if doc.Samples > 0 {
+			points = append(points, &Point{
+				Timestamp: doc.Timestamp,
+				Cpu:       avg(doc.Cpu, doc.Samples),
+				Memory:    avg(doc.Memory, doc.Samples),
+				Load1:     avg(doc.Load1, doc.Samples),
+				DiskRead:  avg(doc.DiskRead, doc.Samples),
+				DiskWrite: avg(doc.DiskWrite, doc.Samples),
+				NetRx:     avg(doc.NetRx, doc.Samples),
+				NetTx:     avg(doc.NetTx, doc.Samples),
+			})
+		}
+		doc = &pointDoc{}
+	}
+
+	err = cursor.Close()
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+type averageDoc struct {
+	Samples int     `bson:"samples"`
+	Cpu     float64 `bson:"cpu"`
+}
+
+func GetCpuAverage(db *database.Database, resourceIds []bson.ObjectId,
+	start time.Time) (cpu float64, samples int, err error) {
+
+	coll := db.Metrics()
+
+	resp := []*averageDoc{}
+	err = coll.Pipe([]*bson.M{
+		&bson.M{
+			"$match": &bson.M{
+				"resource_id": &bson.M{
+					"$in": resourceIds,
+				},
+				"timestamp": &bson.M{
+					"$gte": start,
+				},
+			},
+		},
+		&bson.M{
+			"$group": &bson.M{
+				"_id": nil,
+				"samples": &bson.M{
+					"$sum": "$samples",
+				},
+				"cpu": &bson.M{
+					"$sum": "$cpu",
+				},
+			},
+		},
+	}).All(&resp)
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	if len(resp) == 0 || resp[0].Samples == 0 {
+		return
+	}
+
+	samples = resp[0].Samples
+	cpu = avg(resp[0].Cpu, samples)
+
+	return
+}
+
+func parseTime(val string, def time.Time) (timestamp time.Time, ok bool) {
+	if val == "" {
+		timestamp = def
+		ok = true
+		return
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, val)
+	if err == nil {
+		ok = true
+		return
+	}
+
+	return
+}
+
+func ParseRange(startStr, endStr, resolution string) (
+	start, end time.Time, res string, ok bool) {
+
+	switch resolution {
+	case "", Minute:
+		res = Minute
+	case Hour:
+		res = Hour
+	default:
+		return
+	}
+
+	end, ok = parseTime(endStr, time.Now().UTC())
+	if !ok {
+		return
+	}
+
+	start, ok = parseTime(startStr, end.Add(-24*time.Hour))
+	if !ok {
+		return
+	}
+
+	if !start.Before(end) {
+		ok = false
+		return
+	}
+
+	return
+}
@@ -0,0 +1,159 @@
+package middlewear
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/Sirupsen/logrus"
+	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-cloud/audit"
+	"github.com/pritunl/pritunl-cloud/authorizer"
+	"github.com/pritunl/pritunl-cloud/constants"
+	"github.com/pritunl/pritunl-cloud/database"
+	"gopkg.in/mgo.v2/bson"
+	"io/ioutil"
+	"strings"
+)
+
+type auditWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *auditWriter) Write(data []byte) (int, error) {
+	if w.body.Len() < 65536 {
+		w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *auditWriter) WriteString(data string) (int, error) {
+	if w.body.Len() < 65536 {
+		w.body.WriteString(data)
+	}
+	return w.ResponseWriter.WriteString(data)
+}
+
+type auditResource struct {
+	Id  bson.ObjectId   `json:"id"`
+	Ids []bson.ObjectId `json:"ids"`
+}
+
+func parseAuditIds(data []byte) (ids []bson.ObjectId) {
+	if len(data) == 0 {
+		return
+	}
+
+	if data[0] == '[' {
+		json.Unmarshal(data, &ids)
+		return
+	}
+
+	resource := &auditResource{}
+	json.Unmarshal(data, resource)
+	ids = resource.Ids
+
+	return
+}
+
+func auditRequest(c *gin.Context, typCreate, typUpdate, typDelete string) {
+	typ := ""
+	switch c.Request.Method {
+	case "POST":
+		typ = typCreate
+		break
+	case "PUT":
+		typ = typUpdate
+		break
+	case "DELETE":
+		typ = typDelete
+		break
+	default:
+		return
+	}
+
+	var reqData []byte
+	if strings.HasPrefix(c.ContentType(), "application/json") &&
+		c.Request.Body != nil {
+
+		reqData, _ = ioutil.ReadAll(c.Request.Body)
+		c.Request.Body = ioutil.NopCloser(bytes.NewReader(reqData))
+	}
+
+	writer := &auditWriter{
+		ResponseWriter: c.Writer,
+		body:           &bytes.Buffer{},
+	}
+	c.Writer = writer
+
+	c.Next()
+
+	if c.Writer.Status() >= 400 {
+		return
+	}
+
+	db := c.MustGet("db").(*database.Database)
+	authr := c.MustGet("authorizer").(*authorizer.Authorizer)
+
+	usr, err := authr.GetUser(db)
+	if err != nil || usr == nil {
+		return
+	}
+
+	path := c.Request.URL.Path
+	resourcePath := strings.TrimPrefix(path, constants.ApiPrefix)
+	fields := audit.Fields{
+		"method":   c.Request.Method,
+		"path":     path,
+		"resource": strings.SplitN(strings.Trim(resourcePath, "/"), "/", 2)[0],
+	}
+
+	for _, param := range c.Params {
+		if _, ok := fields["resource_id"]; !ok {
+			fields["resource_id"] = param.Value
+		}
+		fields[param.Key] = param.Value
+	}
+
+	if _, ok := fields["resource_id"]; !ok && c.Request.Method == "POST" {
+		resource := &auditResource{}
+		json.Unmarshal(writer.body.Bytes(), resource)
+		if resource.Id != "" {
+			fields["resource_id"] = resource.Id.Hex()
+		}
+	}
+
+	ids := parseAuditIds(reqData)
+	if len(ids) > 0 {
+		fields["resource_ids"] = ids
+	}
+
+	if orgInf, ok := c.Get("organization"); ok {
+		fields["organization"] = orgInf.(bson.ObjectId)
+	}
+
+	if changesInf, ok := c.Get("audit_changes"); ok {
+		changes := changesInf.(audit.Fields)
+		if len(changes) > 0 {
+			fields["changes"] = changes
+		}
+	}
+
+	err = audit.New(db, c.Request, usr.Id, typ, fields)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"user_id": usr.Id.Hex(),
+			"path":    path,
+			"error":   err,
+		}).Error("middlewear: Failed to write resource audit")
+	}
+}
+
+func AuditAdmin(c *gin.Context) {
+	auditRequest(c, audit.AdminResourceCreate, audit.AdminResourceUpdate,
+		audit.AdminResourceDelete)
+}
+
+func AuditUser(c *gin.Context) {
+	auditRequest(c, audit.UserResourceCreate, audit.UserResourceUpdate,
+		audit.UserResourceDelete)
+}
@@ -14,9 +14,11 @@ import (
 	"github.com/pritunl/pritunl-cloud/node"
 	"github.com/pritunl/pritunl-cloud/organization"
 	"github.com/pritunl/pritunl-cloud/session"
+	"github.com/pritunl/pritunl-cloud/upload"
 	"github.com/pritunl/pritunl-cloud/utils"
 	"github.com/pritunl/pritunl-cloud/validator"
 	"net/http"
+	"strings"
 )
 
 const robots = `User-agent: *
@@ -24,7 +26,14 @@ Disallow: /
 `
 
 func Limiter(c *gin.Context) {
-	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, 1000000)
+	limit := int64(1000000)
+	if c.Request.Method == "PUT" &&
+		strings.Contains(c.Request.URL.Path, "/image_upload/") {
+
+		limit = upload.MaxChunkSize
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
 }
 
 func Counter(c *gin.Context) {
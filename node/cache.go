@@ -0,0 +1,31 @@
+package node
+
+import (
+	"gopkg.in/mgo.v2/bson"
+	"time"
+)
+
+type CacheImage struct {
+	Key      string        `bson:"key" json:"key"`
+	Image    bson.ObjectId `bson:"image" json:"image"`
+	Name     string        `bson:"-" json:"name"`
+	Etag     string        `bson:"etag" json:"etag"`
+	Size     int64         `bson:"size" json:"size"`
+	LastUsed time.Time     `bson:"last_used" json:"last_used"`
+	InUse    bool          `bson:"in_use" json:"in_use"`
+	Prefetch bool          `bson:"prefetch" json:"prefetch"`
+}
+
+type CacheImages []*CacheImage
+
+func (c CacheImages) Len() int {
+	return len(c)
+}
+
+func (c CacheImages) Swap(i, j int) {
+	c[i], c[j] = c[j], c[i]
+}
+
+func (c CacheImages) Less(i, j int) bool {
+	return c[i].LastUsed.Before(c[j].LastUsed)
+}
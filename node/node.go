@@ -9,6 +9,7 @@ import (
 	"github.com/pritunl/pritunl-cloud/database"
 	"github.com/pritunl/pritunl-cloud/errortypes"
 	"github.com/pritunl/pritunl-cloud/event"
+	"github.com/pritunl/pritunl-cloud/prometheus"
 	"github.com/pritunl/pritunl-cloud/settings"
 	"github.com/pritunl/pritunl-cloud/utils"
 	"gopkg.in/mgo.v2"
@@ -44,6 +45,9 @@ type Node struct {
 	ExternalInterface    string                     `bson:"external_interface" json:"external_interface"`
 	InternalInterface    string                     `bson:"internal_interface" json:"internal_interface"`
 	Firewall             bool                       `bson:"firewall" json:"firewall"`
+	LinkedClones         bool                       `bson:"linked_clones" json:"linked_clones"`
+	CacheLimit           int                        `bson:"cache_limit" json:"cache_limit"`
+	CacheImages          []*CacheImage              `bson:"cache_images" json:"-"`
 	NetworkRoles         []string                   `bson:"network_roles" json:"network_roles"`
 	Memory               float64                    `bson:"memory" json:"memory"`
 	Load1                float64                    `bson:"load1" json:"load1"`
@@ -69,6 +73,8 @@ func (n *Node) AddRequest() {
 	back := n.reqCount.Back()
 	back.Value = back.Value.(int) + 1
 	n.reqLock.Unlock()
+
+	prometheus.Add(prometheus.RequestsTotal, nil, 1)
 }
 
 func (n *Node) GetVirtPath() string {
@@ -175,6 +181,10 @@ func (n *Node) Validate(db *database.Database) (
 		n.CachePath = DefaultCache
 	}
 
+	if n.CacheLimit < 0 {
+		n.CacheLimit = 0
+	}
+
 	if n.NetworkRoles == nil || !n.Firewall {
 		n.NetworkRoles = []string{}
 	}
@@ -296,6 +306,8 @@ func (n *Node) update(db *database.Database) (err error) {
 	n.ExternalInterface = nde.ExternalInterface
 	n.InternalInterface = nde.InternalInterface
 	n.Firewall = nde.Firewall
+	n.LinkedClones = nde.LinkedClones
+	n.CacheLimit = nde.CacheLimit
 	n.NetworkRoles = nde.NetworkRoles
 	n.VirtPath = nde.VirtPath
 	n.CachePath = nde.CachePath
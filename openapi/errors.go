@@ -0,0 +1,188 @@
+package openapi
+
+var (
+	quotaErrors = []string{
+		"quota_instances_exceeded",
+		"quota_processors_exceeded",
+		"quota_memory_exceeded",
+		"quota_disk_size_exceeded",
+		"quota_vpcs_exceeded",
+		"quota_images_exceeded",
+	}
+	errorCodes = map[string][]string{
+		"certificate": []string{
+			"missing_acme_domains",
+		},
+		"device": []string{
+			"device_mode_invalid",
+			"device_name_invalid",
+			"device_name_missing",
+			"device_type_invalid",
+		},
+		"disk": []string{
+			"index_invalid",
+			"index_out_of_range",
+			"invalid_state",
+		},
+		"domain": []string{
+			"organization_required",
+		},
+		"firewall": []string{
+			"invalid_ingress_rule_port",
+			"invalid_ingress_rule_protocol",
+			"invalid_ingress_rule_source_ip",
+		},
+		"group": []string{
+			"auto_scaling_invalid",
+			"count_invalid",
+			"organization_required",
+			"rollout_invalid",
+			"schedule_invalid",
+			"template_invalid",
+			"template_required",
+			"zone_required",
+		},
+		"image": []string{
+			"image_share_invalid",
+		},
+		"image_import": []string{
+			"checksum_invalid",
+			"datacenter_required",
+			"datacenter_storage_missing",
+			"format_invalid",
+			"name_required",
+			"organization_required",
+			"url_invalid",
+		},
+		"image_upload": []string{
+			"checksum_invalid",
+			"datacenter_required",
+			"datacenter_storage_missing",
+			"format_invalid",
+			"name_required",
+			"organization_required",
+			"size_invalid",
+			"upload_node_invalid",
+			"upload_offset_invalid",
+			"upload_state_invalid",
+		},
+		"instance": []string{
+			"image_deprecated",
+			"image_family_not_found",
+			"image_obsolete",
+			"image_required",
+			"init_disk_size_invalid",
+			"node_required",
+			"organization_required",
+			"static_ip_count_invalid",
+			"static_ip_in_use",
+			"user_data_invalid",
+			"vpc_required",
+			"zone_required",
+		},
+		"node": []string{
+			"firewall_empty_roles",
+			"node_port_invalid",
+			"node_protocol_invalid",
+		},
+		"organization": []string{
+			"domain_forbidden",
+			"quota_invalid",
+			"spec_invalid",
+		},
+		"policy": []string{
+			"blacklist_networks_policy",
+			"browser_policy",
+			"location_policy",
+			"operating_system_policy",
+			"whitelist_networks_policy",
+		},
+		"settings": []string{
+			"family_pattern_invalid",
+			"signature_policy_invalid",
+			"trusted_key_invalid",
+		},
+		"template": []string{
+			"count_invalid",
+			"disk_size_invalid",
+			"disks_invalid",
+			"image_deprecated",
+			"image_family_not_found",
+			"image_obsolete",
+			"image_required",
+			"init_disk_size_invalid",
+			"node_required",
+			"organization_required",
+			"spec_required",
+			"static_ip_count_invalid",
+			"static_ip_in_use",
+			"static_ip_invalid",
+			"user_data_invalid",
+			"vpc_required",
+			"zone_required",
+		},
+		"user": []string{
+			"user_missing_super",
+			"user_password_missing",
+			"user_remove_super",
+			"user_type_invalid",
+			"user_username_invalid",
+		},
+		"vpc": []string{
+			"datacenter_required",
+			"duplicate_destination",
+			"link_auth_invalid",
+			"link_cert_invalid",
+			"link_node_count_invalid",
+			"link_routing_invalid",
+			"network_invalid",
+			"network_invalid6",
+			"organization_required",
+			"peer_auth_invalid",
+			"peer_esp_proposal_invalid",
+			"peer_ike_proposal_invalid",
+			"peer_local_subnets_invalid",
+			"peer_pre_shared_key_invalid",
+			"peer_remote_id_invalid",
+			"peer_remote_invalid",
+			"peer_remote_subnets_invalid",
+			"peer_remote_subnets_required",
+			"route_destination_invalid",
+			"route_target_destination_invalid",
+			"route_target_invalid",
+			"route_target_invalid_network",
+			"route_target_invalid_network6",
+			"static_ip_in_use",
+			"static_ip_invalid",
+			"static_ip_invalid_network",
+			"static_ip_reserved",
+			"vpc_id_invalid",
+		},
+		"webhook": []string{
+			"webhook_event_invalid",
+			"webhook_url_invalid",
+		},
+		"zone": []string{
+			"datacenter_required",
+		},
+	}
+)
+
+// Validation errors are returned as errortypes.ErrorData
+func errorSchema(tag string) *Schema {
+	codes := append([]string{}, errorCodes[tag]...)
+	codes = append(codes, quotaErrors...)
+
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"error": &Schema{
+				Type: "string",
+				Enum: codes,
+			},
+			"error_msg": &Schema{
+				Type: "string",
+			},
+		},
+	}
+}
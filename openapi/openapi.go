@@ -0,0 +1,255 @@
+// Generates an OpenAPI 3 document from registered handler routes.
+package openapi
+
+import (
+	"github.com/pritunl/pritunl-cloud/constants"
+	"strings"
+)
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Server struct {
+	Url string `json:"url"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type Operation struct {
+	OperationId string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	In          string `json:"in"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+type Document struct {
+	Openapi    string                           `json:"openapi"`
+	Info       *Info                            `json:"info"`
+	Servers    []*Server                        `json:"servers"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components *Components                      `json:"components"`
+	Security   []map[string][]string            `json:"security"`
+}
+
+// Op describes a route, Request and Response are zero values of the
+// handler data structs
+type Op struct {
+	Summary  string
+	Query    []string
+	Request  interface{}
+	Response interface{}
+	Binary   bool
+}
+
+func New(title, prefix string) (doc *Document) {
+	doc = &Document{
+		Openapi: "3.0.3",
+		Info: &Info{
+			Title:   title,
+			Version: constants.Version,
+		},
+		Servers: []*Server{
+			&Server{
+				Url: prefix,
+			},
+		},
+		Paths: map[string]map[string]*Operation{},
+		Components: &Components{
+			Schemas: map[string]*Schema{},
+			SecuritySchemes: map[string]*SecurityScheme{
+				"token": &SecurityScheme{
+					Type: "apiKey",
+					In:   "header",
+					Name: "Pritunl-Cloud-Token",
+					Description: "API token, requests must also include " +
+						"Pritunl-Cloud-Timestamp, Pritunl-Cloud-Nonce and " +
+						"Pritunl-Cloud-Signature headers. The signature is " +
+						"the base64 HMAC-SHA512 of " +
+						"token&timestamp&nonce&method&path using the API " +
+						"secret.",
+				},
+			},
+		},
+		Security: []map[string][]string{
+			map[string][]string{
+				"token": []string{},
+			},
+		},
+	}
+
+	return
+}
+
+func (d *Document) add(method, path string, operationId string,
+	headers []string, op *Op) {
+
+	if op == nil {
+		op = &Op{}
+	}
+
+	tag := strings.SplitN(strings.Trim(path, "/"), "/", 2)[0]
+
+	oper := &Operation{
+		OperationId: operationId,
+		Summary:     op.Summary,
+		Tags:        []string{tag},
+		Parameters:  []*Parameter{},
+		Responses: map[string]*Response{
+			"401": &Response{
+				Description: "Unauthorized",
+			},
+		},
+	}
+
+	docPath := []string{}
+	for _, part := range strings.Split(path, "/") {
+		if strings.HasPrefix(part, ":") {
+			part = part[1:]
+			oper.Parameters = append(oper.Parameters, &Parameter{
+				Name:     part,
+				In:       "path",
+				Required: true,
+				Schema:   d.schema(objectIdType),
+			})
+			part = "{" + part + "}"
+		}
+		docPath = append(docPath, part)
+	}
+
+	for _, header := range headers {
+		oper.Parameters = append(oper.Parameters, &Parameter{
+			Name:     header,
+			In:       "header",
+			Required: true,
+			Schema: &Schema{
+				Type: "string",
+			},
+		})
+	}
+
+	for _, query := range op.Query {
+		oper.Parameters = append(oper.Parameters, &Parameter{
+			Name: query,
+			In:   "query",
+			Schema: &Schema{
+				Type: "string",
+			},
+		})
+	}
+
+	if op.Request != nil {
+		oper.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]*MediaType{
+				"application/json": &MediaType{
+					Schema: d.Schema(op.Request),
+				},
+			},
+		}
+
+		oper.Responses["400"] = &Response{
+			Description: "Validation error",
+			Content: map[string]*MediaType{
+				"application/json": &MediaType{
+					Schema: errorSchema(tag),
+				},
+			},
+		}
+	}
+
+	if op.Binary {
+		oper.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]*MediaType{
+				"application/octet-stream": &MediaType{
+					Schema: &Schema{
+						Type:   "string",
+						Format: "binary",
+					},
+				},
+			},
+		}
+
+		oper.Responses["400"] = &Response{
+			Description: "Validation error",
+			Content: map[string]*MediaType{
+				"application/json": &MediaType{
+					Schema: errorSchema(tag),
+				},
+			},
+		}
+	}
+
+	if op.Response != nil {
+		oper.Responses["200"] = &Response{
+			Description: "Success",
+			Content: map[string]*MediaType{
+				"application/json": &MediaType{
+					Schema: d.Schema(op.Response),
+				},
+			},
+		}
+	} else {
+		oper.Responses["200"] = &Response{
+			Description: "Success",
+		}
+	}
+
+	if strings.Contains(path, "/:") {
+		oper.Responses["404"] = &Response{
+			Description: "Not found",
+		}
+	}
+
+	pth := strings.Join(docPath, "/")
+	if d.Paths[pth] == nil {
+		d.Paths[pth] = map[string]*Operation{}
+	}
+	d.Paths[pth][strings.ToLower(method)] = oper
+}
@@ -0,0 +1,44 @@
+package openapi
+
+import (
+	"github.com/gin-gonic/gin"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// Router registers handlers on each group and documents the route
+type Router struct {
+	Document *Document
+	Groups   []*gin.RouterGroup
+	Headers  []string
+}
+
+func (r *Router) Handle(method, path string, handler gin.HandlerFunc,
+	op *Op) {
+
+	for _, group := range r.Groups {
+		group.Handle(method, path, handler)
+	}
+
+	name := runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
+	name = name[strings.LastIndex(name, ".")+1:]
+
+	r.Document.add(method, path, name, r.Headers, op)
+}
+
+func (r *Router) GET(path string, handler gin.HandlerFunc, op *Op) {
+	r.Handle("GET", path, handler, op)
+}
+
+func (r *Router) PUT(path string, handler gin.HandlerFunc, op *Op) {
+	r.Handle("PUT", path, handler, op)
+}
+
+func (r *Router) POST(path string, handler gin.HandlerFunc, op *Op) {
+	r.Handle("POST", path, handler, op)
+}
+
+func (r *Router) DELETE(path string, handler gin.HandlerFunc, op *Op) {
+	r.Handle("DELETE", path, handler, op)
+}
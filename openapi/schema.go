@@ -0,0 +1,157 @@
+package openapi
+
+import (
+	"gopkg.in/mgo.v2/bson"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	objectIdType = reflect.TypeOf(bson.ObjectId(""))
+	timeType     = reflect.TypeOf(time.Time{})
+	bytesType    = reflect.TypeOf([]byte{})
+)
+
+func schemaName(typ reflect.Type) string {
+	return path.Base(typ.PkgPath()) + "." + typ.Name()
+}
+
+// Schema returns the schema of a value, named structs are added to the
+// document components and referenced
+func (d *Document) Schema(val interface{}) *Schema {
+	return d.schema(reflect.TypeOf(val))
+}
+
+func (d *Document) schema(typ reflect.Type) (schm *Schema) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	switch typ {
+	case objectIdType:
+		schm = &Schema{
+			Type:   "string",
+			Format: "objectid",
+		}
+		return
+	case timeType:
+		schm = &Schema{
+			Type:   "string",
+			Format: "date-time",
+		}
+		return
+	case bytesType:
+		schm = &Schema{
+			Type:   "string",
+			Format: "byte",
+		}
+		return
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		schm = &Schema{
+			Type: "boolean",
+		}
+		break
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32, reflect.Uint64:
+
+		schm = &Schema{
+			Type: "integer",
+		}
+		break
+	case reflect.Float32, reflect.Float64:
+		schm = &Schema{
+			Type: "number",
+		}
+		break
+	case reflect.String:
+		schm = &Schema{
+			Type: "string",
+		}
+		break
+	case reflect.Slice, reflect.Array:
+		schm = &Schema{
+			Type:  "array",
+			Items: d.schema(typ.Elem()),
+		}
+		break
+	case reflect.Map:
+		schm = &Schema{
+			Type:                 "object",
+			AdditionalProperties: d.schema(typ.Elem()),
+		}
+		break
+	case reflect.Struct:
+		if typ.Name() == "" {
+			schm = d.structSchema(typ)
+			break
+		}
+
+		name := schemaName(typ)
+		if _, ok := d.Components.Schemas[name]; !ok {
+			d.Components.Schemas[name] = &Schema{}
+			d.Components.Schemas[name] = d.structSchema(typ)
+		}
+
+		schm = &Schema{
+			Ref: "#/components/schemas/" + name,
+		}
+		break
+	default:
+		schm = &Schema{}
+	}
+
+	return
+}
+
+func (d *Document) structSchema(typ reflect.Type) (schm *Schema) {
+	schm = &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{},
+	}
+
+	d.structFields(typ, schm.Properties)
+
+	return
+}
+
+func (d *Document) structFields(typ reflect.Type,
+	props map[string]*Schema) {
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		if field.Anonymous && name == "" {
+			fieldTyp := field.Type
+			for fieldTyp.Kind() == reflect.Ptr {
+				fieldTyp = fieldTyp.Elem()
+			}
+
+			if fieldTyp.Kind() == reflect.Struct {
+				d.structFields(fieldTyp, props)
+				continue
+			}
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		props[name] = d.schema(field.Type)
+	}
+}
@@ -12,6 +12,7 @@ type Organization struct {
 	Id    bson.ObjectId `bson:"_id,omitempty" json:"id"`
 	Roles []string      `bson:"roles" json:"roles"`
 	Name  string        `bson:"name" json:"name"`
+	Quota *Quota        `bson:"quota" json:"quota"`
 }
 
 func (d *Organization) Validate(db *database.Database) (
@@ -21,6 +22,21 @@ func (d *Organization) Validate(db *database.Database) (
 		d.Roles = []string{}
 	}
 
+	if d.Quota == nil {
+		d.Quota = &Quota{}
+	}
+
+	if d.Quota.Instances < 0 || d.Quota.Processors < 0 ||
+		d.Quota.Memory < 0 || d.Quota.DiskSize < 0 ||
+		d.Quota.Images < 0 || d.Quota.Vpcs < 0 {
+
+		errData = &errortypes.ErrorData{
+			Error:   "quota_invalid",
+			Message: "Organization quota cannot be negative",
+		}
+		return
+	}
+
 	return
 }
 
@@ -0,0 +1,10 @@
+package organization
+
+type Quota struct {
+	Instances  int `bson:"instances" json:"instances"`
+	Processors int `bson:"processors" json:"processors"`
+	Memory     int `bson:"memory" json:"memory"`
+	DiskSize   int `bson:"disk_size" json:"disk_size"`
+	Images     int `bson:"images" json:"images"`
+	Vpcs       int `bson:"vpcs" json:"vpcs"`
+}
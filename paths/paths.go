@@ -71,6 +71,11 @@ func GetSockPath(virtId bson.ObjectId) string {
 		fmt.Sprintf("%s.sock", virtId.Hex()))
 }
 
+func GetQmpSockPath(virtId bson.ObjectId) string {
+	return path.Join(settings.Hypervisor.LibPath,
+		fmt.Sprintf("%s.qmp.sock", virtId.Hex()))
+}
+
 func GetGuestPath(virtId bson.ObjectId) string {
 	return path.Join(settings.Hypervisor.LibPath,
 		fmt.Sprintf("%s.guest", virtId.Hex()))
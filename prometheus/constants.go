@@ -0,0 +1,67 @@
+package prometheus
+
+const (
+	Counter = "counter"
+	Gauge   = "gauge"
+
+	RequestsTotal       = "pritunl_cloud_requests_total"
+	NodeMemory          = "pritunl_cloud_node_memory_percent"
+	NodeLoad1           = "pritunl_cloud_node_load1"
+	NodeLoad5           = "pritunl_cloud_node_load5"
+	NodeLoad15          = "pritunl_cloud_node_load15"
+	NodeCpuUnits        = "pritunl_cloud_node_cpu_units"
+	NodeCpuUnitsRes     = "pritunl_cloud_node_cpu_units_reserved"
+	NodeMemoryUnits     = "pritunl_cloud_node_memory_units"
+	NodeMemoryUnitsRes  = "pritunl_cloud_node_memory_units_reserved"
+	Instances           = "pritunl_cloud_instances"
+	ClusterInstances    = "pritunl_cloud_cluster_instances"
+	DeployTotal         = "pritunl_cloud_deploys_total"
+	DeployDuration      = "pritunl_cloud_deploy_duration_seconds"
+	DeployDurationTotal = "pritunl_cloud_deploy_duration_seconds_total"
+	TaskJobsTotal       = "pritunl_cloud_task_jobs_total"
+	TaskDuration        = "pritunl_cloud_task_duration_seconds"
+	LinkConnections     = "pritunl_cloud_link_connections"
+	EventLag            = "pritunl_cloud_event_lag_seconds"
+	EventsReceivedTotal = "pritunl_cloud_events_received_total"
+)
+
+func init() {
+	Register(RequestsTotal, Counter,
+		"Number of web requests handled")
+	Register(NodeMemory, Gauge,
+		"Node memory usage percent")
+	Register(NodeLoad1, Gauge,
+		"Node load average over one minute")
+	Register(NodeLoad5, Gauge,
+		"Node load average over five minutes")
+	Register(NodeLoad15, Gauge,
+		"Node load average over fifteen minutes")
+	Register(NodeCpuUnits, Gauge,
+		"Node available cpu units")
+	Register(NodeCpuUnitsRes, Gauge,
+		"Node reserved cpu units")
+	Register(NodeMemoryUnits, Gauge,
+		"Node available memory units")
+	Register(NodeMemoryUnitsRes, Gauge,
+		"Node reserved memory units")
+	Register(Instances, Gauge,
+		"Number of instances on node by state")
+	Register(ClusterInstances, Gauge,
+		"Number of instances in cluster by state")
+	Register(DeployTotal, Counter,
+		"Number of hypervisor deploy loops by result")
+	Register(DeployDuration, Gauge,
+		"Duration of last hypervisor deploy loop")
+	Register(DeployDurationTotal, Counter,
+		"Total duration of hypervisor deploy loops")
+	Register(TaskJobsTotal, Counter,
+		"Number of task jobs by result")
+	Register(TaskDuration, Gauge,
+		"Duration of last task job")
+	Register(LinkConnections, Gauge,
+		"Number of link connections by vpc and status")
+	Register(EventLag, Gauge,
+		"Delay between event publish and receive")
+	Register(EventsReceivedTotal, Counter,
+		"Number of events received")
+}
@@ -0,0 +1,139 @@
+package prometheus
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	families     = map[string]*family{}
+	familiesLock = sync.Mutex{}
+	labelEscape  = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+type Labels map[string]string
+
+type family struct {
+	Name    string
+	Type    string
+	Help    string
+	Samples map[string]float64
+}
+
+func (l Labels) String() string {
+	if len(l) == 0 {
+		return ""
+	}
+
+	keys := []string{}
+	for key := range l {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := []string{}
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf(
+			`%s="%s"`, key, labelEscape.Replace(l[key])))
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func Register(name, typ, help string) {
+	familiesLock.Lock()
+	families[name] = &family{
+		Name:    name,
+		Type:    typ,
+		Help:    help,
+		Samples: map[string]float64{},
+	}
+	familiesLock.Unlock()
+}
+
+func Add(name string, labels Labels, val float64) {
+	familiesLock.Lock()
+	defer familiesLock.Unlock()
+
+	fam := families[name]
+	if fam == nil {
+		return
+	}
+
+	fam.Samples[labels.String()] += val
+}
+
+func Set(name string, labels Labels, val float64) {
+	familiesLock.Lock()
+	defer familiesLock.Unlock()
+
+	fam := families[name]
+	if fam == nil {
+		return
+	}
+
+	fam.Samples[labels.String()] = val
+}
+
+func Reset(name string) {
+	familiesLock.Lock()
+	defer familiesLock.Unlock()
+
+	fam := families[name]
+	if fam == nil {
+		return
+	}
+
+	fam.Samples = map[string]float64{}
+}
+
+func Write(w io.Writer) (err error) {
+	familiesLock.Lock()
+	defer familiesLock.Unlock()
+
+	names := []string{}
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	writer := bufio.NewWriter(w)
+
+	for _, name := range names {
+		fam := families[name]
+		if len(fam.Samples) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(writer, "# HELP %s %s\n", fam.Name, fam.Help)
+		fmt.Fprintf(writer, "# TYPE %s %s\n", fam.Name, fam.Type)
+
+		labels := []string{}
+		for label := range fam.Samples {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+
+		for _, label := range labels {
+			fmt.Fprintf(writer, "%s%s %s\n", fam.Name, label,
+				strconv.FormatFloat(fam.Samples[label], 'g', -1, 64))
+		}
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "prometheus: Failed to write metrics"),
+		}
+		return
+	}
+
+	return
+}
@@ -1074,7 +1074,7 @@ func Create(db *database.Database, inst *instance.Instance,
 			Size:           inst.InitDiskSize,
 		}
 
-		err = data.WriteImage(db, virt.Image, dsk.Id, inst.InitDiskSize)
+		err = data.WriteImage(db, dsk)
 		if err != nil {
 			return
 		}
@@ -1132,6 +1132,7 @@ func Destroy(db *database.Database, virt *vm.VirtualMachine) (err error) {
 	unitName := paths.GetUnitName(virt.Id)
 	unitPath := paths.GetUnitPath(virt.Id)
 	sockPath := paths.GetSockPath(virt.Id)
+	qmpSockPath := paths.GetQmpSockPath(virt.Id)
 	guestPath := paths.GetGuestPath(virt.Id)
 	pidPath := paths.GetPidPath(virt.Id)
 
@@ -1193,6 +1194,11 @@ func Destroy(db *database.Database, virt *vm.VirtualMachine) (err error) {
 		return
 	}
 
+	err = utils.RemoveAll(qmpSockPath)
+	if err != nil {
+		return
+	}
+
 	err = utils.RemoveAll(guestPath)
 	if err != nil {
 		return
@@ -7,9 +7,25 @@ import (
 	"github.com/pritunl/pritunl-cloud/vpc"
 	"gopkg.in/mgo.v2/bson"
 	"net"
+	"strconv"
 	"strings"
 )
 
+func parseMetric(metric string) (priority int, ok bool) {
+	n, err := strconv.Atoi(metric)
+	if err != nil {
+		return
+	}
+
+	priority = n - vpc.RouteMetric
+	if priority < 0 || priority >= vpc.LinkNodeMax {
+		return
+	}
+
+	ok = true
+	return
+}
+
 func GetRoutes(instId bson.ObjectId) (routes []vpc.Route,
 	routes6 []vpc.Route, err error) {
 
@@ -45,7 +61,8 @@ func GetRoutes(instId bson.ObjectId) (routes []vpc.Route,
 				continue
 			}
 
-			if fields[4] != "97" {
+			priority, ok := parseMetric(fields[4])
+			if !ok {
 				continue
 			}
 
@@ -62,6 +79,7 @@ func GetRoutes(instId bson.ObjectId) (routes []vpc.Route,
 			route := vpc.Route{
 				Destination: fmt.Sprintf("%s/%d", fields[0], cidr),
 				Target:      fields[1],
+				Priority:    priority,
 			}
 
 			routes = append(routes, route)
@@ -91,7 +109,8 @@ func GetRoutes(instId bson.ObjectId) (routes []vpc.Route,
 				continue
 			}
 
-			if fields[3] != "97" {
+			priority, ok := parseMetric(fields[3])
+			if !ok {
 				continue
 			}
 
@@ -108,6 +127,7 @@ func GetRoutes(instId bson.ObjectId) (routes []vpc.Route,
 			route := vpc.Route{
 				Destination: destination.String(),
 				Target:      target.String(),
+				Priority:    priority,
 			}
 
 			routes6 = append(routes6, route)
@@ -1,7 +1,6 @@
 package qms
 
 import (
-	"bytes"
 	"fmt"
 	"github.com/Sirupsen/logrus"
 	"github.com/dropbox/godropbox/errors"
@@ -9,7 +8,6 @@ import (
 	"github.com/pritunl/pritunl-cloud/utils"
 	"github.com/pritunl/pritunl-cloud/vm"
 	"gopkg.in/mgo.v2/bson"
-	"net"
 	"strconv"
 	"strings"
 	"time"
@@ -20,76 +18,17 @@ var (
 )
 
 func GetDisks(vmId bson.ObjectId) (disks []*vm.Disk, err error) {
-	sockPath := GetSockPath(vmId)
 	disks = []*vm.Disk{}
 
 	lockId := socketsLock.Lock(vmId.Hex())
 	defer socketsLock.Unlock(vmId.Hex(), lockId)
 
-	conn, err := net.DialTimeout(
-		"unix",
-		sockPath,
-		1*time.Second,
-	)
-	if err != nil {
-		err = &errortypes.ReadError{
-			errors.Wrap(err, "qemu: Failed to open socket"),
-		}
-		return
-	}
-	defer conn.Close()
-
-	err = conn.SetDeadline(time.Now().Add(2 * time.Second))
+	output, err := runMonitor(vmId, "info block")
 	if err != nil {
-		err = &errortypes.ReadError{
-			errors.Wrap(err, "qemu: Failed set deadline"),
-		}
 		return
 	}
 
-	buffer := make([]byte, 100000)
-	for {
-		buf := make([]byte, 10000)
-		n, e := conn.Read(buf)
-		if e != nil {
-			err = &errortypes.ReadError{
-				errors.Wrap(e, "qemu: Failed to read socket"),
-			}
-			return
-		}
-		buffer = append(buffer, buf[:n]...)
-
-		if bytes.Contains(bytes.TrimSpace(buffer), []byte("(qemu)")) {
-			break
-		}
-	}
-
-	_, err = conn.Write([]byte("info block\n"))
-	if err != nil {
-		err = &errortypes.ReadError{
-			errors.Wrap(err, "qemu: Failed to write socket"),
-		}
-		return
-	}
-
-	buffer = make([]byte, 100000)
-	for {
-		buf := make([]byte, 10000)
-		n, e := conn.Read(buf)
-		if e != nil {
-			err = &errortypes.ReadError{
-				errors.Wrap(e, "qemu: Failed to read socket"),
-			}
-			return
-		}
-		buffer = append(buffer, buf[:n]...)
-
-		if bytes.Contains(bytes.TrimSpace(buffer), []byte("(qemu)")) {
-			break
-		}
-	}
-
-	for _, line := range strings.Split(string(buffer), "\n") {
+	for _, line := range strings.Split(output, "\n") {
 		if !strings.HasPrefix(line, "virtio") || len(line) < 10 {
 			continue
 		}
@@ -135,27 +74,12 @@ func AddDisk(vmId bson.ObjectId, dsk *vm.Disk) (err error) {
 	lockId := socketsLock.Lock(vmId.Hex())
 	defer socketsLock.Unlock(vmId.Hex(), lockId)
 
-	conn, err := net.DialTimeout(
-		"unix",
-		sockPath,
-		1*time.Second,
-	)
+	conn, err := dial(sockPath, 1*time.Second)
 	if err != nil {
-		err = &errortypes.ReadError{
-			errors.Wrap(err, "qemu: Failed to open socket"),
-		}
 		return
 	}
 	defer conn.Close()
 
-	err = conn.SetDeadline(time.Now().Add(1 * time.Second))
-	if err != nil {
-		err = &errortypes.ReadError{
-			errors.Wrap(err, "qemu: Failed set deadline"),
-		}
-		return
-	}
-
 	drive := fmt.Sprintf(
 		"file=%s,index=%d,media=disk,format=qcow2,discard=on,if=virtio\n",
 		dsk.Path,
@@ -186,27 +110,12 @@ func RemoveDisk(vmId bson.ObjectId, dsk *vm.Disk) (err error) {
 	lockId := socketsLock.Lock(vmId.Hex())
 	defer socketsLock.Unlock(vmId.Hex(), lockId)
 
-	conn, err := net.DialTimeout(
-		"unix",
-		sockPath,
-		1*time.Second,
-	)
+	conn, err := dial(sockPath, 1*time.Second)
 	if err != nil {
-		err = &errortypes.ReadError{
-			errors.Wrap(err, "qemu: Failed to open socket"),
-		}
 		return
 	}
 	defer conn.Close()
 
-	err = conn.SetDeadline(time.Now().Add(1 * time.Second))
-	if err != nil {
-		err = &errortypes.ReadError{
-			errors.Wrap(err, "qemu: Failed set deadline"),
-		}
-		return
-	}
-
 	_, err = conn.Write([]byte(
 		fmt.Sprintf("drive_del virtio%d\n", dsk.Index)))
 	if err != nil {
@@ -227,34 +136,67 @@ func Shutdown(vmId bson.ObjectId) (err error) {
 	lockId := socketsLock.Lock(vmId.Hex())
 	defer socketsLock.Unlock(vmId.Hex(), lockId)
 
-	conn, err := net.DialTimeout(
-		"unix",
-		sockPath,
-		1*time.Second,
-	)
+	conn, err := dial(sockPath, 1*time.Second)
 	if err != nil {
-		err = &errortypes.ReadError{
-			errors.Wrap(err, "qemu: Failed to open socket"),
-		}
 		return
 	}
 	defer conn.Close()
 
-	err = conn.SetDeadline(time.Now().Add(1 * time.Second))
+	_, err = conn.Write([]byte("system_powerdown\n"))
 	if err != nil {
 		err = &errortypes.ReadError{
-			errors.Wrap(err, "qemu: Failed set deadline"),
+			errors.Wrap(err, "qemu: Failed to write socket"),
 		}
 		return
 	}
 
-	_, err = conn.Write([]byte("system_powerdown\n"))
+	return
+}
+
+type BlockStat struct {
+	Index      int
+	ReadBytes  uint64
+	WriteBytes uint64
+}
+
+type qmpBlockStats struct {
+	Device string `json:"device"`
+	Stats  *struct {
+		ReadBytes  uint64 `json:"rd_bytes"`
+		WriteBytes uint64 `json:"wr_bytes"`
+	} `json:"stats"`
+}
+
+func GetBlockStats(vmId bson.ObjectId) (stats []*BlockStat, err error) {
+	stats = []*BlockStat{}
+
+	lockId := socketsLock.Lock(vmId.Hex())
+	defer socketsLock.Unlock(vmId.Hex(), lockId)
+
+	blockStats := []*qmpBlockStats{}
+	err = runQmp(vmId, "query-blockstats", &blockStats)
 	if err != nil {
-		err = &errortypes.ReadError{
-			errors.Wrap(err, "qemu: Failed to write socket"),
-		}
 		return
 	}
 
+	for _, blockStat := range blockStats {
+		if !strings.HasPrefix(blockStat.Device, "virtio") ||
+			blockStat.Stats == nil {
+
+			continue
+		}
+
+		index, e := strconv.Atoi(blockStat.Device[6:])
+		if e != nil {
+			continue
+		}
+
+		stats = append(stats, &BlockStat{
+			Index:      index,
+			ReadBytes:  blockStat.Stats.ReadBytes,
+			WriteBytes: blockStat.Stats.WriteBytes,
+		})
+	}
+
 	return
 }
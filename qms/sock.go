@@ -0,0 +1,219 @@
+package qms
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"gopkg.in/mgo.v2/bson"
+	"net"
+	"time"
+)
+
+type qmpCommand struct {
+	Execute string `json:"execute"`
+}
+
+type qmpError struct {
+	Class string `json:"class"`
+	Desc  string `json:"desc"`
+}
+
+type qmpResponse struct {
+	Event  string          `json:"event"`
+	Return json.RawMessage `json:"return"`
+	Error  *qmpError       `json:"error"`
+}
+
+func dial(sockPath string, timeout time.Duration) (
+	conn net.Conn, err error) {
+
+	conn, err = net.DialTimeout(
+		"unix",
+		sockPath,
+		1*time.Second,
+	)
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "qemu: Failed to open socket"),
+		}
+		return
+	}
+
+	err = conn.SetDeadline(time.Now().Add(timeout))
+	if err != nil {
+		conn.Close()
+		conn = nil
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "qemu: Failed set deadline"),
+		}
+		return
+	}
+
+	return
+}
+
+// Read monitor output until the next prompt
+func readPrompt(conn net.Conn) (output string, err error) {
+	buffer := make([]byte, 0, 10000)
+	buf := make([]byte, 10000)
+
+	for {
+		n, e := conn.Read(buf)
+		if e != nil {
+			err = &errortypes.ReadError{
+				errors.Wrap(e, "qemu: Failed to read socket"),
+			}
+			return
+		}
+		buffer = append(buffer, buf[:n]...)
+
+		if bytes.Contains(buffer, []byte("(qemu)")) {
+			break
+		}
+	}
+
+	output = string(buffer)
+
+	return
+}
+
+// Run a monitor command and return the output, caller must hold the
+// socket lock
+func runMonitor(vmId bson.ObjectId, cmd string) (output string, err error) {
+	conn, err := dial(GetSockPath(vmId), 2*time.Second)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	_, err = readPrompt(conn)
+	if err != nil {
+		return
+	}
+
+	_, err = conn.Write([]byte(cmd + "\n"))
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "qemu: Failed to write socket"),
+		}
+		return
+	}
+
+	output, err = readPrompt(conn)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func readQmp(reader *bufio.Reader) (resp *qmpResponse, err error) {
+	for {
+		line, e := reader.ReadBytes('\n')
+		if e != nil {
+			err = &errortypes.ReadError{
+				errors.Wrap(e, "qemu: Failed to read qmp socket"),
+			}
+			return
+		}
+
+		resp = &qmpResponse{}
+		err = json.Unmarshal(line, resp)
+		if err != nil {
+			err = &errortypes.ParseError{
+				errors.Wrap(err, "qemu: Failed to parse qmp response"),
+			}
+			return
+		}
+
+		if resp.Event != "" {
+			continue
+		}
+
+		if resp.Error != nil {
+			err = &errortypes.RequestError{
+				errors.Newf("qemu: Qmp error %s %s",
+					resp.Error.Class, resp.Error.Desc),
+			}
+			return
+		}
+
+		return
+	}
+}
+
+func writeQmp(conn net.Conn, execute string) (err error) {
+	data, err := json.Marshal(&qmpCommand{
+		Execute: execute,
+	})
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "qemu: Failed to marshal qmp command"),
+		}
+		return
+	}
+
+	_, err = conn.Write(append(data, '\n'))
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "qemu: Failed to write qmp socket"),
+		}
+		return
+	}
+
+	return
+}
+
+// Run a qmp command and unmarshal the return value, caller must hold the
+// socket lock
+func runQmp(vmId bson.ObjectId, execute string, ret interface{}) (
+	err error) {
+
+	conn, err := dial(GetQmpSockPath(vmId), 2*time.Second)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+
+	_, err = reader.ReadBytes('\n')
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "qemu: Failed to read qmp greeting"),
+		}
+		return
+	}
+
+	err = writeQmp(conn, "qmp_capabilities")
+	if err != nil {
+		return
+	}
+
+	_, err = readQmp(reader)
+	if err != nil {
+		return
+	}
+
+	err = writeQmp(conn, execute)
+	if err != nil {
+		return
+	}
+
+	resp, err := readQmp(reader)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(resp.Return, ret)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "qemu: Failed to parse qmp return"),
+		}
+		return
+	}
+
+	return
+}
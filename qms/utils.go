@@ -11,3 +11,8 @@ func GetSockPath(virtId bson.ObjectId) string {
 	return path.Join(settings.Hypervisor.LibPath,
 		fmt.Sprintf("%s.sock", virtId.Hex()))
 }
+
+func GetQmpSockPath(virtId bson.ObjectId) string {
+	return path.Join(settings.Hypervisor.LibPath,
+		fmt.Sprintf("%s.qmp.sock", virtId.Hex()))
+}
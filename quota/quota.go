@@ -0,0 +1,245 @@
+package quota
+
+import (
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/organization"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type Usage struct {
+	Instances  int `bson:"instances" json:"instances"`
+	Processors int `bson:"processors" json:"processors"`
+	Memory     int `bson:"memory" json:"memory"`
+	DiskSize   int `bson:"disk_size" json:"disk_size"`
+	Images     int `bson:"images" json:"images"`
+	Vpcs       int `bson:"vpcs" json:"vpcs"`
+}
+
+type Report struct {
+	Organization bson.ObjectId       `json:"organization"`
+	Quota        *organization.Quota `json:"quota"`
+	Usage        *Usage              `json:"usage"`
+}
+
+type instanceUsage struct {
+	Count      int `bson:"count"`
+	Processors int `bson:"processors"`
+	Memory     int `bson:"memory"`
+}
+
+type diskUsage struct {
+	Size int `bson:"size"`
+}
+
+func getInstanceUsage(db *database.Database, orgId bson.ObjectId,
+	usage *Usage) (err error) {
+
+	coll := db.Instances()
+
+	resp := []*instanceUsage{}
+	err = coll.Pipe([]*bson.M{
+		&bson.M{
+			"$match": &bson.M{
+				"organization": orgId,
+			},
+		},
+		&bson.M{
+			"$group": &bson.M{
+				"_id": nil,
+				"count": &bson.M{
+					"$sum": 1,
+				},
+				"processors": &bson.M{
+					"$sum": "$processors",
+				},
+				"memory": &bson.M{
+					"$sum": "$memory",
+				},
+			},
+		},
+	}).All(&resp)
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	if len(resp) > 0 {
+		usage.Instances = resp[0].Count
+		usage.Processors = resp[0].Processors
+		usage.Memory = resp[0].Memory
+	}
+
+	return
+}
+
+func getDiskUsage(db *database.Database, orgId bson.ObjectId,
+	usage *Usage) (err error) {
+
+	coll := db.Disks()
+
+	resp := []*diskUsage{}
+	err = coll.Pipe([]*bson.M{
+		&bson.M{
+			"$match": &bson.M{
+				"organization": orgId,
+			},
+		},
+		&bson.M{
+			"$group": &bson.M{
+				"_id": nil,
+				"size": &bson.M{
+					"$sum": "$size",
+				},
+			},
+		},
+	}).All(&resp)
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	if len(resp) > 0 {
+		usage.DiskSize = resp[0].Size
+	}
+
+	return
+}
+
+func GetUsage(db *database.Database, orgId bson.ObjectId) (
+	usage *Usage, err error) {
+
+	usage = &Usage{}
+
+	err = getInstanceUsage(db, orgId, usage)
+	if err != nil {
+		return
+	}
+
+	err = getDiskUsage(db, orgId, usage)
+	if err != nil {
+		return
+	}
+
+	usage.Images, err = db.Images().Find(&bson.M{
+		"organization": orgId,
+	}).Count()
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	usage.Vpcs, err = db.Vpcs().Find(&bson.M{
+		"organization": orgId,
+	}).Count()
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+func GetReport(db *database.Database, orgId bson.ObjectId) (
+	report *Report, err error) {
+
+	org, err := organization.Get(db, orgId)
+	if err != nil {
+		return
+	}
+
+	usage, err := GetUsage(db, orgId)
+	if err != nil {
+		return
+	}
+
+	quota := org.Quota
+	if quota == nil {
+		quota = &organization.Quota{}
+	}
+
+	report = &Report{
+		Organization: orgId,
+		Quota:        quota,
+		Usage:        usage,
+	}
+
+	return
+}
+
+func exceeded(limit, cur, add int) bool {
+	return limit > 0 && add > 0 && cur+add > limit
+}
+
+func Check(db *database.Database, orgId bson.ObjectId, req *Usage) (
+	errData *errortypes.ErrorData, err error) {
+
+	if orgId == "" {
+		return
+	}
+
+	org, err := organization.Get(db, orgId)
+	if err != nil {
+		return
+	}
+
+	quota := org.Quota
+	if quota == nil || *quota == (organization.Quota{}) {
+		return
+	}
+
+	usage, err := GetUsage(db, orgId)
+	if err != nil {
+		return
+	}
+
+	if exceeded(quota.Instances, usage.Instances, req.Instances) {
+		errData = &errortypes.ErrorData{
+			Error:   "quota_instances_exceeded",
+			Message: "Organization instance quota exceeded",
+		}
+		return
+	}
+
+	if exceeded(quota.Processors, usage.Processors, req.Processors) {
+		errData = &errortypes.ErrorData{
+			Error:   "quota_processors_exceeded",
+			Message: "Organization processor quota exceeded",
+		}
+		return
+	}
+
+	if exceeded(quota.Memory, usage.Memory, req.Memory) {
+		errData = &errortypes.ErrorData{
+			Error:   "quota_memory_exceeded",
+			Message: "Organization memory quota exceeded",
+		}
+		return
+	}
+
+	if exceeded(quota.DiskSize, usage.DiskSize, req.DiskSize) {
+		errData = &errortypes.ErrorData{
+			Error:   "quota_disk_size_exceeded",
+			Message: "Organization disk size quota exceeded",
+		}
+		return
+	}
+
+	if exceeded(quota.Images, usage.Images, req.Images) {
+		errData = &errortypes.ErrorData{
+			Error:   "quota_images_exceeded",
+			Message: "Organization image and snapshot quota exceeded",
+		}
+		return
+	}
+
+	if exceeded(quota.Vpcs, usage.Vpcs, req.Vpcs) {
+		errData = &errortypes.ErrorData{
+			Error:   "quota_vpcs_exceeded",
+			Message: "Organization VPC quota exceeded",
+		}
+		return
+	}
+
+	return
+}
@@ -0,0 +1,147 @@
+package router
+
+import (
+	"crypto/subtle"
+	"github.com/Sirupsen/logrus"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/link"
+	"github.com/pritunl/pritunl-cloud/node"
+	"github.com/pritunl/pritunl-cloud/prometheus"
+	"github.com/pritunl/pritunl-cloud/settings"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"gopkg.in/mgo.v2/bson"
+	"net/http"
+	"strings"
+)
+
+type instanceCount struct {
+	State string `bson:"_id"`
+	Count int    `bson:"count"`
+}
+
+func prometheusAuthorized(re *http.Request) bool {
+	token := settings.Router.PrometheusToken
+	if token == "" {
+		return false
+	}
+
+	auth := strings.SplitN(re.Header.Get("Authorization"), " ", 2)
+	if len(auth) != 2 || strings.ToLower(auth[0]) != "bearer" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(
+		[]byte(strings.TrimSpace(auth[1])), []byte(token)) == 1
+}
+
+func collectInstances(db *database.Database, name string,
+	query *bson.M) (err error) {
+
+	resp := []*instanceCount{}
+	err = db.Instances().Pipe([]*bson.M{
+		&bson.M{
+			"$match": query,
+		},
+		&bson.M{
+			"$group": &bson.M{
+				"_id": "$vm_state",
+				"count": &bson.M{
+					"$sum": 1,
+				},
+			},
+		},
+	}).All(&resp)
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	prometheus.Reset(name)
+	for _, item := range resp {
+		prometheus.Set(name, prometheus.Labels{
+			"state": item.State,
+		}, float64(item.Count))
+	}
+
+	return
+}
+
+func collectLinks() {
+	prometheus.Reset(prometheus.LinkConnections)
+
+	link.LinkStatusLock.Lock()
+	defer link.LinkStatusLock.Unlock()
+
+	for vpcId, status := range link.LinkStatus {
+		for _, conns := range status {
+			for _, connStatus := range conns {
+				labels := prometheus.Labels{
+					"vpc":    vpcId.Hex(),
+					"status": connStatus,
+				}
+				prometheus.Add(prometheus.LinkConnections, labels, 1)
+			}
+		}
+	}
+}
+
+func collectPrometheus() (err error) {
+	prometheus.Set(prometheus.NodeMemory, nil, node.Self.Memory)
+	prometheus.Set(prometheus.NodeLoad1, nil, node.Self.Load1)
+	prometheus.Set(prometheus.NodeLoad5, nil, node.Self.Load5)
+	prometheus.Set(prometheus.NodeLoad15, nil, node.Self.Load15)
+	prometheus.Set(prometheus.NodeCpuUnits, nil,
+		float64(node.Self.CpuUnits))
+	prometheus.Set(prometheus.NodeCpuUnitsRes, nil,
+		float64(node.Self.CpuUnitsRes))
+	prometheus.Set(prometheus.NodeMemoryUnits, nil,
+		node.Self.MemoryUnits)
+	prometheus.Set(prometheus.NodeMemoryUnitsRes, nil,
+		node.Self.MemoryUnitsRes)
+
+	db := database.GetDatabase()
+	defer db.Close()
+
+	if node.Self.IsHypervisor() {
+		err = collectInstances(db, prometheus.Instances, &bson.M{
+			"node": node.Self.Id,
+		})
+		if err != nil {
+			return
+		}
+
+		collectLinks()
+	}
+
+	if node.Self.IsAdmin() {
+		err = collectInstances(db, prometheus.ClusterInstances, &bson.M{})
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func servePrometheus(w http.ResponseWriter, re *http.Request) {
+	if !prometheusAuthorized(re) {
+		utils.WriteStatus(w, 401)
+		return
+	}
+
+	err := collectPrometheus()
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("router: Failed to collect prometheus metrics")
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	err = prometheus.Write(w)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("router: Failed to write prometheus metrics")
+	}
+}
@@ -59,6 +59,11 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, re *http.Request) {
 		return
 	}
 
+	if re.URL.Path == "/metrics" {
+		servePrometheus(w, re)
+		return
+	}
+
 	hst := utils.StripPort(re.Host)
 	if r.adminType && !r.userType {
 		r.aRouter.ServeHTTP(w, re)
@@ -443,6 +448,7 @@ func (r *Router) hashNode() []byte {
 	io.WriteString(hash, strconv.Itoa(settings.Router.ReadHeaderTimeout))
 	io.WriteString(hash, strconv.Itoa(settings.Router.WriteTimeout))
 	io.WriteString(hash, strconv.Itoa(settings.Router.IdleTimeout))
+	io.WriteString(hash, settings.Router.PrometheusToken)
 
 	certs := node.Self.CertificateObjs
 	if certs != nil {
@@ -475,7 +481,10 @@ func (r *Router) Run() (err error) {
 	go r.watchNode()
 
 	for {
-		if !node.Self.IsAdmin() && !node.Self.IsUser() {
+		if !node.Self.IsAdmin() && !node.Self.IsUser() &&
+			(!node.Self.IsHypervisor() ||
+				settings.Router.PrometheusToken == "") {
+
 			time.Sleep(500 * time.Millisecond)
 			continue
 		}
@@ -0,0 +1,29 @@
+package settings
+
+import (
+	"gopkg.in/mgo.v2/bson"
+)
+
+var Audit *audit
+
+type audit struct {
+	Id             string        `bson:"_id"`
+	ExportStorage  bson.ObjectId `bson:"export_storage,omitempty"`
+	ExportPrefix   string        `bson:"export_prefix" default:"audit/"`
+	SyslogAddress  string        `bson:"syslog_address"`
+	SyslogProtocol string        `bson:"syslog_protocol" default:"udp"`
+}
+
+func newAudit() interface{} {
+	return &audit{
+		Id: "audit",
+	}
+}
+
+func updateAudit(data interface{}) {
+	Audit = data.(*audit)
+}
+
+func init() {
+	register("audit", newAudit, updateAudit)
+}
@@ -0,0 +1,24 @@
+package settings
+
+var Image *image
+
+type image struct {
+	Id              string   `bson:"_id"`
+	SignaturePolicy string   `bson:"signature_policy" default:"none"`
+	TrustedKeys     []string `bson:"trusted_keys"`
+	FamilyPattern   string   `bson:"family_pattern" default:"^(?:.*/)?(?P<family>[a-zA-Z0-9.-]+)_(?P<version>[0-9][0-9.]*)\\.qcow2$"`
+}
+
+func newImage() interface{} {
+	return &image{
+		Id: "image",
+	}
+}
+
+func updateImage(data interface{}) {
+	Image = data.(*image)
+}
+
+func init() {
+	register("image", newImage, updateImage)
+}
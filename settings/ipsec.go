@@ -9,6 +9,12 @@ type ipsec struct {
 	DisableDisconnectedRestart bool   `bson:"disable_disconnected_restart"`
 	StateCacheTtl              int    `bson:"state_cache_ttl" default:"25"`
 	SkipVerify                 bool   `bson:"skip_verify"`
+	WgPort                     int    `bson:"wg_port" default:"51820"`
+	WgHandshakeTimeout         int    `bson:"wg_handshake_timeout" default:"180"`
+	CertLifetime               int    `bson:"cert_lifetime" default:"720"`
+	CertRenew                  int    `bson:"cert_renew" default:"168"`
+	CaLifetime                 int    `bson:"ca_lifetime" default:"87600"`
+	CaRenew                    int    `bson:"ca_renew" default:"8760"`
 }
 
 func newIpsec() interface{} {
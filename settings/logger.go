@@ -0,0 +1,34 @@
+package settings
+
+var Logger *logger
+
+type logger struct {
+	Id                string `bson:"_id"`
+	SyslogAddress     string `bson:"syslog_address"`
+	SyslogProtocol    string `bson:"syslog_protocol" default:"udp"`
+	SyslogFacility    int    `bson:"syslog_facility" default:"1"`
+	SyslogAppName     string `bson:"syslog_app_name" default:"pritunl-cloud"`
+	SyslogSkipVerify  bool   `bson:"syslog_skip_verify"`
+	Journald          bool   `bson:"journald"`
+	HttpUrl           string `bson:"http_url"`
+	HttpFormat        string `bson:"http_format" default:"json"`
+	HttpIndex         string `bson:"http_index" default:"pritunl-cloud"`
+	HttpAuthorization string `bson:"http_authorization"`
+	HttpBatchSize     int    `bson:"http_batch_size" default:"100"`
+	HttpBatchInterval int    `bson:"http_batch_interval" default:"5"`
+	HttpSkipVerify    bool   `bson:"http_skip_verify"`
+}
+
+func newLogger() interface{} {
+	return &logger{
+		Id: "logger",
+	}
+}
+
+func updateLogger(data interface{}) {
+	Logger = data.(*logger)
+}
+
+func init() {
+	register("logger", newLogger, updateLogger)
+}
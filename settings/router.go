@@ -16,6 +16,7 @@ type router struct {
 	HandshakeTimeout    int    `bson:"handshake_timeout" default:"10"`
 	ContinueTimeout     int    `bson:"continue_timeout" default:"10"`
 	SkipVerify          bool   `bson:"skip_verify"`
+	PrometheusToken     string `bson:"prometheus_token"`
 }
 
 func newRouter() interface{} {
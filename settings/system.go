@@ -18,6 +18,7 @@ type system struct {
 	AdminCookieCryptoKey []byte `bson:"admin_cookie_crypto_key"`
 	UserCookieAuthKey    []byte `bson:"user_cookie_auth_key"`
 	UserCookieCryptoKey  []byte `bson:"user_cookie_crypto_key"`
+	AuditChainKey        []byte `bson:"audit_chain_key"`
 	AcmeKeyAlgorithm     string `bson:"acme_key_algorithm" default:"rsa"`
 }
 
@@ -0,0 +1,504 @@
+package spec
+
+import (
+	"github.com/dropbox/godropbox/container/set"
+	"github.com/pritunl/pritunl-cloud/authority"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/disk"
+	"github.com/pritunl/pritunl-cloud/domain"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/event"
+	"github.com/pritunl/pritunl-cloud/firewall"
+	"github.com/pritunl/pritunl-cloud/instance"
+	"github.com/pritunl/pritunl-cloud/quota"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"github.com/pritunl/pritunl-cloud/vpc"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func (s *state) applyVpc(db *database.Database, orgId bson.ObjectId,
+	chng *Change) (errData *errortypes.ErrorData, err error) {
+
+	switch chng.Action {
+	case Create:
+		item := chng.item.(*VpcSpec)
+
+		vc := &vpc.Vpc{
+			Name:         item.Name,
+			Network:      item.Network,
+			Organization: orgId,
+			Datacenter:   item.Datacenter,
+			Routes:       item.Routes,
+		}
+
+		vc.GenerateVpcId()
+
+		errData, err = vc.Validate(db)
+		if err != nil || errData != nil {
+			return
+		}
+
+		errData, err = quota.Check(db, orgId, &quota.Usage{
+			Vpcs: 1,
+		})
+		if err != nil || errData != nil {
+			return
+		}
+
+		err = vc.Insert(db)
+		if err != nil {
+			return
+		}
+
+		chng.Id = vc.Id
+		s.vpcs[vc.Name] = vc
+		break
+	case Update:
+		item := chng.item.(*VpcSpec)
+		vc := s.vpcs[item.Name]
+
+		vc.Routes = item.Routes
+
+		errData, err = vc.Validate(db)
+		if err != nil || errData != nil {
+			return
+		}
+
+		err = vc.CommitFields(db, set.NewSet("routes"))
+		if err != nil {
+			return
+		}
+		break
+	case Delete:
+		err = vpc.Remove(db, chng.Id)
+		if err != nil {
+			return
+		}
+		break
+	}
+
+	return
+}
+
+func (s *state) applyFirewall(db *database.Database, orgId bson.ObjectId,
+	chng *Change) (errData *errortypes.ErrorData, err error) {
+
+	switch chng.Action {
+	case Create:
+		item := chng.item.(*FirewallSpec)
+
+		fire := &firewall.Firewall{
+			Name:         item.Name,
+			Organization: orgId,
+			NetworkRoles: item.NetworkRoles,
+			Ingress:      item.Ingress,
+		}
+
+		errData, err = fire.Validate(db)
+		if err != nil || errData != nil {
+			return
+		}
+
+		err = fire.Insert(db)
+		if err != nil {
+			return
+		}
+
+		chng.Id = fire.Id
+		break
+	case Update:
+		item := chng.item.(*FirewallSpec)
+		fire := s.fires[item.Name]
+
+		fire.NetworkRoles = item.NetworkRoles
+		fire.Ingress = item.Ingress
+
+		errData, err = fire.Validate(db)
+		if err != nil || errData != nil {
+			return
+		}
+
+		err = fire.CommitFields(db, set.NewSet("network_roles", "ingress"))
+		if err != nil {
+			return
+		}
+		break
+	case Delete:
+		err = firewall.Remove(db, chng.Id)
+		if err != nil {
+			return
+		}
+		break
+	}
+
+	return
+}
+
+func (s *state) applyAuthority(db *database.Database, orgId bson.ObjectId,
+	chng *Change) (errData *errortypes.ErrorData, err error) {
+
+	switch chng.Action {
+	case Create:
+		item := chng.item.(*AuthoritySpec)
+
+		authr := &authority.Authority{
+			Name:         item.Name,
+			Type:         item.Type,
+			Organization: orgId,
+			NetworkRoles: item.NetworkRoles,
+			Key:          item.Key,
+			Roles:        item.Roles,
+			Certificate:  item.Certificate,
+		}
+
+		errData, err = authr.Validate(db)
+		if err != nil || errData != nil {
+			return
+		}
+
+		err = authr.Insert(db)
+		if err != nil {
+			return
+		}
+
+		chng.Id = authr.Id
+		break
+	case Update:
+		item := chng.item.(*AuthoritySpec)
+		authr := s.authrs[item.Name]
+
+		authr.Type = item.Type
+		authr.NetworkRoles = item.NetworkRoles
+		authr.Key = item.Key
+		authr.Roles = item.Roles
+		authr.Certificate = item.Certificate
+
+		errData, err = authr.Validate(db)
+		if err != nil || errData != nil {
+			return
+		}
+
+		err = authr.CommitFields(db, set.NewSet(
+			"type",
+			"network_roles",
+			"key",
+			"roles",
+			"certificate",
+		))
+		if err != nil {
+			return
+		}
+		break
+	case Delete:
+		err = authority.Remove(db, chng.Id)
+		if err != nil {
+			return
+		}
+		break
+	}
+
+	return
+}
+
+func (s *state) applyDomain(db *database.Database, orgId bson.ObjectId,
+	chng *Change) (errData *errortypes.ErrorData, err error) {
+
+	switch chng.Action {
+	case Create:
+		item := chng.item.(*DomainSpec)
+
+		domn := &domain.Domain{
+			Name:         item.Name,
+			Organization: orgId,
+			Type:         item.Type,
+			AwsId:        item.AwsId,
+			AwsSecret:    item.AwsSecret,
+		}
+
+		errData, err = domn.Validate(db)
+		if err != nil || errData != nil {
+			return
+		}
+
+		err = domn.Insert(db)
+		if err != nil {
+			return
+		}
+
+		chng.Id = domn.Id
+		s.domns[domn.Name] = domn
+		break
+	case Update:
+		item := chng.item.(*DomainSpec)
+		domn := s.domns[item.Name]
+
+		domn.Type = item.Type
+		domn.AwsId = item.AwsId
+		if item.AwsSecret != "" {
+			domn.AwsSecret = item.AwsSecret
+		}
+
+		errData, err = domn.Validate(db)
+		if err != nil || errData != nil {
+			return
+		}
+
+		err = domn.CommitFields(db, set.NewSet(
+			"type",
+			"aws_id",
+			"aws_secret",
+		))
+		if err != nil {
+			return
+		}
+		break
+	case Delete:
+		err = domain.Remove(db, chng.Id)
+		if err != nil {
+			return
+		}
+		break
+	}
+
+	return
+}
+
+func (s *state) applyInstance(db *database.Database, orgId bson.ObjectId,
+	chng *Change) (errData *errortypes.ErrorData, err error) {
+
+	switch chng.Action {
+	case Create:
+		item := chng.item.(*InstanceSpec)
+
+		inst := &instance.Instance{
+			State:        item.State,
+			Organization: orgId,
+			Zone:         item.Zone,
+			Vpc:          s.vpcs[item.Vpc].Id,
+			Node:         item.Node,
+			Image:        item.Image,
+			Name:         item.Name,
+			InitDiskSize: item.InitDiskSize,
+			Memory:       item.Memory,
+			Processors:   item.Processors,
+			NetworkRoles: item.NetworkRoles,
+			StaticIp:     item.StaticIp,
+			UserData:     item.UserData,
+		}
+		if item.Domain != "" {
+			inst.Domain = s.domns[item.Domain].Id
+		}
+
+		errData, err = inst.Validate(db)
+		if err != nil || errData != nil {
+			return
+		}
+
+		errData, err = quota.Check(db, orgId, &quota.Usage{
+			Instances:  1,
+			Processors: inst.Processors,
+			Memory:     inst.Memory,
+			DiskSize:   utils.Max(inst.InitDiskSize, 10),
+		})
+		if err != nil || errData != nil {
+			return
+		}
+
+		err = inst.Insert(db)
+		if err != nil {
+			return
+		}
+
+		chng.Id = inst.Id
+		s.insts[inst.Name] = inst
+		break
+	case Update:
+		item := chng.item.(*InstanceSpec)
+		inst := s.insts[item.Name]
+
+		inst.PreCommit()
+
+		curProcessors := inst.Processors
+		curMemory := inst.Memory
+
+		inst.Vpc = s.vpcs[item.Vpc].Id
+		inst.Domain = ""
+		if item.Domain != "" {
+			inst.Domain = s.domns[item.Domain].Id
+		}
+		inst.State = item.State
+		inst.Memory = item.Memory
+		inst.Processors = item.Processors
+		inst.NetworkRoles = item.NetworkRoles
+		inst.StaticIp = item.StaticIp
+		inst.UserData = item.UserData
+
+		errData, err = inst.Validate(db)
+		if err != nil || errData != nil {
+			return
+		}
+
+		errData, err = quota.Check(db, orgId, &quota.Usage{
+			Processors: inst.Processors - curProcessors,
+			Memory:     inst.Memory - curMemory,
+		})
+		if err != nil || errData != nil {
+			return
+		}
+
+		err = inst.PostCommit(db)
+		if err != nil {
+			return
+		}
+
+		err = inst.CommitFields(db, set.NewSet(
+			"vpc",
+			"domain",
+			"state",
+			"restart",
+			"memory",
+			"processors",
+			"network_roles",
+			"static_ip",
+			"user_data",
+		))
+		if err != nil {
+			return
+		}
+		break
+	case Delete:
+		err = instance.Delete(db, chng.Id)
+		if err != nil {
+			return
+		}
+		break
+	}
+
+	return
+}
+
+func (s *state) applyDisk(db *database.Database, orgId bson.ObjectId,
+	chng *Change) (errData *errortypes.ErrorData, err error) {
+
+	switch chng.Action {
+	case Create:
+		item := chng.item.(*DiskSpec)
+
+		dsk := &disk.Disk{
+			Name:         item.Name,
+			Organization: orgId,
+			Index:        item.Index,
+			Node:         item.Node,
+			Image:        item.Image,
+			Size:         item.Size,
+		}
+		if item.Instance != "" {
+			dsk.Instance = s.insts[item.Instance].Id
+		}
+
+		errData, err = dsk.Validate(db)
+		if err != nil || errData != nil {
+			return
+		}
+
+		errData, err = quota.Check(db, orgId, &quota.Usage{
+			DiskSize: dsk.Size,
+		})
+		if err != nil || errData != nil {
+			return
+		}
+
+		err = dsk.Insert(db)
+		if err != nil {
+			return
+		}
+		break
+	case Update:
+		item := chng.item.(*DiskSpec)
+		dsk := s.dsks[item.Name]
+
+		dsk.Instance = ""
+		if item.Instance != "" {
+			dsk.Instance = s.insts[item.Instance].Id
+		}
+		dsk.Index = item.Index
+
+		errData, err = dsk.Validate(db)
+		if err != nil || errData != nil {
+			return
+		}
+
+		err = dsk.CommitFields(db, set.NewSet("instance", "index"))
+		if err != nil {
+			return
+		}
+		break
+	case Delete:
+		err = disk.Delete(db, chng.Id)
+		if err != nil {
+			return
+		}
+		break
+	}
+
+	return
+}
+
+// Apply changes in dependency order, changes applied before an error are
+// not reverted
+func Apply(db *database.Database, orgId bson.ObjectId, spc *Spec,
+	opts *Options) (changes []*Change, errData *errortypes.ErrorData,
+	err error) {
+
+	st, err := loadState(db, orgId)
+	if err != nil {
+		return
+	}
+
+	errData, err = st.validate(db, orgId, spc, opts)
+	if err != nil || errData != nil {
+		return
+	}
+
+	changes, errData = st.plan(spc, opts)
+	if errData != nil {
+		return
+	}
+
+	resources := set.NewSet()
+	defer func() {
+		for resource := range resources.Iter() {
+			event.PublishDispatch(db, resource.(string)+".change")
+		}
+	}()
+
+	for _, chng := range changes {
+		switch chng.Resource {
+		case Vpc:
+			errData, err = st.applyVpc(db, orgId, chng)
+			break
+		case Firewall:
+			errData, err = st.applyFirewall(db, orgId, chng)
+			break
+		case Authority:
+			errData, err = st.applyAuthority(db, orgId, chng)
+			break
+		case Domain:
+			errData, err = st.applyDomain(db, orgId, chng)
+			break
+		case Instance:
+			errData, err = st.applyInstance(db, orgId, chng)
+			break
+		case Disk:
+			errData, err = st.applyDisk(db, orgId, chng)
+			break
+		}
+		if err != nil || errData != nil {
+			return
+		}
+
+		resources.Add(chng.Resource)
+	}
+
+	return
+}
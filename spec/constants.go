@@ -0,0 +1,14 @@
+package spec
+
+const (
+	Create = "create"
+	Update = "update"
+	Delete = "delete"
+
+	Vpc       = "vpc"
+	Firewall  = "firewall"
+	Authority = "authority"
+	Domain    = "domain"
+	Instance  = "instance"
+	Disk      = "disk"
+)
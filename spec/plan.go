@@ -0,0 +1,591 @@
+package spec
+
+import (
+	"fmt"
+	"github.com/dropbox/godropbox/container/set"
+	"github.com/pritunl/pritunl-cloud/audit"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/datacenter"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/image"
+	"github.com/pritunl/pritunl-cloud/node"
+	"github.com/pritunl/pritunl-cloud/zone"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type Options struct {
+	Prune bool
+	Admin bool
+}
+
+type Change struct {
+	Resource string        `json:"resource"`
+	Action   string        `json:"action"`
+	Name     string        `json:"name"`
+	Id       bson.ObjectId `json:"id,omitempty"`
+	Fields   audit.Fields  `json:"fields,omitempty"`
+	item     interface{}
+}
+
+func diff(cur, item interface{}) (fields audit.Fields) {
+	orig := audit.Snapshot(cur)
+
+	keys := set.NewSet()
+	for key := range orig {
+		keys.Add(key)
+	}
+	for key := range audit.Snapshot(item) {
+		keys.Add(key)
+	}
+
+	fields = audit.Diff(orig, item, keys)
+
+	return
+}
+
+func immutable(resource, name string, fields audit.Fields,
+	keys ...string) (errData *errortypes.ErrorData) {
+
+	for _, key := range keys {
+		if _, ok := fields[key]; ok {
+			errData = &errortypes.ErrorData{
+				Error: fmt.Sprintf("%s_immutable", resource),
+				Message: fmt.Sprintf(
+					"Cannot change %s of %s '%s'", key, resource, name),
+			}
+			return
+		}
+	}
+
+	return
+}
+
+func duplicate(resource string, names set.Set, name string) (
+	errData *errortypes.ErrorData) {
+
+	if name == "" {
+		errData = &errortypes.ErrorData{
+			Error:   fmt.Sprintf("%s_name_required", resource),
+			Message: fmt.Sprintf("Missing required %s name", resource),
+		}
+		return
+	}
+
+	if names.Contains(name) {
+		errData = &errortypes.ErrorData{
+			Error: fmt.Sprintf("%s_name_duplicate", resource),
+			Message: fmt.Sprintf(
+				"Duplicate %s name '%s' in spec", resource, name),
+		}
+		return
+	}
+	names.Add(name)
+
+	return
+}
+
+func missing(resource, name, ref string) *errortypes.ErrorData {
+	return &errortypes.ErrorData{
+		Error: fmt.Sprintf("%s_reference_invalid", resource),
+		Message: fmt.Sprintf(
+			"Unknown %s '%s' referenced by '%s'", resource, ref, name),
+	}
+}
+
+func forbidden(resource, name string) *errortypes.ErrorData {
+	return &errortypes.ErrorData{
+		Error: fmt.Sprintf("%s_forbidden", resource),
+		Message: fmt.Sprintf(
+			"Resource referenced by %s '%s' not available", resource, name),
+	}
+}
+
+func checkNode(db *database.Database, orgId, nodeId bson.ObjectId,
+	zoneId bson.ObjectId, opts *Options) (allowed bool, err error) {
+
+	nde, err := node.Get(db, nodeId)
+	if err != nil {
+		return
+	}
+
+	if zoneId != "" && nde.Zone != zoneId {
+		return
+	}
+
+	zne, err := zone.Get(db, nde.Zone)
+	if err != nil {
+		return
+	}
+
+	if !opts.Admin {
+		allowed, err = datacenter.ExistsOrg(db, orgId, zne.Datacenter)
+		return
+	}
+
+	allowed = true
+
+	return
+}
+
+func checkImage(db *database.Database, orgId, imgId bson.ObjectId,
+	opts *Options) (allowed bool, err error) {
+
+	if opts.Admin || imgId == "" {
+		allowed = true
+		return
+	}
+
+	allowed, err = image.ExistsOrg(db, orgId, imgId)
+	return
+}
+
+func (s *state) validate(db *database.Database, orgId bson.ObjectId,
+	spc *Spec, opts *Options) (errData *errortypes.ErrorData, err error) {
+
+	vpcNames := set.NewSet()
+	for _, item := range spc.Vpcs {
+		errData = duplicate(Vpc, vpcNames, item.Name)
+		if errData != nil {
+			return
+		}
+
+		if !opts.Admin {
+			exists, e := datacenter.ExistsOrg(db, orgId, item.Datacenter)
+			if e != nil {
+				err = e
+				return
+			}
+			if !exists {
+				errData = forbidden(Vpc, item.Name)
+				return
+			}
+		}
+	}
+
+	names := set.NewSet()
+	for _, item := range spc.Firewalls {
+		errData = duplicate(Firewall, names, item.Name)
+		if errData != nil {
+			return
+		}
+	}
+
+	names = set.NewSet()
+	for _, item := range spc.Authorities {
+		errData = duplicate(Authority, names, item.Name)
+		if errData != nil {
+			return
+		}
+	}
+
+	if !opts.Admin && len(spc.Domains) > 0 {
+		errData = &errortypes.ErrorData{
+			Error:   "domain_forbidden",
+			Message: "Domains can only be managed by administrators",
+		}
+		return
+	}
+
+	domnNames := set.NewSet()
+	for _, item := range spc.Domains {
+		errData = duplicate(Domain, domnNames, item.Name)
+		if errData != nil {
+			return
+		}
+	}
+
+	instNames := set.NewSet()
+	for _, item := range spc.Instances {
+		errData = duplicate(Instance, instNames, item.Name)
+		if errData != nil {
+			return
+		}
+
+		if !vpcNames.Contains(item.Vpc) {
+			if _, ok := s.vpcs[item.Vpc]; !ok || opts.Prune {
+				errData = missing(Vpc, item.Name, item.Vpc)
+				return
+			}
+		}
+
+		if item.Domain != "" && !domnNames.Contains(item.Domain) {
+			if _, ok := s.domns[item.Domain]; !ok ||
+				(opts.Prune && opts.Admin) {
+
+				errData = missing(Domain, item.Name, item.Domain)
+				return
+			}
+		}
+
+		allowed, e := checkNode(db, orgId, item.Node, item.Zone, opts)
+		if e != nil {
+			err = e
+			return
+		}
+		if allowed {
+			allowed, err = checkImage(db, orgId, item.Image, opts)
+			if err != nil {
+				return
+			}
+		}
+		if !allowed {
+			errData = forbidden(Instance, item.Name)
+			return
+		}
+	}
+
+	names = set.NewSet()
+	for _, item := range spc.Disks {
+		errData = duplicate(Disk, names, item.Name)
+		if errData != nil {
+			return
+		}
+
+		if item.Instance != "" && !instNames.Contains(item.Instance) {
+			if _, ok := s.insts[item.Instance]; !ok || opts.Prune {
+				errData = missing(Instance, item.Name, item.Instance)
+				return
+			}
+		}
+
+		allowed, e := checkNode(db, orgId, item.Node, "", opts)
+		if e != nil {
+			err = e
+			return
+		}
+		if allowed {
+			allowed, err = checkImage(db, orgId, item.Image, opts)
+			if err != nil {
+				return
+			}
+		}
+		if !allowed {
+			errData = forbidden(Disk, item.Name)
+			return
+		}
+	}
+
+	return
+}
+
+func (s *state) plan(spc *Spec, opts *Options) (
+	changes []*Change, errData *errortypes.ErrorData) {
+
+	changes = []*Change{}
+
+	for _, item := range spc.Vpcs {
+		cur := s.vpcs[item.Name]
+		if cur == nil {
+			changes = append(changes, &Change{
+				Resource: Vpc,
+				Action:   Create,
+				Name:     item.Name,
+				item:     item,
+			})
+			continue
+		}
+
+		fields := diff(s.vpcSpec(cur), item)
+		if len(fields) == 0 {
+			continue
+		}
+
+		errData = immutable(Vpc, item.Name, fields, "datacenter", "network")
+		if errData != nil {
+			return
+		}
+
+		changes = append(changes, &Change{
+			Resource: Vpc,
+			Action:   Update,
+			Name:     item.Name,
+			Id:       cur.Id,
+			Fields:   fields,
+			item:     item,
+		})
+	}
+
+	for _, item := range spc.Firewalls {
+		cur := s.fires[item.Name]
+		if cur == nil {
+			changes = append(changes, &Change{
+				Resource: Firewall,
+				Action:   Create,
+				Name:     item.Name,
+				item:     item,
+			})
+			continue
+		}
+
+		fields := diff(s.firewallSpec(cur), item)
+		if len(fields) == 0 {
+			continue
+		}
+
+		changes = append(changes, &Change{
+			Resource: Firewall,
+			Action:   Update,
+			Name:     item.Name,
+			Id:       cur.Id,
+			Fields:   fields,
+			item:     item,
+		})
+	}
+
+	for _, item := range spc.Authorities {
+		cur := s.authrs[item.Name]
+		if cur == nil {
+			changes = append(changes, &Change{
+				Resource: Authority,
+				Action:   Create,
+				Name:     item.Name,
+				item:     item,
+			})
+			continue
+		}
+
+		fields := diff(s.authoritySpec(cur), item)
+		if len(fields) == 0 {
+			continue
+		}
+
+		changes = append(changes, &Change{
+			Resource: Authority,
+			Action:   Update,
+			Name:     item.Name,
+			Id:       cur.Id,
+			Fields:   fields,
+			item:     item,
+		})
+	}
+
+	for _, item := range spc.Domains {
+		cur := s.domns[item.Name]
+		if cur == nil {
+			changes = append(changes, &Change{
+				Resource: Domain,
+				Action:   Create,
+				Name:     item.Name,
+				item:     item,
+			})
+			continue
+		}
+
+		// Secrets are not exported, an empty secret keeps the current value
+		fields := diff(s.domainSpec(cur), item)
+		if len(fields) == 0 {
+			continue
+		}
+
+		changes = append(changes, &Change{
+			Resource: Domain,
+			Action:   Update,
+			Name:     item.Name,
+			Id:       cur.Id,
+			Fields:   fields,
+			item:     item,
+		})
+	}
+
+	for _, item := range spc.Instances {
+		cur := s.insts[item.Name]
+		if cur == nil {
+			changes = append(changes, &Change{
+				Resource: Instance,
+				Action:   Create,
+				Name:     item.Name,
+				item:     item,
+			})
+			continue
+		}
+
+		if item.State == "" {
+			item.State = cur.State
+		}
+
+		fields := diff(s.instanceSpec(cur), item)
+		if len(fields) == 0 {
+			continue
+		}
+
+		errData = immutable(Instance, item.Name, fields,
+			"zone", "node", "image", "init_disk_size")
+		if errData != nil {
+			return
+		}
+
+		changes = append(changes, &Change{
+			Resource: Instance,
+			Action:   Update,
+			Name:     item.Name,
+			Id:       cur.Id,
+			Fields:   fields,
+			item:     item,
+		})
+	}
+
+	for _, item := range spc.Disks {
+		cur := s.dsks[item.Name]
+		if cur == nil {
+			changes = append(changes, &Change{
+				Resource: Disk,
+				Action:   Create,
+				Name:     item.Name,
+				item:     item,
+			})
+			continue
+		}
+
+		fields := diff(s.diskSpec(cur), item)
+		if len(fields) == 0 {
+			continue
+		}
+
+		errData = immutable(Disk, item.Name, fields, "node", "image", "size")
+		if errData != nil {
+			return
+		}
+
+		changes = append(changes, &Change{
+			Resource: Disk,
+			Action:   Update,
+			Name:     item.Name,
+			Id:       cur.Id,
+			Fields:   fields,
+			item:     item,
+		})
+	}
+
+	if !opts.Prune {
+		return
+	}
+
+	names := set.NewSet()
+	for _, item := range spc.Disks {
+		names.Add(item.Name)
+	}
+	for name, cur := range s.dsks {
+		if !names.Contains(name) {
+			changes = append(changes, &Change{
+				Resource: Disk,
+				Action:   Delete,
+				Name:     name,
+				Id:       cur.Id,
+			})
+		}
+	}
+
+	names = set.NewSet()
+	for _, item := range spc.Instances {
+		names.Add(item.Name)
+	}
+	for name, cur := range s.insts {
+		if !names.Contains(name) {
+			changes = append(changes, &Change{
+				Resource: Instance,
+				Action:   Delete,
+				Name:     name,
+				Id:       cur.Id,
+			})
+		}
+	}
+
+	if opts.Admin {
+		names = set.NewSet()
+		for _, item := range spc.Domains {
+			names.Add(item.Name)
+		}
+		for name, cur := range s.domns {
+			if !names.Contains(name) {
+				changes = append(changes, &Change{
+					Resource: Domain,
+					Action:   Delete,
+					Name:     name,
+					Id:       cur.Id,
+				})
+			}
+		}
+	}
+
+	names = set.NewSet()
+	for _, item := range spc.Authorities {
+		names.Add(item.Name)
+	}
+	for name, cur := range s.authrs {
+		if !names.Contains(name) {
+			changes = append(changes, &Change{
+				Resource: Authority,
+				Action:   Delete,
+				Name:     name,
+				Id:       cur.Id,
+			})
+		}
+	}
+
+	names = set.NewSet()
+	for _, item := range spc.Firewalls {
+		names.Add(item.Name)
+	}
+	for name, cur := range s.fires {
+		if !names.Contains(name) {
+			changes = append(changes, &Change{
+				Resource: Firewall,
+				Action:   Delete,
+				Name:     name,
+				Id:       cur.Id,
+			})
+		}
+	}
+
+	names = set.NewSet()
+	for _, item := range spc.Vpcs {
+		names.Add(item.Name)
+	}
+	for name, cur := range s.vpcs {
+		if !names.Contains(name) {
+			changes = append(changes, &Change{
+				Resource: Vpc,
+				Action:   Delete,
+				Name:     name,
+				Id:       cur.Id,
+			})
+		}
+	}
+
+	return
+}
+
+func Plan(db *database.Database, orgId bson.ObjectId, spc *Spec,
+	opts *Options) (changes []*Change, errData *errortypes.ErrorData,
+	err error) {
+
+	st, err := loadState(db, orgId)
+	if err != nil {
+		return
+	}
+
+	errData, err = st.validate(db, orgId, spc, opts)
+	if err != nil || errData != nil {
+		return
+	}
+
+	changes, errData = st.plan(spc, opts)
+	if errData != nil {
+		return
+	}
+
+	return
+}
+
+func Export(db *database.Database, orgId bson.ObjectId, opts *Options) (
+	spc *Spec, err error) {
+
+	st, err := loadState(db, orgId)
+	if err != nil {
+		return
+	}
+
+	spc = st.export(opts.Admin)
+
+	return
+}
@@ -0,0 +1,214 @@
+package spec
+
+import (
+	"github.com/dropbox/godropbox/errors"
+	"github.com/ghodss/yaml"
+	"github.com/pritunl/pritunl-cloud/authority"
+	"github.com/pritunl/pritunl-cloud/domain"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/firewall"
+	"github.com/pritunl/pritunl-cloud/vpc"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type VpcSpec struct {
+	Name       string        `bson:"name" json:"name"`
+	Datacenter bson.ObjectId `bson:"datacenter" json:"datacenter"`
+	Network    string        `bson:"network" json:"network"`
+	Routes     []*vpc.Route  `bson:"routes" json:"routes"`
+}
+
+func (v *VpcSpec) normalize() {
+	if v.Routes == nil {
+		v.Routes = []*vpc.Route{}
+	}
+}
+
+type FirewallSpec struct {
+	Name         string           `bson:"name" json:"name"`
+	NetworkRoles []string         `bson:"network_roles" json:"network_roles"`
+	Ingress      []*firewall.Rule `bson:"ingress" json:"ingress"`
+}
+
+func (f *FirewallSpec) normalize() {
+	if f.NetworkRoles == nil {
+		f.NetworkRoles = []string{}
+	}
+	if f.Ingress == nil {
+		f.Ingress = []*firewall.Rule{}
+	}
+}
+
+type AuthoritySpec struct {
+	Name         string   `bson:"name" json:"name"`
+	Type         string   `bson:"type" json:"type"`
+	NetworkRoles []string `bson:"network_roles" json:"network_roles"`
+	Key          string   `bson:"key" json:"key,omitempty"`
+	Roles        []string `bson:"roles" json:"roles"`
+	Certificate  string   `bson:"certificate" json:"certificate,omitempty"`
+}
+
+func (a *AuthoritySpec) normalize() {
+	if a.NetworkRoles == nil {
+		a.NetworkRoles = []string{}
+	}
+	if a.Roles == nil {
+		a.Roles = []string{}
+	}
+	if a.Type == "" {
+		a.Type = authority.SshKey
+	}
+
+	switch a.Type {
+	case authority.SshKey:
+		a.Roles = []string{}
+		a.Certificate = ""
+		break
+	case authority.SshCertificate:
+		a.Key = ""
+		break
+	}
+}
+
+type DomainSpec struct {
+	Name      string `bson:"name" json:"name"`
+	Type      string `bson:"type" json:"type"`
+	AwsId     string `bson:"aws_id" json:"aws_id"`
+	AwsSecret string `bson:"aws_secret" json:"aws_secret,omitempty"`
+}
+
+func (d *DomainSpec) normalize() {
+	if d.Type == "" {
+		d.Type = domain.Route53
+	}
+}
+
+type InstanceSpec struct {
+	Name         string        `bson:"name" json:"name"`
+	Zone         bson.ObjectId `bson:"zone" json:"zone"`
+	Node         bson.ObjectId `bson:"node" json:"node"`
+	Image        bson.ObjectId `bson:"image" json:"image"`
+	Vpc          string        `bson:"vpc" json:"vpc"`
+	Domain       string        `bson:"domain" json:"domain,omitempty"`
+	State        string        `bson:"state" json:"state"`
+	StaticIp     string        `bson:"static_ip" json:"static_ip,omitempty"`
+	InitDiskSize int           `bson:"init_disk_size" json:"init_disk_size"`
+	Memory       int           `bson:"memory" json:"memory"`
+	Processors   int           `bson:"processors" json:"processors"`
+	NetworkRoles []string      `bson:"network_roles" json:"network_roles"`
+	UserData     string        `bson:"user_data" json:"user_data,omitempty"`
+}
+
+func (i *InstanceSpec) normalize() {
+	if i.Memory < 256 {
+		i.Memory = 256
+	}
+	if i.Processors < 1 {
+		i.Processors = 1
+	}
+	if i.NetworkRoles == nil {
+		i.NetworkRoles = []string{}
+	}
+}
+
+type DiskSpec struct {
+	Name     string        `bson:"name" json:"name"`
+	Node     bson.ObjectId `bson:"node" json:"node"`
+	Image    bson.ObjectId `bson:"image,omitempty" json:"image,omitempty"`
+	Instance string        `bson:"instance" json:"instance,omitempty"`
+	Index    string        `bson:"index" json:"index,omitempty"`
+	Size     int           `bson:"size" json:"size"`
+}
+
+func (d *DiskSpec) normalize() {
+	if d.Size < 10 {
+		d.Size = 10
+	}
+	if d.Instance == "" {
+		d.Index = ""
+	}
+}
+
+type Spec struct {
+	Vpcs        []*VpcSpec       `json:"vpcs"`
+	Firewalls   []*FirewallSpec  `json:"firewalls"`
+	Authorities []*AuthoritySpec `json:"authorities"`
+	Domains     []*DomainSpec    `json:"domains,omitempty"`
+	Instances   []*InstanceSpec  `json:"instances"`
+	Disks       []*DiskSpec      `json:"disks"`
+}
+
+func (s *Spec) normalize() {
+	if s.Vpcs == nil {
+		s.Vpcs = []*VpcSpec{}
+	}
+	for _, item := range s.Vpcs {
+		item.normalize()
+	}
+
+	if s.Firewalls == nil {
+		s.Firewalls = []*FirewallSpec{}
+	}
+	for _, item := range s.Firewalls {
+		item.normalize()
+	}
+
+	if s.Authorities == nil {
+		s.Authorities = []*AuthoritySpec{}
+	}
+	for _, item := range s.Authorities {
+		item.normalize()
+	}
+
+	if s.Domains == nil {
+		s.Domains = []*DomainSpec{}
+	}
+	for _, item := range s.Domains {
+		item.normalize()
+	}
+
+	if s.Instances == nil {
+		s.Instances = []*InstanceSpec{}
+	}
+	for _, item := range s.Instances {
+		item.normalize()
+	}
+
+	if s.Disks == nil {
+		s.Disks = []*DiskSpec{}
+	}
+	for _, item := range s.Disks {
+		item.normalize()
+	}
+}
+
+func (s *Spec) Marshal() (data []byte, err error) {
+	data, err = yaml.Marshal(s)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "spec: Failed to marshal spec"),
+		}
+		return
+	}
+
+	return
+}
+
+// Parse YAML or JSON spec
+func Parse(data []byte) (spc *Spec, errData *errortypes.ErrorData) {
+	spc = &Spec{}
+
+	err := yaml.Unmarshal(data, spc)
+	if err != nil {
+		spc = nil
+		errData = &errortypes.ErrorData{
+			Error:   "spec_invalid",
+			Message: "Failed to parse spec: " + err.Error(),
+		}
+		return
+	}
+
+	spc.normalize()
+
+	return
+}
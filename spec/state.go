@@ -0,0 +1,273 @@
+package spec
+
+import (
+	"github.com/dropbox/godropbox/container/set"
+	"github.com/pritunl/pritunl-cloud/authority"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/disk"
+	"github.com/pritunl/pritunl-cloud/domain"
+	"github.com/pritunl/pritunl-cloud/firewall"
+	"github.com/pritunl/pritunl-cloud/instance"
+	"github.com/pritunl/pritunl-cloud/vpc"
+	"gopkg.in/mgo.v2/bson"
+	"sort"
+)
+
+// Current organization resources keyed by name, when names are duplicated
+// only the first resource is managed
+type state struct {
+	vpcs      map[string]*vpc.Vpc
+	fires     map[string]*firewall.Firewall
+	authrs    map[string]*authority.Authority
+	domns     map[string]*domain.Domain
+	insts     map[string]*instance.Instance
+	dsks      map[string]*disk.Disk
+	vpcNames  map[bson.ObjectId]string
+	domnNames map[bson.ObjectId]string
+	instNames map[bson.ObjectId]string
+}
+
+func loadState(db *database.Database, orgId bson.ObjectId) (
+	st *state, err error) {
+
+	st = &state{
+		vpcs:      map[string]*vpc.Vpc{},
+		fires:     map[string]*firewall.Firewall{},
+		authrs:    map[string]*authority.Authority{},
+		domns:     map[string]*domain.Domain{},
+		insts:     map[string]*instance.Instance{},
+		dsks:      map[string]*disk.Disk{},
+		vpcNames:  map[bson.ObjectId]string{},
+		domnNames: map[bson.ObjectId]string{},
+		instNames: map[bson.ObjectId]string{},
+	}
+
+	query := &bson.M{
+		"organization": orgId,
+	}
+
+	vcs, err := vpc.GetAll(db, query)
+	if err != nil {
+		return
+	}
+	for _, vc := range vcs {
+		st.vpcNames[vc.Id] = vc.Name
+		if _, ok := st.vpcs[vc.Name]; !ok {
+			st.vpcs[vc.Name] = vc
+		}
+	}
+
+	fires, err := firewall.GetAll(db, query)
+	if err != nil {
+		return
+	}
+	for _, fire := range fires {
+		if _, ok := st.fires[fire.Name]; !ok {
+			st.fires[fire.Name] = fire
+		}
+	}
+
+	authrs, err := authority.GetAll(db, query)
+	if err != nil {
+		return
+	}
+	for _, authr := range authrs {
+		if _, ok := st.authrs[authr.Name]; !ok {
+			st.authrs[authr.Name] = authr
+		}
+	}
+
+	domns, err := domain.GetAll(db, query)
+	if err != nil {
+		return
+	}
+	for _, domn := range domns {
+		st.domnNames[domn.Id] = domn.Name
+		if _, ok := st.domns[domn.Name]; !ok {
+			st.domns[domn.Name] = domn
+		}
+	}
+
+	// Group instances are managed by the group reconciler
+	insts, err := instance.GetAll(db, &bson.M{
+		"organization": orgId,
+		"state": &bson.M{
+			"$ne": instance.Destroy,
+		},
+	})
+	if err != nil {
+		return
+	}
+	groupInsts := set.NewSet()
+	for _, inst := range insts {
+		if inst.Group != "" {
+			groupInsts.Add(inst.Id)
+			continue
+		}
+
+		st.instNames[inst.Id] = inst.Name
+		if _, ok := st.insts[inst.Name]; !ok {
+			st.insts[inst.Name] = inst
+		}
+	}
+
+	// Root disks are managed by the instance
+	dsks, err := disk.GetAll(db, &bson.M{
+		"organization": orgId,
+		"state": &bson.M{
+			"$ne": disk.Destroy,
+		},
+		"index": &bson.M{
+			"$ne": "0",
+		},
+	})
+	if err != nil {
+		return
+	}
+	for _, dsk := range dsks {
+		if dsk.Instance != "" && groupInsts.Contains(dsk.Instance) {
+			continue
+		}
+
+		if _, ok := st.dsks[dsk.Name]; !ok {
+			st.dsks[dsk.Name] = dsk
+		}
+	}
+
+	return
+}
+
+func (s *state) vpcSpec(vc *vpc.Vpc) *VpcSpec {
+	return &VpcSpec{
+		Name:       vc.Name,
+		Datacenter: vc.Datacenter,
+		Network:    vc.Network,
+		Routes:     vc.Routes,
+	}
+}
+
+func (s *state) firewallSpec(fire *firewall.Firewall) *FirewallSpec {
+	return &FirewallSpec{
+		Name:         fire.Name,
+		NetworkRoles: fire.NetworkRoles,
+		Ingress:      fire.Ingress,
+	}
+}
+
+func (s *state) authoritySpec(authr *authority.Authority) *AuthoritySpec {
+	return &AuthoritySpec{
+		Name:         authr.Name,
+		Type:         authr.Type,
+		NetworkRoles: authr.NetworkRoles,
+		Key:          authr.Key,
+		Roles:        authr.Roles,
+		Certificate:  authr.Certificate,
+	}
+}
+
+func (s *state) domainSpec(domn *domain.Domain) *DomainSpec {
+	return &DomainSpec{
+		Name:  domn.Name,
+		Type:  domn.Type,
+		AwsId: domn.AwsId,
+	}
+}
+
+func (s *state) instanceSpec(inst *instance.Instance) *InstanceSpec {
+	return &InstanceSpec{
+		Name:         inst.Name,
+		Zone:         inst.Zone,
+		Node:         inst.Node,
+		Image:        inst.Image,
+		Vpc:          s.vpcNames[inst.Vpc],
+		Domain:       s.domnNames[inst.Domain],
+		State:        inst.State,
+		StaticIp:     inst.StaticIp,
+		InitDiskSize: inst.InitDiskSize,
+		Memory:       inst.Memory,
+		Processors:   inst.Processors,
+		NetworkRoles: inst.NetworkRoles,
+		UserData:     inst.UserData,
+	}
+}
+
+func (s *state) diskSpec(dsk *disk.Disk) *DiskSpec {
+	dskSpec := &DiskSpec{
+		Name:  dsk.Name,
+		Node:  dsk.Node,
+		Image: dsk.Image,
+		Size:  dsk.Size,
+	}
+
+	if dsk.Instance != "" {
+		dskSpec.Instance = s.instNames[dsk.Instance]
+		dskSpec.Index = dsk.Index
+	}
+
+	return dskSpec
+}
+
+func sortNames(names []string) []string {
+	sort.Strings(names)
+	return names
+}
+
+func (s *state) export(domains bool) (spc *Spec) {
+	spc = &Spec{}
+
+	names := []string{}
+	for name := range s.vpcs {
+		names = append(names, name)
+	}
+	for _, name := range sortNames(names) {
+		spc.Vpcs = append(spc.Vpcs, s.vpcSpec(s.vpcs[name]))
+	}
+
+	names = []string{}
+	for name := range s.fires {
+		names = append(names, name)
+	}
+	for _, name := range sortNames(names) {
+		spc.Firewalls = append(spc.Firewalls, s.firewallSpec(s.fires[name]))
+	}
+
+	names = []string{}
+	for name := range s.authrs {
+		names = append(names, name)
+	}
+	for _, name := range sortNames(names) {
+		spc.Authorities = append(spc.Authorities,
+			s.authoritySpec(s.authrs[name]))
+	}
+
+	if domains {
+		names = []string{}
+		for name := range s.domns {
+			names = append(names, name)
+		}
+		for _, name := range sortNames(names) {
+			spc.Domains = append(spc.Domains, s.domainSpec(s.domns[name]))
+		}
+	}
+
+	names = []string{}
+	for name := range s.insts {
+		names = append(names, name)
+	}
+	for _, name := range sortNames(names) {
+		spc.Instances = append(spc.Instances,
+			s.instanceSpec(s.insts[name]))
+	}
+
+	names = []string{}
+	for name := range s.dsks {
+		names = append(names, name)
+	}
+	for _, name := range sortNames(names) {
+		spc.Disks = append(spc.Disks, s.diskSpec(s.dsks[name]))
+	}
+
+	spc.normalize()
+
+	return
+}
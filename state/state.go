@@ -68,6 +68,16 @@ func (s *State) DiskInUse(instId, dskId bson.ObjectId) bool {
 	return false
 }
 
+func (s *State) DisksFlattening(instId bson.ObjectId) bool {
+	for _, dsk := range s.disks {
+		if dsk.Instance == instId && dsk.State == disk.Flatten {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (s *State) GetVirt(instId bson.ObjectId) *vm.VirtualMachine {
 	return s.virtsMap[instId]
 }
@@ -0,0 +1,19 @@
+package sync
+
+import (
+	"github.com/pritunl/pritunl-cloud/metric"
+	"time"
+)
+
+func metricRunner() {
+	time.Sleep(5 * time.Second)
+
+	for {
+		metric.Collect()
+		time.Sleep(60 * time.Second)
+	}
+}
+
+func initMetric() {
+	go metricRunner()
+}
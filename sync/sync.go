@@ -6,4 +6,6 @@ func Init() {
 	initVm()
 	initIpsec()
 	initLink()
+	initMetric()
+	initWebhook()
 }
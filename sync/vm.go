@@ -9,6 +9,7 @@ import (
 	"github.com/pritunl/pritunl-cloud/instance"
 	"github.com/pritunl/pritunl-cloud/iptables"
 	"github.com/pritunl/pritunl-cloud/node"
+	"github.com/pritunl/pritunl-cloud/prometheus"
 	"github.com/pritunl/pritunl-cloud/settings"
 	"github.com/pritunl/pritunl-cloud/state"
 	"time"
@@ -96,13 +97,27 @@ func vmRunner() {
 			break
 		}
 
+		start := time.Now()
 		err := deployState()
+		duration := time.Since(start).Seconds()
+
+		prometheus.Set(prometheus.DeployDuration, nil, duration)
+		prometheus.Add(prometheus.DeployDurationTotal, nil, duration)
+
 		if err != nil {
+			prometheus.Add(prometheus.DeployTotal, prometheus.Labels{
+				"result": "failed",
+			}, 1)
+
 			logrus.WithFields(logrus.Fields{
 				"error": err,
 			}).Error("sync: Failed to deploy state")
 			continue
 		}
+
+		prometheus.Add(prometheus.DeployTotal, prometheus.Labels{
+			"result": "finished",
+		}, 1)
 	}
 }
 
@@ -0,0 +1,29 @@
+package sync
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/webhook"
+	"time"
+)
+
+func webhookRunner() {
+	time.Sleep(3 * time.Second)
+
+	for {
+		time.Sleep(5 * time.Second)
+
+		db := database.GetDatabase()
+		err := webhook.Process(db)
+		db.Close()
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("sync: Failed to process webhook deliveries")
+		}
+	}
+}
+
+func initWebhook() {
+	go webhookRunner()
+}
@@ -0,0 +1,26 @@
+package task
+
+import (
+	"github.com/pritunl/pritunl-cloud/audit"
+	"github.com/pritunl/pritunl-cloud/database"
+)
+
+var auditExport = &Task{
+	Name:    "audit_export",
+	Hours:   AllHours,
+	Mins:    AllMins,
+	Handler: auditExportHandler,
+}
+
+func auditExportHandler(db *database.Database) (err error) {
+	err = audit.Export(db)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func init() {
+	register(auditExport)
+}
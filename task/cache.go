@@ -3,15 +3,23 @@ package task
 import (
 	"fmt"
 	"github.com/Sirupsen/logrus"
+	"github.com/dropbox/godropbox/container/set"
 	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/data"
 	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/datacenter"
+	"github.com/pritunl/pritunl-cloud/disk"
 	"github.com/pritunl/pritunl-cloud/errortypes"
 	"github.com/pritunl/pritunl-cloud/image"
 	"github.com/pritunl/pritunl-cloud/node"
+	"github.com/pritunl/pritunl-cloud/storage"
 	"github.com/pritunl/pritunl-cloud/utils"
+	"github.com/pritunl/pritunl-cloud/zone"
+	"gopkg.in/mgo.v2/bson"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 )
@@ -21,9 +29,75 @@ var cacheClean = &Task{
 	Hours: []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12,
 		13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23},
 	Mins:    []int{0, 5, 10, 15, 20, 25, 30, 35, 40, 45, 50, 55},
+	Local:   true,
 	Handler: cacheCleanHandler,
 }
 
+var cachePrefetch = &Task{
+	Name: "cache_prefetch",
+	Hours: []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12,
+		13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23},
+	Mins:       []int{2, 7, 12, 17, 22, 27, 32, 37, 42, 47, 52, 57},
+	Local:      true,
+	RunOnStart: true,
+	Handler:    cachePrefetchHandler,
+}
+
+func getPrefetchImages(db *database.Database) (
+	imgs []*image.Image, err error) {
+
+	imgs = []*image.Image{}
+
+	if !node.Self.IsHypervisor() || node.Self.Zone == "" {
+		return
+	}
+
+	zne, err := zone.Get(db, node.Self.Zone)
+	if err != nil {
+		return
+	}
+
+	for _, imgId := range zne.PrefetchImages {
+		img, e := image.Get(db, imgId)
+		if e != nil {
+			if _, ok := e.(*database.NotFoundError); ok {
+				continue
+			}
+			err = e
+			return
+		}
+
+		if img.Type != storage.Public {
+			continue
+		}
+
+		imgs = append(imgs, img)
+	}
+
+	if len(zne.PrefetchFamilies) == 0 {
+		return
+	}
+
+	dc, err := datacenter.Get(db, zne.Datacenter)
+	if err != nil {
+		return
+	}
+
+	for _, family := range zne.PrefetchFamilies {
+		img, e := image.GetFamilyPublic(db, family, dc.PublicStorages)
+		if e != nil {
+			err = e
+			return
+		}
+
+		if img != nil {
+			imgs = append(imgs, img)
+		}
+	}
+
+	return
+}
+
 func cacheCleanHandler(db *database.Database) (err error) {
 	cacheDir := node.Self.GetCachePath()
 
@@ -32,6 +106,21 @@ func cacheCleanHandler(db *database.Database) (err error) {
 		return
 	}
 
+	backingKeys, err := disk.GetBackingKeys(db, node.Self.Id)
+	if err != nil {
+		return
+	}
+
+	prefetchImgs, err := getPrefetchImages(db)
+	if err != nil {
+		return
+	}
+
+	prefetchKeys := set.NewSet()
+	for _, img := range prefetchImgs {
+		prefetchKeys.Add(fmt.Sprintf("%s-%s", img.Id.Hex(), img.Etag))
+	}
+
 	exists, err := utils.ExistsDir(cacheDir)
 	if !exists {
 		return
@@ -45,13 +134,16 @@ func cacheCleanHandler(db *database.Database) (err error) {
 		return
 	}
 
+	caches := []*node.CacheImage{}
+	cacheSize := int64(0)
+
 	for _, item := range items {
 		name := item.Name()
 		pth := filepath.Join(cacheDir, name)
 
 		if strings.HasPrefix(name, "image-") {
 			keys := strings.Split(name, "-")
-			if len(keys) != 3 {
+			if len(keys) != 3 || !bson.IsObjectIdHex(keys[1]) {
 				logrus.WithFields(logrus.Fields{
 					"path": pth,
 				}).Warning("task: Removing unknown image cache")
@@ -60,7 +152,7 @@ func cacheCleanHandler(db *database.Database) (err error) {
 			}
 			key := fmt.Sprintf("%s-%s", keys[1], keys[2])
 
-			if !imageKeys.Contains(key) {
+			if !imageKeys.Contains(key) && !backingKeys.Contains(key) {
 				if time.Since(item.ModTime()) > 5*time.Minute {
 					logrus.WithFields(logrus.Fields{
 						"key":  key,
@@ -70,6 +162,85 @@ func cacheCleanHandler(db *database.Database) (err error) {
 					continue
 				}
 			}
+
+			caches = append(caches, &node.CacheImage{
+				Key:      key,
+				Image:    bson.ObjectIdHex(keys[1]),
+				Etag:     keys[2],
+				Size:     item.Size(),
+				LastUsed: item.ModTime(),
+				InUse:    backingKeys.Contains(key),
+				Prefetch: prefetchKeys.Contains(key),
+			})
+			cacheSize += item.Size()
+		}
+	}
+
+	sort.Sort(node.CacheImages(caches))
+
+	cacheLimit := int64(node.Self.CacheLimit) << 30
+	if cacheLimit > 0 && cacheSize > cacheLimit {
+		remaining := []*node.CacheImage{}
+
+		for _, cache := range caches {
+			if cacheSize <= cacheLimit || cache.InUse || cache.Prefetch ||
+				time.Since(cache.LastUsed) < 5*time.Minute {
+
+				remaining = append(remaining, cache)
+				continue
+			}
+
+			pth := filepath.Join(cacheDir, "image-"+cache.Key)
+
+			logrus.WithFields(logrus.Fields{
+				"key":        cache.Key,
+				"path":       pth,
+				"size":       cache.Size,
+				"cache_size": cacheSize,
+				"limit":      cacheLimit,
+			}).Info("task: Evicting least recently used image cache")
+
+			err = os.Remove(pth)
+			if err != nil {
+				err = &errortypes.WriteError{
+					errors.Wrap(err, "task: Failed to remove image cache"),
+				}
+				return
+			}
+
+			cacheSize -= cache.Size
+		}
+
+		caches = remaining
+	}
+
+	nde := &node.Node{
+		Id:          node.Self.Id,
+		CacheImages: caches,
+	}
+
+	err = nde.CommitFields(db, set.NewSet("cache_images"))
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func cachePrefetchHandler(db *database.Database) (err error) {
+	imgs, err := getPrefetchImages(db)
+	if err != nil {
+		return
+	}
+
+	for _, img := range imgs {
+		e := data.PrefetchImage(db, img)
+		if e != nil {
+			logrus.WithFields(logrus.Fields{
+				"image_id": img.Id.Hex(),
+				"key":      img.Key,
+				"error":    e,
+			}).Error("task: Failed to prefetch image")
 		}
 	}
 
@@ -78,4 +249,5 @@ func cacheCleanHandler(db *database.Database) (err error) {
 
 func init() {
 	register(cacheClean)
+	register(cachePrefetch)
 }
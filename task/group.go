@@ -0,0 +1,26 @@
+package task
+
+import (
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/group"
+)
+
+var groupReconcile = &Task{
+	Name:    "group_reconcile",
+	Hours:   AllHours,
+	Mins:    AllMins,
+	Handler: groupReconcileHandler,
+}
+
+func groupReconcileHandler(db *database.Database) (err error) {
+	err = group.Reconcile(db)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func init() {
+	register(groupReconcile)
+}
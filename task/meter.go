@@ -0,0 +1,28 @@
+package task
+
+import (
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/meter"
+	"time"
+)
+
+var meterSample = &Task{
+	Name:    "meter_sample",
+	Hours:   AllHours,
+	Mins:    []int{0, 5, 10, 15, 20, 25, 30, 35, 40, 45, 50, 55},
+	Handler: meterSampleHandler,
+}
+
+func meterSampleHandler(db *database.Database) (err error) {
+	err = meter.Sample(db, time.Now(),
+		time.Duration(meter.Interval)*time.Minute)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func init() {
+	register(meterSample)
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/Sirupsen/logrus"
 	"github.com/pritunl/pritunl-cloud/database"
 	"github.com/pritunl/pritunl-cloud/node"
+	"github.com/pritunl/pritunl-cloud/prometheus"
 	"time"
 )
 
@@ -17,6 +18,7 @@ type Task struct {
 	Hours      []int
 	Mins       []int
 	Retry      bool
+	Local      bool
 	Handler    func(*database.Database) error
 	RunOnStart bool
 }
@@ -38,9 +40,14 @@ func (t *Task) run(now time.Time) {
 	db := database.GetDatabase()
 	defer db.Close()
 
+	jobId := fmt.Sprintf("%s-%d", t.Name, now.Unix()-int64(now.Second()))
+	if t.Local {
+		jobId = fmt.Sprintf("%s-%s-%d", t.Name, node.Self.Id.Hex(),
+			now.Unix()-int64(now.Second()))
+	}
+
 	job := &Job{
-		Id: fmt.Sprintf(
-			"%s-%d", t.Name, now.Unix()-int64(now.Second())),
+		Id:        jobId,
 		Name:      t.Name,
 		State:     Running,
 		Retry:     t.Retry,
@@ -61,17 +68,33 @@ func (t *Task) run(now time.Time) {
 		return
 	}
 
+	start := time.Now()
 	err = t.Handler(db)
+
+	prometheus.Set(prometheus.TaskDuration, prometheus.Labels{
+		"task": t.Name,
+	}, time.Since(start).Seconds())
+
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"task":  t.Name,
 			"error": err,
 		}).Error("task: Task failed")
 		job.Failed(db)
+
+		prometheus.Add(prometheus.TaskJobsTotal, prometheus.Labels{
+			"task":   t.Name,
+			"result": "failed",
+		}, 1)
 		return
 	}
 
 	job.Finished(db)
+
+	prometheus.Add(prometheus.TaskJobsTotal, prometheus.Labels{
+		"task":   t.Name,
+		"result": "finished",
+	}, 1)
 }
 
 func runScheduler() {
@@ -0,0 +1,26 @@
+package task
+
+import (
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/upload"
+)
+
+var uploadExpire = &Task{
+	Name:    "upload_expire",
+	Hours:   AllHours,
+	Mins:    []int{15},
+	Handler: uploadExpireHandler,
+}
+
+func uploadExpireHandler(db *database.Database) (err error) {
+	err = upload.Expire(db)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func init() {
+	register(uploadExpire)
+}
@@ -0,0 +1,55 @@
+package task
+
+import (
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/node"
+	"github.com/pritunl/pritunl-cloud/webhook"
+	"gopkg.in/mgo.v2/bson"
+	"time"
+)
+
+var webhookNode = &Task{
+	Name:    "webhook_node",
+	Hours:   AllHours,
+	Mins:    AllMins,
+	Handler: webhookNodeHandler,
+}
+
+type nodeOffline struct {
+	Node      bson.ObjectId `json:"node"`
+	Name      string        `json:"name"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+func webhookNodeHandler(db *database.Database) (err error) {
+	now := time.Now()
+
+	// Each offline node falls within a single one minute window
+	nodes, err := node.GetAll(db)
+	if err != nil {
+		return
+	}
+
+	for _, nde := range nodes {
+		if nde.Timestamp.After(now.Add(-30*time.Second)) ||
+			!nde.Timestamp.After(now.Add(-90*time.Second)) {
+
+			continue
+		}
+
+		err = webhook.Publish(db, "", webhook.NodeOffline, &nodeOffline{
+			Node:      nde.Id,
+			Name:      nde.Name,
+			Timestamp: nde.Timestamp,
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func init() {
+	register(webhookNode)
+}
@@ -0,0 +1,6 @@
+package template
+
+const (
+	MaxDisks    = 10
+	MaxUserData = 65536
+)
@@ -0,0 +1,249 @@
+package template
+
+import (
+	"fmt"
+	"github.com/Sirupsen/logrus"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/disk"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/instance"
+	"github.com/pritunl/pritunl-cloud/quota"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"gopkg.in/mgo.v2/bson"
+	"strconv"
+	"strings"
+)
+
+type Launch struct {
+	Organization bson.ObjectId
+	Template     bson.ObjectId
+	Version      int
+	Group        bson.ObjectId
+	Spec         *Spec
+	Name         string
+	State        string
+	StaticIp     string
+	Count        int
+}
+
+func (l *Launch) Validate(db *database.Database) (
+	errData *errortypes.ErrorData, err error) {
+
+	if l.Count == 0 {
+		l.Count = 1
+	}
+
+	if l.Count < 1 || l.Count > 100 {
+		errData = &errortypes.ErrorData{
+			Error:   "count_invalid",
+			Message: "Launch count invalid",
+		}
+		return
+	}
+
+	if l.staticIp() != "" && l.Count > 1 {
+		errData = &errortypes.ErrorData{
+			Error:   "static_ip_count_invalid",
+			Message: "Static IP address cannot be used with multiple instances",
+		}
+		return
+	}
+
+	errData = l.Spec.Validate()
+	if errData != nil {
+		return
+	}
+
+	errData, err = l.Spec.ResolveImage(db, l.Organization)
+	if err != nil || errData != nil {
+		return
+	}
+
+	diskSize := utils.Max(l.Spec.InitDiskSize, 10)
+	for _, dsk := range l.Spec.Disks {
+		diskSize += dsk.Size
+	}
+
+	errData, err = quota.Check(db, l.Organization, &quota.Usage{
+		Instances:  l.Count,
+		Processors: l.Spec.Processors * l.Count,
+		Memory:     l.Spec.Memory * l.Count,
+		DiskSize:   diskSize * l.Count,
+	})
+	if err != nil || errData != nil {
+		return
+	}
+
+	return
+}
+
+func (l *Launch) staticIp() string {
+	if l.StaticIp != "" {
+		return l.StaticIp
+	}
+	return l.Spec.StaticIp
+}
+
+func (l *Launch) name(index int) string {
+	return strings.Replace(l.Name, "%d", strconv.Itoa(index), -1)
+}
+
+func (l *Launch) rollback(db *database.Database,
+	insts []*instance.Instance, dsks []*disk.Disk) {
+
+	for _, dsk := range dsks {
+		err := disk.Remove(db, dsk.Id)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"disk_id": dsk.Id.Hex(),
+				"error":   err,
+			}).Error("template: Failed to remove launch disk")
+		}
+	}
+
+	for _, inst := range insts {
+		err := instance.Remove(db, inst.Id)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"instance_id": inst.Id.Hex(),
+				"error":       err,
+			}).Error("template: Failed to remove launch instance")
+		}
+	}
+}
+
+func (l *Launch) insert(db *database.Database, pending []*instance.Instance) (
+	insts []*instance.Instance, dsks []*disk.Disk,
+	errData *errortypes.ErrorData, err error) {
+
+	insts = []*instance.Instance{}
+	dsks = []*disk.Disk{}
+
+	for _, inst := range pending {
+		err = inst.Insert(db)
+		if err != nil {
+			return
+		}
+
+		insts = append(insts, inst)
+
+		for j, tmplDsk := range l.Spec.Disks {
+			dskName := tmplDsk.Name
+			if dskName == "" {
+				dskName = fmt.Sprintf("%s Disk %d", inst.Name, j+1)
+			}
+
+			dsk := &disk.Disk{
+				Name:         dskName,
+				Organization: l.Organization,
+				Instance:     inst.Id,
+				Index:        strconv.Itoa(j + 1),
+				Node:         inst.Node,
+				Size:         tmplDsk.Size,
+			}
+
+			errData, err = dsk.Validate(db)
+			if err != nil || errData != nil {
+				return
+			}
+
+			err = dsk.Insert(db)
+			if err != nil {
+				return
+			}
+
+			dsks = append(dsks, dsk)
+		}
+	}
+
+	return
+}
+
+// Create validates every instance before inserting any, a failed insert
+// removes the instances and disks already created
+func (l *Launch) Create(db *database.Database) (
+	insts []*instance.Instance, errData *errortypes.ErrorData, err error) {
+
+	pending := []*instance.Instance{}
+
+	for i := 0; i < l.Count; i++ {
+		inst := &instance.Instance{
+			State:           l.State,
+			Organization:    l.Organization,
+			Zone:            l.Spec.Zone,
+			Vpc:             l.Spec.Vpc,
+			Node:            l.Spec.Node,
+			Image:           l.Spec.Image,
+			Name:            l.name(i + 1),
+			InitDiskSize:    l.Spec.InitDiskSize,
+			Memory:          l.Spec.Memory,
+			Processors:      l.Spec.Processors,
+			NetworkRoles:    l.Spec.NetworkRoles,
+			Domain:          l.Spec.Domain,
+			StaticIp:        l.staticIp(),
+			UserData:        l.Spec.UserData,
+			Template:        l.Template,
+			TemplateVersion: l.Version,
+			Group:           l.Group,
+		}
+
+		errData, err = inst.Validate(db)
+		if err != nil || errData != nil {
+			return
+		}
+
+		pending = append(pending, inst)
+	}
+
+	created, dsks, errData, err := l.insert(db, pending)
+	if err != nil || errData != nil {
+		l.rollback(db, created, dsks)
+		return
+	}
+
+	insts = created
+
+	return
+}
+
+type Overrides struct {
+	Zone         bson.ObjectId `json:"zone"`
+	Node         bson.ObjectId `json:"node"`
+	Vpc          bson.ObjectId `json:"vpc"`
+	Domain       bson.ObjectId `json:"domain"`
+	InitDiskSize int           `json:"init_disk_size"`
+	Memory       int           `json:"memory"`
+	Processors   int           `json:"processors"`
+	NetworkRoles []string      `json:"network_roles"`
+	UserData     string        `json:"user_data"`
+}
+
+func (o *Overrides) Apply(spec *Spec) {
+	if o.Zone != "" {
+		spec.Zone = o.Zone
+	}
+	if o.Node != "" {
+		spec.Node = o.Node
+	}
+	if o.Vpc != "" {
+		spec.Vpc = o.Vpc
+	}
+	if o.Domain != "" {
+		spec.Domain = o.Domain
+	}
+	if o.InitDiskSize != 0 {
+		spec.InitDiskSize = o.InitDiskSize
+	}
+	if o.Memory != 0 {
+		spec.Memory = o.Memory
+	}
+	if o.Processors != 0 {
+		spec.Processors = o.Processors
+	}
+	if o.NetworkRoles != nil {
+		spec.NetworkRoles = o.NetworkRoles
+	}
+	if o.UserData != "" {
+		spec.UserData = o.UserData
+	}
+}
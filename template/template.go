@@ -0,0 +1,352 @@
+package template
+
+import (
+	"github.com/dropbox/godropbox/container/set"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/datacenter"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/image"
+	"github.com/pritunl/pritunl-cloud/zone"
+	"gopkg.in/mgo.v2/bson"
+	"net"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type Disk struct {
+	Name string `bson:"name" json:"name"`
+	Size int    `bson:"size" json:"size"`
+}
+
+type Spec struct {
+	Zone         bson.ObjectId `bson:"zone" json:"zone"`
+	Vpc          bson.ObjectId `bson:"vpc" json:"vpc"`
+	Node         bson.ObjectId `bson:"node" json:"node"`
+	Image        bson.ObjectId `bson:"image" json:"image"`
+	ImageFamily  string        `bson:"image_family" json:"image_family"`
+	Domain       bson.ObjectId `bson:"domain,omitempty" json:"domain"`
+	InitDiskSize int           `bson:"init_disk_size" json:"init_disk_size"`
+	Memory       int           `bson:"memory" json:"memory"`
+	Processors   int           `bson:"processors" json:"processors"`
+	NetworkRoles []string      `bson:"network_roles" json:"network_roles"`
+	StaticIp     string        `bson:"static_ip" json:"static_ip"`
+	Disks        []*Disk       `bson:"disks" json:"disks"`
+	UserData     string        `bson:"user_data" json:"user_data"`
+}
+
+func (s *Spec) Copy() (spec *Spec) {
+	spec = &Spec{}
+	*spec = *s
+
+	spec.NetworkRoles = make([]string, len(s.NetworkRoles))
+	copy(spec.NetworkRoles, s.NetworkRoles)
+
+	spec.Disks = []*Disk{}
+	for _, dsk := range s.Disks {
+		spec.Disks = append(spec.Disks, &Disk{
+			Name: dsk.Name,
+			Size: dsk.Size,
+		})
+	}
+
+	return
+}
+
+func (s *Spec) normalize() {
+	s.ImageFamily = strings.ToLower(strings.TrimSpace(s.ImageFamily))
+	s.StaticIp = strings.TrimSpace(s.StaticIp)
+	if s.ImageFamily != "" {
+		s.Image = ""
+	}
+
+	if s.Memory < 256 {
+		s.Memory = 256
+	}
+
+	if s.Processors < 1 {
+		s.Processors = 1
+	}
+
+	if s.NetworkRoles == nil {
+		s.NetworkRoles = []string{}
+	}
+
+	if s.Disks == nil {
+		s.Disks = []*Disk{}
+	}
+}
+
+func (s *Spec) Validate() (errData *errortypes.ErrorData) {
+	s.normalize()
+
+	if s.Zone == "" {
+		errData = &errortypes.ErrorData{
+			Error:   "zone_required",
+			Message: "Missing required zone",
+		}
+		return
+	}
+
+	if s.Node == "" {
+		errData = &errortypes.ErrorData{
+			Error:   "node_required",
+			Message: "Missing required node",
+		}
+		return
+	}
+
+	if s.Image == "" && s.ImageFamily == "" {
+		errData = &errortypes.ErrorData{
+			Error:   "image_required",
+			Message: "Missing required image",
+		}
+		return
+	}
+
+	if s.Vpc == "" {
+		errData = &errortypes.ErrorData{
+			Error:   "vpc_required",
+			Message: "Missing required VPC",
+		}
+		return
+	}
+
+	if s.StaticIp != "" {
+		ip := net.ParseIP(s.StaticIp)
+		if ip == nil || ip.To4() == nil {
+			errData = &errortypes.ErrorData{
+				Error:   "static_ip_invalid",
+				Message: "Static IP address invalid",
+			}
+			return
+		}
+		s.StaticIp = ip.To4().String()
+	}
+
+	if s.InitDiskSize != 0 && s.InitDiskSize < 10 {
+		errData = &errortypes.ErrorData{
+			Error:   "init_disk_size_invalid",
+			Message: "Disk size below minimum",
+		}
+		return
+	}
+
+	if len(s.Disks) > MaxDisks {
+		errData = &errortypes.ErrorData{
+			Error:   "disks_invalid",
+			Message: "Too many template disks",
+		}
+		return
+	}
+
+	for _, dsk := range s.Disks {
+		if dsk.Size < 10 {
+			errData = &errortypes.ErrorData{
+				Error:   "disk_size_invalid",
+				Message: "Disk size below minimum",
+			}
+			return
+		}
+	}
+
+	if len(s.UserData) > MaxUserData {
+		errData = &errortypes.ErrorData{
+			Error:   "user_data_invalid",
+			Message: "User data too large",
+		}
+		return
+	}
+
+	if s.UserData != "" && !strings.HasPrefix(s.UserData, "#cloud-config") &&
+		!strings.HasPrefix(s.UserData, "#!") {
+
+		errData = &errortypes.ErrorData{
+			Error:   "user_data_invalid",
+			Message: "User data must be a cloud-config or script",
+		}
+		return
+	}
+
+	return
+}
+
+// Resolve image family to the newest launchable image version
+func (s *Spec) ResolveImage(db *database.Database, orgId bson.ObjectId) (
+	errData *errortypes.ErrorData, err error) {
+
+	if s.ImageFamily == "" {
+		return
+	}
+
+	zne, err := zone.Get(db, s.Zone)
+	if err != nil {
+		return
+	}
+
+	dc, err := datacenter.Get(db, zne.Datacenter)
+	if err != nil {
+		return
+	}
+
+	img, err := image.GetFamily(db, orgId, s.ImageFamily, dc.Storages())
+	if err != nil {
+		return
+	}
+
+	if img == nil {
+		errData = &errortypes.ErrorData{
+			Error:   "image_family_not_found",
+			Message: "No launchable image found in image family",
+		}
+		return
+	}
+
+	s.Image = img.Id
+
+	return
+}
+
+type Template struct {
+	Id           bson.ObjectId `bson:"_id,omitempty" json:"id"`
+	Name         string        `bson:"name" json:"name"`
+	Organization bson.ObjectId `bson:"organization" json:"organization"`
+	Version      int           `bson:"version" json:"version"`
+	Timestamp    time.Time     `bson:"timestamp" json:"timestamp"`
+	Spec         *Spec         `bson:"spec" json:"spec"`
+}
+
+func (t *Template) Validate(db *database.Database) (
+	errData *errortypes.ErrorData, err error) {
+
+	if t.Organization == "" {
+		errData = &errortypes.ErrorData{
+			Error:   "organization_required",
+			Message: "Missing required organization",
+		}
+		return
+	}
+
+	if t.Spec == nil {
+		errData = &errortypes.ErrorData{
+			Error:   "spec_required",
+			Message: "Missing required template spec",
+		}
+		return
+	}
+
+	errData = t.Spec.Validate()
+	if errData != nil {
+		return
+	}
+
+	return
+}
+
+// Set spec and increment version if spec changed
+func (t *Template) SetSpec(spec *Spec) (changed bool) {
+	if spec == nil {
+		return
+	}
+
+	spec.normalize()
+	if t.Version != 0 && t.Spec != nil && reflect.DeepEqual(t.Spec, spec) {
+		return
+	}
+
+	changed = true
+	t.Spec = spec
+	t.Version += 1
+	t.Timestamp = time.Now()
+
+	return
+}
+
+func (t *Template) InsertVersion(db *database.Database) (err error) {
+	ver := &Version{
+		Template:     t.Id,
+		Organization: t.Organization,
+		Version:      t.Version,
+		Timestamp:    t.Timestamp,
+		Spec:         t.Spec.Copy(),
+	}
+
+	err = ver.Insert(db)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (t *Template) GetSpec(db *database.Database, version int) (
+	spec *Spec, ver int, err error) {
+
+	if version == 0 || version == t.Version {
+		spec = t.Spec.Copy()
+		ver = t.Version
+		return
+	}
+
+	tmplVer, err := GetVersion(db, t.Id, version)
+	if err != nil {
+		return
+	}
+
+	spec = tmplVer.Spec.Copy()
+	ver = tmplVer.Version
+
+	return
+}
+
+func (t *Template) Commit(db *database.Database) (err error) {
+	coll := db.Templates()
+
+	err = coll.Commit(t.Id, t)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (t *Template) CommitFields(db *database.Database, fields set.Set) (
+	err error) {
+
+	coll := db.Templates()
+
+	err = coll.CommitFields(t.Id, t, fields)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (t *Template) Insert(db *database.Database) (err error) {
+	coll := db.Templates()
+
+	if t.Id != "" {
+		err = &errortypes.DatabaseError{
+			errors.New("template: Template already exists"),
+		}
+		return
+	}
+
+	t.Id = bson.NewObjectId()
+
+	err = coll.Insert(t)
+	if err != nil {
+		t.Id = ""
+		err = database.ParseError(err)
+		return
+	}
+
+	err = t.InsertVersion(db)
+	if err != nil {
+		return
+	}
+
+	return
+}
@@ -0,0 +1,275 @@
+package template
+
+import (
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func Get(db *database.Database, tmplId bson.ObjectId) (
+	tmpl *Template, err error) {
+
+	coll := db.Templates()
+	tmpl = &Template{}
+
+	err = coll.FindOneId(tmplId, tmpl)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func GetOrg(db *database.Database, orgId, tmplId bson.ObjectId) (
+	tmpl *Template, err error) {
+
+	coll := db.Templates()
+	tmpl = &Template{}
+
+	err = coll.FindOne(&bson.M{
+		"_id":          tmplId,
+		"organization": orgId,
+	}, tmpl)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func ExistsOrg(db *database.Database, orgId, tmplId bson.ObjectId) (
+	exists bool, err error) {
+
+	coll := db.Templates()
+
+	n, err := coll.Find(&bson.M{
+		"_id":          tmplId,
+		"organization": orgId,
+	}).Count()
+	if err != nil {
+		return
+	}
+
+	if n > 0 {
+		exists = true
+	}
+
+	return
+}
+
+func GetAll(db *database.Database, query *bson.M) (
+	tmpls []*Template, err error) {
+
+	coll := db.Templates()
+	tmpls = []*Template{}
+
+	cursor := coll.Find(query).Iter()
+
+	tmpl := &Template{}
+	for cursor.Next(tmpl) {
+		tmpls = append(tmpls, tmpl)
+		tmpl = &Template{}
+	}
+
+	err = cursor.Close()
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+func GetAllPaged(db *database.Database, query *bson.M, page, pageCount int) (
+	tmpls []*Template, count int, err error) {
+
+	coll := db.Templates()
+	tmpls = []*Template{}
+
+	qury := coll.Find(query)
+
+	count, err = qury.Count()
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	skip := utils.Min(page*pageCount, utils.Max(0, count-pageCount))
+
+	cursor := qury.Sort("name").Skip(skip).Limit(pageCount).Iter()
+
+	tmpl := &Template{}
+	for cursor.Next(tmpl) {
+		tmpls = append(tmpls, tmpl)
+		tmpl = &Template{}
+	}
+
+	err = cursor.Close()
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+func GetVersion(db *database.Database, tmplId bson.ObjectId, version int) (
+	ver *Version, err error) {
+
+	coll := db.TemplatesVersion()
+	ver = &Version{}
+
+	err = coll.FindOne(&bson.M{
+		"template": tmplId,
+		"version":  version,
+	}, ver)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func GetVersions(db *database.Database, tmplId bson.ObjectId) (
+	vers []*Version, err error) {
+
+	coll := db.TemplatesVersion()
+	vers = []*Version{}
+
+	cursor := coll.Find(&bson.M{
+		"template": tmplId,
+	}).Sort("-version").Iter()
+
+	ver := &Version{}
+	for cursor.Next(ver) {
+		vers = append(vers, ver)
+		ver = &Version{}
+	}
+
+	err = cursor.Close()
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+func removeVersions(db *database.Database, tmplIds []bson.ObjectId) (
+	err error) {
+
+	coll := db.TemplatesVersion()
+
+	_, err = coll.RemoveAll(&bson.M{
+		"template": &bson.M{
+			"$in": tmplIds,
+		},
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+func Remove(db *database.Database, tmplId bson.ObjectId) (err error) {
+	coll := db.Templates()
+
+	err = coll.Remove(&bson.M{
+		"_id": tmplId,
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		switch err.(type) {
+		case *database.NotFoundError:
+			err = nil
+		default:
+			return
+		}
+	}
+
+	err = removeVersions(db, []bson.ObjectId{tmplId})
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func RemoveOrg(db *database.Database, orgId, tmplId bson.ObjectId) (
+	err error) {
+
+	coll := db.Templates()
+
+	err = coll.Remove(&bson.M{
+		"_id":          tmplId,
+		"organization": orgId,
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		switch err.(type) {
+		case *database.NotFoundError:
+			err = nil
+		}
+		return
+	}
+
+	err = removeVersions(db, []bson.ObjectId{tmplId})
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func RemoveMulti(db *database.Database, tmplIds []bson.ObjectId) (err error) {
+	coll := db.Templates()
+
+	_, err = coll.RemoveAll(&bson.M{
+		"_id": &bson.M{
+			"$in": tmplIds,
+		},
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	err = removeVersions(db, tmplIds)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func RemoveMultiOrg(db *database.Database, orgId bson.ObjectId,
+	tmplIds []bson.ObjectId) (err error) {
+
+	coll := db.Templates()
+
+	_, err = coll.RemoveAll(&bson.M{
+		"_id": &bson.M{
+			"$in": tmplIds,
+		},
+		"organization": orgId,
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	_, err = db.TemplatesVersion().RemoveAll(&bson.M{
+		"template": &bson.M{
+			"$in": tmplIds,
+		},
+		"organization": orgId,
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
@@ -0,0 +1,37 @@
+package template
+
+import (
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"gopkg.in/mgo.v2/bson"
+	"time"
+)
+
+type Version struct {
+	Id           bson.ObjectId `bson:"_id,omitempty" json:"id"`
+	Template     bson.ObjectId `bson:"template" json:"template"`
+	Organization bson.ObjectId `bson:"organization" json:"organization"`
+	Version      int           `bson:"version" json:"version"`
+	Timestamp    time.Time     `bson:"timestamp" json:"timestamp"`
+	Spec         *Spec         `bson:"spec" json:"spec"`
+}
+
+func (v *Version) Insert(db *database.Database) (err error) {
+	coll := db.TemplatesVersion()
+
+	if v.Id != "" {
+		err = &errortypes.DatabaseError{
+			errors.New("template: Version already exists"),
+		}
+		return
+	}
+
+	err = coll.Insert(v)
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
@@ -0,0 +1,388 @@
+package uhandlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-cloud/authority"
+	"github.com/pritunl/pritunl-cloud/datacenter"
+	"github.com/pritunl/pritunl-cloud/disk"
+	"github.com/pritunl/pritunl-cloud/domain"
+	"github.com/pritunl/pritunl-cloud/event"
+	"github.com/pritunl/pritunl-cloud/firewall"
+	"github.com/pritunl/pritunl-cloud/group"
+	"github.com/pritunl/pritunl-cloud/image"
+	"github.com/pritunl/pritunl-cloud/instance"
+	"github.com/pritunl/pritunl-cloud/metric"
+	"github.com/pritunl/pritunl-cloud/node"
+	"github.com/pritunl/pritunl-cloud/openapi"
+	"github.com/pritunl/pritunl-cloud/organization"
+	"github.com/pritunl/pritunl-cloud/quota"
+	"github.com/pritunl/pritunl-cloud/spec"
+	"github.com/pritunl/pritunl-cloud/template"
+	"github.com/pritunl/pritunl-cloud/upload"
+	"github.com/pritunl/pritunl-cloud/vpc"
+	"github.com/pritunl/pritunl-cloud/webhook"
+	"github.com/pritunl/pritunl-cloud/zone"
+	"gopkg.in/mgo.v2/bson"
+)
+
+var (
+	apiDoc  *openapi.Document
+	apiIds  = []bson.ObjectId{}
+	apiPage = []string{"page", "page_count", "id", "name"}
+)
+
+func registerApi(api, orgApi *openapi.Router) {
+	orgApi.GET("/authority", authoritiesGet, &openapi.Op{
+		Summary:  "List authorities",
+		Query:    append(apiPage, "network_role", "role"),
+		Response: authoritiesData{},
+	})
+	orgApi.GET("/authority/:authority_id", authorityGet, &openapi.Op{
+		Summary:  "Get authority",
+		Response: authority.Authority{},
+	})
+	orgApi.PUT("/authority/:authority_id", authorityPut, &openapi.Op{
+		Summary:  "Update authority",
+		Request:  authorityData{},
+		Response: authority.Authority{},
+	})
+	orgApi.POST("/authority", authorityPost, &openapi.Op{
+		Summary:  "Create authority",
+		Request:  authorityData{},
+		Response: authority.Authority{},
+	})
+	orgApi.DELETE("/authority", authoritiesDelete, &openapi.Op{
+		Summary: "Delete authorities",
+		Request: apiIds,
+	})
+	orgApi.DELETE("/authority/:authority_id", authorityDelete, &openapi.Op{
+		Summary: "Delete authority",
+	})
+
+	orgApi.GET("/datacenter", datacentersGet, &openapi.Op{
+		Summary:  "List datacenters",
+		Response: []*datacenter.Datacenter{},
+	})
+
+	orgApi.GET("/domain", domainsGet, &openapi.Op{
+		Summary:  "List domains",
+		Response: []*domain.Domain{},
+	})
+
+	orgApi.GET("/disk", disksGet, &openapi.Op{
+		Summary:  "List disks",
+		Query:    append(apiPage, "instance"),
+		Response: disksData{},
+	})
+	orgApi.GET("/disk/:disk_id", diskGet, &openapi.Op{
+		Summary:  "Get disk",
+		Response: disk.Disk{},
+	})
+	orgApi.PUT("/disk", disksPut, &openapi.Op{
+		Summary: "Update disks state",
+		Request: disksMultiData{},
+	})
+	orgApi.PUT("/disk/:disk_id", diskPut, &openapi.Op{
+		Summary:  "Update disk",
+		Request:  diskData{},
+		Response: disk.Disk{},
+	})
+	orgApi.POST("/disk", diskPost, &openapi.Op{
+		Summary:  "Create disk",
+		Request:  diskData{},
+		Response: disk.Disk{},
+	})
+	orgApi.DELETE("/disk", disksDelete, &openapi.Op{
+		Summary: "Delete disks",
+		Request: apiIds,
+	})
+	orgApi.DELETE("/disk/:disk_id", diskDelete, &openapi.Op{
+		Summary: "Delete disk",
+	})
+
+	api.GET("/event", eventGet, &openapi.Op{
+		Summary:  "Event websocket",
+		Response: event.Event{},
+	})
+
+	orgApi.GET("/firewall", firewallsGet, &openapi.Op{
+		Summary:  "List firewalls",
+		Query:    append(apiPage, "network_role"),
+		Response: firewallsData{},
+	})
+	orgApi.GET("/firewall/:firewall_id", firewallGet, &openapi.Op{
+		Summary:  "Get firewall",
+		Response: firewall.Firewall{},
+	})
+	orgApi.PUT("/firewall/:firewall_id", firewallPut, &openapi.Op{
+		Summary:  "Update firewall",
+		Request:  firewallData{},
+		Response: firewall.Firewall{},
+	})
+	orgApi.POST("/firewall", firewallPost, &openapi.Op{
+		Summary:  "Create firewall",
+		Request:  firewallData{},
+		Response: firewall.Firewall{},
+	})
+	orgApi.DELETE("/firewall", firewallsDelete, &openapi.Op{
+		Summary: "Delete firewalls",
+		Request: apiIds,
+	})
+	orgApi.DELETE("/firewall/:firewall_id", firewallDelete, &openapi.Op{
+		Summary: "Delete firewall",
+	})
+
+	orgApi.GET("/image", imagesGet, &openapi.Op{
+		Summary:  "List images",
+		Query:    append(apiPage, "datacenter", "type"),
+		Response: imagesData{},
+	})
+	orgApi.GET("/image/:image_id", imageGet, &openapi.Op{
+		Summary:  "Get image",
+		Response: image.Image{},
+	})
+	orgApi.PUT("/image/:image_id", imagePut, &openapi.Op{
+		Summary:  "Update image",
+		Request:  imageData{},
+		Response: image.Image{},
+	})
+	orgApi.DELETE("/image", imagesDelete, &openapi.Op{
+		Summary: "Delete images",
+		Request: apiIds,
+	})
+	orgApi.DELETE("/image/:image_id", imageDelete, &openapi.Op{
+		Summary: "Delete image",
+	})
+
+	orgApi.GET("/image_upload", uploadsGet, &openapi.Op{
+		Summary:  "List image uploads and imports",
+		Response: []*upload.Upload{},
+	})
+	orgApi.GET("/image_upload/:upload_id", uploadGet, &openapi.Op{
+		Summary:  "Get image upload",
+		Response: upload.Upload{},
+	})
+	orgApi.PUT("/image_upload/:upload_id", uploadPut, &openapi.Op{
+		Summary:  "Write image upload chunk at offset",
+		Query:    []string{"offset"},
+		Binary:   true,
+		Response: upload.Upload{},
+	})
+	orgApi.POST("/image_upload", uploadPost, &openapi.Op{
+		Summary:  "Create image upload",
+		Request:  uploadData{},
+		Response: upload.Upload{},
+	})
+	orgApi.DELETE("/image_upload/:upload_id", uploadDelete, &openapi.Op{
+		Summary: "Delete image upload",
+	})
+	orgApi.POST("/image_import", importPost, &openapi.Op{
+		Summary:  "Import image from URL",
+		Request:  uploadData{},
+		Response: upload.Upload{},
+	})
+
+	orgApi.GET("/instance", instancesGet, &openapi.Op{
+		Summary:  "List instances",
+		Query:    append(apiPage, "network_role", "group", "node"),
+		Response: instancesData{},
+	})
+	orgApi.PUT("/instance", instancesPut, &openapi.Op{
+		Summary: "Update instances state",
+		Request: instanceMultiData{},
+	})
+	orgApi.GET("/instance/:instance_id", instanceGet, &openapi.Op{
+		Summary:  "Get instance",
+		Response: instance.Instance{},
+	})
+	orgApi.PUT("/instance/:instance_id", instancePut, &openapi.Op{
+		Summary:  "Update instance",
+		Request:  instanceData{},
+		Response: instance.Instance{},
+	})
+	orgApi.POST("/instance", instancePost, &openapi.Op{
+		Summary:  "Create instance, returns an array when count > 1",
+		Request:  instanceData{},
+		Response: instance.Instance{},
+	})
+	orgApi.DELETE("/instance", instancesDelete, &openapi.Op{
+		Summary: "Delete instances",
+		Query:   []string{"force"},
+		Request: apiIds,
+	})
+	orgApi.DELETE("/instance/:instance_id", instanceDelete, &openapi.Op{
+		Summary: "Delete instance",
+	})
+
+	orgApi.GET("/metric/instance/:instance_id", metricInstanceGet,
+		&openapi.Op{
+			Summary:  "Get instance metrics",
+			Query:    []string{"resolution", "start", "end"},
+			Response: []*metric.Point{},
+		})
+
+	orgApi.GET("/node", nodesGet, &openapi.Op{
+		Summary:  "List nodes",
+		Query:    []string{"zone"},
+		Response: []*node.Node{},
+	})
+
+	api.GET("/organization", organizationsGet, &openapi.Op{
+		Summary:  "List organizations",
+		Response: []*organization.Organization{},
+	})
+	orgApi.GET("/organization/quota", organizationQuotaGet, &openapi.Op{
+		Summary:  "Get organization quota",
+		Response: quota.Report{},
+	})
+	orgApi.GET("/organization/spec", specGet, &openapi.Op{
+		Summary:  "Export organization spec, YAML unless format=json",
+		Query:    []string{"format"},
+		Response: spec.Spec{},
+	})
+	orgApi.POST("/organization/spec/plan", specPlanPost, &openapi.Op{
+		Summary:  "Plan organization spec",
+		Query:    []string{"prune"},
+		Request:  spec.Spec{},
+		Response: specChangesData{},
+	})
+	orgApi.POST("/organization/spec/apply", specApplyPost, &openapi.Op{
+		Summary:  "Apply organization spec",
+		Query:    []string{"prune"},
+		Request:  spec.Spec{},
+		Response: specChangesData{},
+	})
+
+	orgApi.GET("/vpc", vpcsGet, &openapi.Op{
+		Summary:  "List VPCs",
+		Query:    append(apiPage, "names", "network", "datacenter"),
+		Response: vpcsData{},
+	})
+	orgApi.GET("/vpc/:vpc_id", vpcGet, &openapi.Op{
+		Summary:  "Get VPC",
+		Response: vpc.Vpc{},
+	})
+	orgApi.PUT("/vpc/:vpc_id", vpcPut, &openapi.Op{
+		Summary:  "Update VPC",
+		Request:  vpcData{},
+		Response: vpc.Vpc{},
+	})
+	orgApi.POST("/vpc", vpcPost, &openapi.Op{
+		Summary:  "Create VPC",
+		Request:  vpcData{},
+		Response: vpc.Vpc{},
+	})
+	orgApi.DELETE("/vpc", vpcsDelete, &openapi.Op{
+		Summary: "Delete VPCs",
+		Request: apiIds,
+	})
+	orgApi.DELETE("/vpc/:vpc_id", vpcDelete, &openapi.Op{
+		Summary: "Delete VPC",
+	})
+
+	orgApi.GET("/webhook", webhooksGet, &openapi.Op{
+		Summary:  "List webhooks",
+		Query:    apiPage,
+		Response: webhooksData{},
+	})
+	orgApi.GET("/webhook/:webhook_id", webhookGet, &openapi.Op{
+		Summary:  "Get webhook",
+		Response: webhook.Webhook{},
+	})
+	orgApi.GET("/webhook/:webhook_id/delivery", webhookDeliveriesGet,
+		&openapi.Op{
+			Summary:  "List webhook deliveries",
+			Response: []*webhook.Delivery{},
+		})
+	orgApi.PUT("/webhook/:webhook_id", webhookPut, &openapi.Op{
+		Summary:  "Update webhook",
+		Request:  webhookData{},
+		Response: webhook.Webhook{},
+	})
+	orgApi.POST("/webhook", webhookPost, &openapi.Op{
+		Summary:  "Create webhook",
+		Request:  webhookData{},
+		Response: webhook.Webhook{},
+	})
+	orgApi.DELETE("/webhook", webhooksDelete, &openapi.Op{
+		Summary: "Delete webhooks",
+		Request: apiIds,
+	})
+	orgApi.DELETE("/webhook/:webhook_id", webhookDelete, &openapi.Op{
+		Summary: "Delete webhook",
+	})
+
+	orgApi.GET("/group", groupsGet, &openapi.Op{
+		Summary:  "List instance groups",
+		Query:    apiPage,
+		Response: groupsData{},
+	})
+	orgApi.GET("/group/:group_id", groupGet, &openapi.Op{
+		Summary:  "Get instance group",
+		Response: group.Group{},
+	})
+	orgApi.PUT("/group/:group_id", groupPut, &openapi.Op{
+		Summary:  "Update instance group",
+		Request:  groupData{},
+		Response: group.Group{},
+	})
+	orgApi.POST("/group", groupPost, &openapi.Op{
+		Summary:  "Create instance group",
+		Request:  groupData{},
+		Response: group.Group{},
+	})
+	orgApi.DELETE("/group", groupsDelete, &openapi.Op{
+		Summary: "Delete instance groups",
+		Request: apiIds,
+	})
+	orgApi.DELETE("/group/:group_id", groupDelete, &openapi.Op{
+		Summary: "Delete instance group",
+	})
+
+	orgApi.GET("/template", templatesGet, &openapi.Op{
+		Summary:  "List templates",
+		Query:    apiPage,
+		Response: templatesData{},
+	})
+	orgApi.GET("/template/:template_id", templateGet, &openapi.Op{
+		Summary:  "Get template",
+		Response: template.Template{},
+	})
+	orgApi.GET("/template/:template_id/version", templateVersionsGet,
+		&openapi.Op{
+			Summary:  "List template versions",
+			Response: []*template.Version{},
+		})
+	orgApi.PUT("/template/:template_id", templatePut, &openapi.Op{
+		Summary:  "Update template",
+		Request:  templateData{},
+		Response: template.Template{},
+	})
+	orgApi.POST("/template", templatePost, &openapi.Op{
+		Summary:  "Create template",
+		Request:  templateData{},
+		Response: template.Template{},
+	})
+	orgApi.POST("/template/:template_id/launch", templateLaunchPost,
+		&openapi.Op{
+			Summary:  "Launch instances from template",
+			Request:  templateLaunchData{},
+			Response: []*instance.Instance{},
+		})
+	orgApi.DELETE("/template", templatesDelete, &openapi.Op{
+		Summary: "Delete templates",
+		Request: apiIds,
+	})
+	orgApi.DELETE("/template/:template_id", templateDelete, &openapi.Op{
+		Summary: "Delete template",
+	})
+
+	orgApi.GET("/zone", zonesGet, &openapi.Op{
+		Summary:  "List zones",
+		Response: []*zone.Zone{},
+	})
+}
+
+func openapiGet(c *gin.Context) {
+	c.JSON(200, apiDoc)
+}
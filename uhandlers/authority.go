@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/dropbox/godropbox/container/set"
 	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-cloud/audit"
 	"github.com/pritunl/pritunl-cloud/authority"
 	"github.com/pritunl/pritunl-cloud/database"
 	"github.com/pritunl/pritunl-cloud/demo"
@@ -56,6 +57,8 @@ func authorityPut(c *gin.Context) {
 		return
 	}
 
+	orig := audit.Snapshot(fire)
+
 	fire.Name = data.Name
 	fire.Type = data.Type
 	fire.NetworkRoles = data.NetworkRoles
@@ -91,6 +94,8 @@ func authorityPut(c *gin.Context) {
 		return
 	}
 
+	c.Set("audit_changes", audit.Diff(orig, fire, fields))
+
 	event.PublishDispatch(db, "authority.change")
 
 	c.JSON(200, fire)
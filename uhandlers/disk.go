@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/dropbox/godropbox/container/set"
 	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-cloud/audit"
 	"github.com/pritunl/pritunl-cloud/database"
 	"github.com/pritunl/pritunl-cloud/datacenter"
 	"github.com/pritunl/pritunl-cloud/demo"
@@ -13,6 +14,7 @@ import (
 	"github.com/pritunl/pritunl-cloud/image"
 	"github.com/pritunl/pritunl-cloud/instance"
 	"github.com/pritunl/pritunl-cloud/node"
+	"github.com/pritunl/pritunl-cloud/quota"
 	"github.com/pritunl/pritunl-cloud/utils"
 	"github.com/pritunl/pritunl-cloud/zone"
 	"gopkg.in/mgo.v2/bson"
@@ -68,6 +70,8 @@ func diskPut(c *gin.Context) {
 		return
 	}
 
+	orig := audit.Snapshot(dsk)
+
 	if dta.Instance != "" {
 		exists, err := instance.ExistsOrg(db, userOrg, dta.Instance)
 		if err != nil {
@@ -83,8 +87,15 @@ func diskPut(c *gin.Context) {
 	dsk.Instance = dta.Instance
 	dsk.Index = dta.Index
 
+	snapshot := false
 	if dsk.State == disk.Available && dta.State == disk.Snapshot {
 		dsk.State = disk.Snapshot
+		snapshot = true
+	} else if dsk.State == disk.Available && dta.State == disk.Flatten &&
+		dsk.Backing != "" {
+
+		dsk.State = disk.Flatten
+		dsk.Message = ""
 	}
 
 	fields := set.NewSet(
@@ -92,6 +103,7 @@ func diskPut(c *gin.Context) {
 		"name",
 		"instance",
 		"index",
+		"message",
 	)
 
 	errData, err := dsk.Validate(db)
@@ -105,12 +117,29 @@ func diskPut(c *gin.Context) {
 		return
 	}
 
+	if snapshot {
+		errData, err = quota.Check(db, userOrg, &quota.Usage{
+			Images: 1,
+		})
+		if err != nil {
+			utils.AbortWithError(c, 500, err)
+			return
+		}
+
+		if errData != nil {
+			c.JSON(400, errData)
+			return
+		}
+	}
+
 	err = dsk.CommitFields(db, fields)
 	if err != nil {
 		utils.AbortWithError(c, 500, err)
 		return
 	}
 
+	c.Set("audit_changes", audit.Diff(orig, dsk, fields))
+
 	event.PublishDispatch(db, "disk.change")
 
 	c.JSON(200, dsk)
@@ -198,6 +227,19 @@ func diskPost(c *gin.Context) {
 		return
 	}
 
+	errData, err = quota.Check(db, userOrg, &quota.Usage{
+		DiskSize: dsk.Size,
+	})
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	if errData != nil {
+		c.JSON(400, errData)
+		return
+	}
+
 	err = dsk.Insert(db)
 	if err != nil {
 		utils.AbortWithError(c, 500, err)
@@ -234,6 +276,19 @@ func disksPut(c *gin.Context) {
 		return
 	}
 
+	errData, err := quota.Check(db, userOrg, &quota.Usage{
+		Images: len(data.Ids),
+	})
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	if errData != nil {
+		c.JSON(400, errData)
+		return
+	}
+
 	doc := bson.M{
 		"state": data.State,
 	}
@@ -0,0 +1,335 @@
+package uhandlers
+
+import (
+	"fmt"
+	"github.com/dropbox/godropbox/container/set"
+	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-cloud/audit"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/datacenter"
+	"github.com/pritunl/pritunl-cloud/demo"
+	"github.com/pritunl/pritunl-cloud/event"
+	"github.com/pritunl/pritunl-cloud/group"
+	"github.com/pritunl/pritunl-cloud/image"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"github.com/pritunl/pritunl-cloud/zone"
+	"gopkg.in/mgo.v2/bson"
+	"strconv"
+	"strings"
+)
+
+type groupData struct {
+	Id              bson.ObjectId      `json:"id"`
+	Name            string             `json:"name"`
+	Disabled        bool               `json:"disabled"`
+	Zone            bson.ObjectId      `json:"zone"`
+	Template        bson.ObjectId      `json:"template"`
+	TemplateVersion int                `json:"template_version"`
+	Image           bson.ObjectId      `json:"image"`
+	Count           int                `json:"count"`
+	MinCount        int                `json:"min_count"`
+	MaxCount        int                `json:"max_count"`
+	Surge           int                `json:"surge"`
+	MaxUnavailable  int                `json:"max_unavailable"`
+	AutoScaling     *group.AutoScaling `json:"auto_scaling"`
+	Schedules       []*group.Schedule  `json:"schedules"`
+}
+
+type groupsData struct {
+	Groups []*group.Group `json:"groups"`
+	Count  int            `json:"count"`
+}
+
+func groupAllowed(db *database.Database, userOrg bson.ObjectId,
+	data *groupData) (allowed bool, err error) {
+
+	zne, err := zone.Get(db, data.Zone)
+	if err != nil {
+		return
+	}
+
+	exists, err := datacenter.ExistsOrg(db, userOrg, zne.Datacenter)
+	if err != nil || !exists {
+		return
+	}
+
+	if data.Image != "" {
+		exists, err = image.ExistsOrg(db, userOrg, data.Image)
+		if err != nil || !exists {
+			return
+		}
+	}
+
+	allowed = true
+
+	return
+}
+
+func groupPut(c *gin.Context) {
+	if demo.Blocked(c) {
+		return
+	}
+
+	db := c.MustGet("db").(*database.Database)
+	userOrg := c.MustGet("organization").(bson.ObjectId)
+	data := &groupData{}
+
+	groupId, ok := utils.ParseObjectId(c.Param("group_id"))
+	if !ok {
+		utils.AbortWithStatus(c, 400)
+		return
+	}
+
+	err := c.Bind(data)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	grp, err := group.GetOrg(db, userOrg, groupId)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	orig := audit.Snapshot(grp)
+
+	allowed, err := groupAllowed(db, userOrg, data)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+	if !allowed {
+		utils.AbortWithStatus(c, 405)
+		return
+	}
+
+	grp.Name = data.Name
+	grp.Disabled = data.Disabled
+	grp.Zone = data.Zone
+	grp.Template = data.Template
+	grp.TemplateVersion = data.TemplateVersion
+	grp.Image = data.Image
+	grp.Count = data.Count
+	grp.MinCount = data.MinCount
+	grp.MaxCount = data.MaxCount
+	grp.Surge = data.Surge
+	grp.MaxUnavailable = data.MaxUnavailable
+	grp.AutoScaling = data.AutoScaling
+	grp.Schedules = data.Schedules
+
+	fields := set.NewSet(
+		"name",
+		"disabled",
+		"zone",
+		"template",
+		"template_version",
+		"image",
+		"count",
+		"min_count",
+		"max_count",
+		"surge",
+		"max_unavailable",
+		"auto_scaling",
+		"schedules",
+	)
+
+	errData, err := grp.Validate(db)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	if errData != nil {
+		c.JSON(400, errData)
+		return
+	}
+
+	err = grp.CommitFields(db, fields)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	c.Set("audit_changes", audit.Diff(orig, grp, fields))
+
+	event.PublishDispatch(db, "group.change")
+
+	c.JSON(200, grp)
+}
+
+func groupPost(c *gin.Context) {
+	if demo.Blocked(c) {
+		return
+	}
+
+	db := c.MustGet("db").(*database.Database)
+	userOrg := c.MustGet("organization").(bson.ObjectId)
+	data := &groupData{
+		Name: "New Group",
+	}
+
+	err := c.Bind(data)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	allowed, err := groupAllowed(db, userOrg, data)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+	if !allowed {
+		utils.AbortWithStatus(c, 405)
+		return
+	}
+
+	grp := &group.Group{
+		Name:            data.Name,
+		Organization:    userOrg,
+		Disabled:        data.Disabled,
+		Zone:            data.Zone,
+		Template:        data.Template,
+		TemplateVersion: data.TemplateVersion,
+		Image:           data.Image,
+		Count:           data.Count,
+		MinCount:        data.MinCount,
+		MaxCount:        data.MaxCount,
+		Surge:           data.Surge,
+		MaxUnavailable:  data.MaxUnavailable,
+		AutoScaling:     data.AutoScaling,
+		Schedules:       data.Schedules,
+	}
+
+	errData, err := grp.Validate(db)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	if errData != nil {
+		c.JSON(400, errData)
+		return
+	}
+
+	err = grp.Insert(db)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	event.PublishDispatch(db, "group.change")
+
+	c.JSON(200, grp)
+}
+
+func groupDelete(c *gin.Context) {
+	if demo.Blocked(c) {
+		return
+	}
+
+	db := c.MustGet("db").(*database.Database)
+	userOrg := c.MustGet("organization").(bson.ObjectId)
+
+	groupId, ok := utils.ParseObjectId(c.Param("group_id"))
+	if !ok {
+		utils.AbortWithStatus(c, 400)
+		return
+	}
+
+	err := group.RemoveOrg(db, userOrg, groupId)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	event.PublishDispatch(db, "group.change")
+	event.PublishDispatch(db, "instance.change")
+
+	c.JSON(200, nil)
+}
+
+func groupsDelete(c *gin.Context) {
+	if demo.Blocked(c) {
+		return
+	}
+
+	db := c.MustGet("db").(*database.Database)
+	userOrg := c.MustGet("organization").(bson.ObjectId)
+	data := []bson.ObjectId{}
+
+	err := c.Bind(&data)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	err = group.RemoveMultiOrg(db, userOrg, data)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	event.PublishDispatch(db, "group.change")
+	event.PublishDispatch(db, "instance.change")
+
+	c.JSON(200, nil)
+}
+
+func groupGet(c *gin.Context) {
+	db := c.MustGet("db").(*database.Database)
+	userOrg := c.MustGet("organization").(bson.ObjectId)
+
+	groupId, ok := utils.ParseObjectId(c.Param("group_id"))
+	if !ok {
+		utils.AbortWithStatus(c, 400)
+		return
+	}
+
+	grp, err := group.GetOrg(db, userOrg, groupId)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	c.JSON(200, grp)
+}
+
+func groupsGet(c *gin.Context) {
+	db := c.MustGet("db").(*database.Database)
+	userOrg := c.MustGet("organization").(bson.ObjectId)
+
+	page, _ := strconv.Atoi(c.Query("page"))
+	pageCount, _ := strconv.Atoi(c.Query("page_count"))
+
+	query := bson.M{
+		"organization": userOrg,
+	}
+
+	groupId, ok := utils.ParseObjectId(c.Query("id"))
+	if ok {
+		query["_id"] = groupId
+	}
+
+	name := strings.TrimSpace(c.Query("name"))
+	if name != "" {
+		query["name"] = &bson.M{
+			"$regex":   fmt.Sprintf(".*%s.*", name),
+			"$options": "i",
+		}
+	}
+
+	grps, count, err := group.GetAllPaged(db, &query, page, pageCount)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	data := &groupsData{
+		Groups: grps,
+		Count:  count,
+	}
+
+	c.JSON(200, data)
+}
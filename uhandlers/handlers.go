@@ -5,6 +5,7 @@ import (
 	"github.com/pritunl/pritunl-cloud/config"
 	"github.com/pritunl/pritunl-cloud/constants"
 	"github.com/pritunl/pritunl-cloud/middlewear"
+	"github.com/pritunl/pritunl-cloud/openapi"
 	"github.com/pritunl/pritunl-cloud/requires"
 	"github.com/pritunl/pritunl-cloud/static"
 	"net/http"
@@ -31,12 +32,35 @@ func Register(engine *gin.Engine) {
 
 	csrfGroup := authGroup.Group("")
 	csrfGroup.Use(middlewear.CsrfToken)
+	csrfGroup.Use(middlewear.AuditUser)
 
 	orgGroup := csrfGroup.Group("")
 	orgGroup.Use(middlewear.UserOrg)
 
+	apiGroup := engine.Group(constants.ApiPrefix)
+	apiGroup.Use(middlewear.Database)
+	apiGroup.Use(middlewear.SessionUser)
+	apiGroup.Use(middlewear.AuthUser)
+	apiGroup.Use(middlewear.CsrfToken)
+	apiGroup.Use(middlewear.AuditUser)
+
+	apiOrgGroup := apiGroup.Group("")
+	apiOrgGroup.Use(middlewear.UserOrg)
+
+	apiDoc = openapi.New("Pritunl Cloud User API", constants.ApiPrefix)
+	registerApi(&openapi.Router{
+		Document: apiDoc,
+		Groups:   []*gin.RouterGroup{csrfGroup, apiGroup},
+	}, &openapi.Router{
+		Document: apiDoc,
+		Groups:   []*gin.RouterGroup{orgGroup, apiOrgGroup},
+		Headers:  []string{"Organization"},
+	})
+
 	engine.NoRoute(middlewear.NotFound)
 
+	engine.GET(constants.ApiPrefix+"/openapi.json", openapiGet)
+
 	engine.GET("/auth/state", authStateGet)
 	dbGroup.POST("/auth/session", authSessionPost)
 	dbGroup.POST("/auth/secondary", authSecondaryPost)
@@ -50,19 +74,10 @@ func Register(engine *gin.Engine) {
 	sessGroup.GET("/logout", logoutGet)
 	sessGroup.GET("/logout_all", logoutAllGet)
 
-	orgGroup.GET("/authority", authoritiesGet)
-	orgGroup.GET("/authority/:authority_id", authorityGet)
-	orgGroup.PUT("/authority/:authority_id", authorityPut)
-	orgGroup.POST("/authority", authorityPost)
-	orgGroup.DELETE("/authority", authoritiesDelete)
-	orgGroup.DELETE("/authority/:authority_id", authorityDelete)
-
 	engine.GET("/check", checkGet)
 
 	authGroup.GET("/csrf", csrfGet)
 
-	orgGroup.GET("/datacenter", datacentersGet)
-
 	csrfGroup.GET("/device", devicesGet)
 	csrfGroup.PUT("/device/:device_id", devicePut)
 	csrfGroup.DELETE("/device/:device_id", deviceDelete)
@@ -72,56 +87,10 @@ func Register(engine *gin.Engine) {
 	csrfGroup.GET("/device/:device_id/register", deviceU2fRegisterGet)
 	csrfGroup.POST("/device/:device_id/register", deviceU2fRegisterPost)
 
-	orgGroup.GET("/domain", domainsGet)
-
-	orgGroup.GET("/disk", disksGet)
-	orgGroup.GET("/disk/:disk_id", diskGet)
-	orgGroup.PUT("/disk", disksPut)
-	orgGroup.PUT("/disk/:disk_id", diskPut)
-	orgGroup.POST("/disk", diskPost)
-	orgGroup.DELETE("/disk", disksDelete)
-	orgGroup.DELETE("/disk/:disk_id", diskDelete)
-
-	csrfGroup.GET("/event", eventGet)
-
-	orgGroup.GET("/firewall", firewallsGet)
-	orgGroup.GET("/firewall/:firewall_id", firewallGet)
-	orgGroup.PUT("/firewall/:firewall_id", firewallPut)
-	orgGroup.POST("/firewall", firewallPost)
-	orgGroup.DELETE("/firewall", firewallsDelete)
-	orgGroup.DELETE("/firewall/:firewall_id", firewallDelete)
-
-	orgGroup.GET("/image", imagesGet)
-	orgGroup.GET("/image/:image_id", imageGet)
-	orgGroup.PUT("/image/:image_id", imagePut)
-	orgGroup.DELETE("/image", imagesDelete)
-	orgGroup.DELETE("/image/:image_id", imageDelete)
-
-	orgGroup.GET("/instance", instancesGet)
-	orgGroup.PUT("/instance", instancesPut)
-	orgGroup.GET("/instance/:instance_id", instanceGet)
-	orgGroup.PUT("/instance/:instance_id", instancePut)
-	orgGroup.POST("/instance", instancePost)
-	orgGroup.DELETE("/instance", instancesDelete)
-	orgGroup.DELETE("/instance/:instance_id", instanceDelete)
-
 	csrfGroup.PUT("/license", licensePut)
 
-	orgGroup.GET("/node", nodesGet)
-
-	csrfGroup.GET("/organization", organizationsGet)
-
 	csrfGroup.PUT("/theme", themePut)
 
-	orgGroup.GET("/vpc", vpcsGet)
-	orgGroup.GET("/vpc/:vpc_id", vpcGet)
-	orgGroup.PUT("/vpc/:vpc_id", vpcPut)
-	orgGroup.POST("/vpc", vpcPost)
-	orgGroup.DELETE("/vpc", vpcsDelete)
-	orgGroup.DELETE("/vpc/:vpc_id", vpcDelete)
-
-	orgGroup.GET("/zone", zonesGet)
-
 	engine.GET("/robots.txt", middlewear.RobotsGet)
 
 	if constants.Production {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/dropbox/godropbox/container/set"
 	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-cloud/audit"
 	"github.com/pritunl/pritunl-cloud/data"
 	"github.com/pritunl/pritunl-cloud/database"
 	"github.com/pritunl/pritunl-cloud/datacenter"
@@ -53,6 +54,8 @@ func imagePut(c *gin.Context) {
 		return
 	}
 
+	orig := audit.Snapshot(img)
+
 	img.Name = dta.Name
 
 	fields := set.NewSet(
@@ -76,6 +79,8 @@ func imagePut(c *gin.Context) {
 		return
 	}
 
+	c.Set("audit_changes", audit.Diff(orig, img, fields))
+
 	event.PublishDispatch(db, "image.change")
 
 	c.JSON(200, img)
@@ -142,13 +147,13 @@ func imageGet(c *gin.Context) {
 		return
 	}
 
-	img, err := image.GetOrg(db, userOrg, imageId)
+	img, err := image.GetOrgShared(db, userOrg, imageId)
 	if err != nil {
 		utils.AbortWithError(c, 500, err)
 		return
 	}
 
-	img.Json()
+	img.JsonOrg(userOrg)
 
 	c.JSON(200, img)
 }
@@ -187,13 +192,21 @@ func imagesGet(c *gin.Context) {
 		}
 
 		for _, img := range images {
-			img.Json()
+			img.JsonOrg(userOrg)
 		}
 
 		if dc.PrivateStorage != "" {
 			query = &bson.M{
-				"organization": userOrg,
-				"storage":      dc.PrivateStorage,
+				"$or": []*bson.M{
+					&bson.M{
+						"organization": userOrg,
+						"storage":      dc.PrivateStorage,
+					},
+					&bson.M{
+						"shares":  userOrg,
+						"storage": dc.PrivateStorage,
+					},
+				},
 			}
 
 			images2, err := image.GetAllNames(db, query)
@@ -203,7 +216,7 @@ func imagesGet(c *gin.Context) {
 			}
 
 			for _, img := range images2 {
-				img.Json()
+				img.JsonOrg(userOrg)
 				images = append(images, img)
 			}
 		}
@@ -223,6 +236,9 @@ func imagesGet(c *gin.Context) {
 						"$exists": false,
 					},
 				},
+				&bson.M{
+					"shares": userOrg,
+				},
 			},
 		}
 
@@ -245,6 +261,9 @@ func imagesGet(c *gin.Context) {
 									"$exists": false,
 								},
 							},
+							&bson.M{
+								"shares": userOrg,
+							},
 						},
 					},
 					&bson.M{
@@ -279,7 +298,7 @@ func imagesGet(c *gin.Context) {
 		}
 
 		for _, img := range images {
-			img.Json()
+			img.JsonOrg(userOrg)
 		}
 
 		dta := &imagesData{
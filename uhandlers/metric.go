@@ -0,0 +1,46 @@
+package uhandlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/instance"
+	"github.com/pritunl/pritunl-cloud/metric"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func metricInstanceGet(c *gin.Context) {
+	db := c.MustGet("db").(*database.Database)
+	userOrg := c.MustGet("organization").(bson.ObjectId)
+
+	instanceId, ok := utils.ParseObjectId(c.Param("instance_id"))
+	if !ok {
+		utils.AbortWithStatus(c, 400)
+		return
+	}
+
+	start, end, resolution, ok := metric.ParseRange(
+		c.Query("start"), c.Query("end"), c.Query("resolution"))
+	if !ok {
+		utils.AbortWithStatus(c, 400)
+		return
+	}
+
+	exists, err := instance.ExistsOrg(db, userOrg, instanceId)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+	if !exists {
+		utils.AbortWithStatus(c, 404)
+		return
+	}
+
+	points, err := metric.GetSeries(db, instanceId, resolution, start, end)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	c.JSON(200, points)
+}
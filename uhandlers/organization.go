@@ -5,7 +5,9 @@ import (
 	"github.com/pritunl/pritunl-cloud/authorizer"
 	"github.com/pritunl/pritunl-cloud/database"
 	"github.com/pritunl/pritunl-cloud/organization"
+	"github.com/pritunl/pritunl-cloud/quota"
 	"github.com/pritunl/pritunl-cloud/utils"
+	"gopkg.in/mgo.v2/bson"
 )
 
 func organizationsGet(c *gin.Context) {
@@ -26,3 +28,16 @@ func organizationsGet(c *gin.Context) {
 
 	c.JSON(200, orgs)
 }
+
+func organizationQuotaGet(c *gin.Context) {
+	db := c.MustGet("db").(*database.Database)
+	userOrg := c.MustGet("organization").(bson.ObjectId)
+
+	report, err := quota.GetReport(db, userOrg)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	c.JSON(200, report)
+}
@@ -0,0 +1,97 @@
+package uhandlers
+
+import (
+	"github.com/dropbox/godropbox/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/demo"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/spec"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"gopkg.in/mgo.v2/bson"
+	"io/ioutil"
+)
+
+type specChangesData struct {
+	Changes []*spec.Change `json:"changes"`
+}
+
+func specGet(c *gin.Context) {
+	db := c.MustGet("db").(*database.Database)
+	userOrg := c.MustGet("organization").(bson.ObjectId)
+
+	spc, err := spec.Export(db, userOrg, &spec.Options{})
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	if c.Query("format") == "json" {
+		c.JSON(200, spc)
+		return
+	}
+
+	data, err := spc.Marshal()
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	c.Data(200, "application/x-yaml", data)
+}
+
+func specRequest(c *gin.Context, apply bool) {
+	db := c.MustGet("db").(*database.Database)
+	userOrg := c.MustGet("organization").(bson.ObjectId)
+
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "handler: Failed to read spec"),
+		}
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	spc, errData := spec.Parse(body)
+	if errData != nil {
+		c.JSON(400, errData)
+		return
+	}
+
+	opts := &spec.Options{
+		Prune: c.Query("prune") == "true",
+	}
+
+	var changes []*spec.Change
+	if apply {
+		changes, errData, err = spec.Apply(db, userOrg, spc, opts)
+	} else {
+		changes, errData, err = spec.Plan(db, userOrg, spc, opts)
+	}
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	if errData != nil {
+		c.JSON(400, errData)
+		return
+	}
+
+	c.JSON(200, &specChangesData{
+		Changes: changes,
+	})
+}
+
+func specPlanPost(c *gin.Context) {
+	specRequest(c, false)
+}
+
+func specApplyPost(c *gin.Context) {
+	if demo.Blocked(c) {
+		return
+	}
+
+	specRequest(c, true)
+}
@@ -0,0 +1,213 @@
+package uhandlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-cloud/data"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/datacenter"
+	"github.com/pritunl/pritunl-cloud/demo"
+	"github.com/pritunl/pritunl-cloud/event"
+	"github.com/pritunl/pritunl-cloud/upload"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"gopkg.in/mgo.v2/bson"
+	"strconv"
+)
+
+type uploadData struct {
+	Name       string        `json:"name"`
+	Datacenter bson.ObjectId `json:"datacenter"`
+	Format     string        `json:"format"`
+	Size       int64         `json:"size"`
+	Url        string        `json:"url"`
+	Checksum   string        `json:"checksum"`
+}
+
+func uploadCreate(c *gin.Context, source string) {
+	if demo.Blocked(c) {
+		return
+	}
+
+	db := c.MustGet("db").(*database.Database)
+	userOrg := c.MustGet("organization").(bson.ObjectId)
+	dta := &uploadData{}
+
+	err := c.Bind(dta)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	upld := &upload.Upload{
+		Name:         dta.Name,
+		Organization: userOrg,
+		Datacenter:   dta.Datacenter,
+		Source:       source,
+		Format:       dta.Format,
+		Checksum:     dta.Checksum,
+		Restricted:   true,
+	}
+
+	if source == upload.ImportSource {
+		upld.Url = dta.Url
+	} else {
+		upld.Size = dta.Size
+	}
+
+	if dta.Datacenter != "" {
+		exists, e := datacenter.ExistsOrg(db, userOrg, dta.Datacenter)
+		if e != nil {
+			utils.AbortWithError(c, 500, e)
+			return
+		}
+		if !exists {
+			utils.AbortWithStatus(c, 405)
+			return
+		}
+	}
+
+	errData, err := upld.Validate(db)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	if errData != nil {
+		c.JSON(400, errData)
+		return
+	}
+
+	err = upld.Insert(db)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	if source == upload.ImportSource {
+		go func() {
+			db := database.GetDatabase()
+			defer db.Close()
+			data.ImportUpload(db, upld)
+		}()
+	}
+
+	event.PublishDispatch(db, "image_upload.change")
+
+	c.JSON(200, upld)
+}
+
+func uploadPost(c *gin.Context) {
+	uploadCreate(c, upload.UploadSource)
+}
+
+func importPost(c *gin.Context) {
+	uploadCreate(c, upload.ImportSource)
+}
+
+func uploadPut(c *gin.Context) {
+	if demo.Blocked(c) {
+		return
+	}
+
+	db := c.MustGet("db").(*database.Database)
+	userOrg := c.MustGet("organization").(bson.ObjectId)
+
+	uploadId, ok := utils.ParseObjectId(c.Param("upload_id"))
+	if !ok {
+		utils.AbortWithStatus(c, 400)
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.Query("offset"), 10, 64)
+	if err != nil {
+		utils.AbortWithStatus(c, 400)
+		return
+	}
+
+	upld, err := upload.GetOrg(db, userOrg, uploadId)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	errData, err := upld.WriteChunk(db, offset, c.Request.Body)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	if errData != nil {
+		c.JSON(400, errData)
+		return
+	}
+
+	if upld.State == upload.Processing {
+		go func() {
+			db := database.GetDatabase()
+			defer db.Close()
+			data.ImportUpload(db, upld)
+		}()
+
+		event.PublishDispatch(db, "image_upload.change")
+	}
+
+	c.JSON(200, upld)
+}
+
+func uploadGet(c *gin.Context) {
+	db := c.MustGet("db").(*database.Database)
+	userOrg := c.MustGet("organization").(bson.ObjectId)
+
+	uploadId, ok := utils.ParseObjectId(c.Param("upload_id"))
+	if !ok {
+		utils.AbortWithStatus(c, 400)
+		return
+	}
+
+	upld, err := upload.GetOrg(db, userOrg, uploadId)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	c.JSON(200, upld)
+}
+
+func uploadsGet(c *gin.Context) {
+	db := c.MustGet("db").(*database.Database)
+	userOrg := c.MustGet("organization").(bson.ObjectId)
+
+	uplds, err := upload.GetAll(db, &bson.M{
+		"organization": userOrg,
+	})
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	c.JSON(200, uplds)
+}
+
+func uploadDelete(c *gin.Context) {
+	if demo.Blocked(c) {
+		return
+	}
+
+	db := c.MustGet("db").(*database.Database)
+	userOrg := c.MustGet("organization").(bson.ObjectId)
+
+	uploadId, ok := utils.ParseObjectId(c.Param("upload_id"))
+	if !ok {
+		utils.AbortWithStatus(c, 400)
+		return
+	}
+
+	err := upload.RemoveOrg(db, userOrg, uploadId)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	event.PublishDispatch(db, "image_upload.change")
+
+	c.JSON(200, nil)
+}
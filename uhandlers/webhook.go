@@ -0,0 +1,273 @@
+package uhandlers
+
+import (
+	"fmt"
+	"github.com/dropbox/godropbox/container/set"
+	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/demo"
+	"github.com/pritunl/pritunl-cloud/event"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"github.com/pritunl/pritunl-cloud/webhook"
+	"gopkg.in/mgo.v2/bson"
+	"strconv"
+	"strings"
+)
+
+type webhookData struct {
+	Id       bson.ObjectId `json:"id"`
+	Name     string        `json:"name"`
+	Disabled bool          `json:"disabled"`
+	Url      string        `json:"url"`
+	Secret   string        `json:"secret"`
+	Events   []string      `json:"events"`
+}
+
+type webhooksData struct {
+	Webhooks []*webhook.Webhook `json:"webhooks"`
+	Count    int                `json:"count"`
+}
+
+func webhookPut(c *gin.Context) {
+	if demo.Blocked(c) {
+		return
+	}
+
+	db := c.MustGet("db").(*database.Database)
+	userOrg := c.MustGet("organization").(bson.ObjectId)
+	data := &webhookData{}
+
+	webhookId, ok := utils.ParseObjectId(c.Param("webhook_id"))
+	if !ok {
+		utils.AbortWithStatus(c, 400)
+		return
+	}
+
+	err := c.Bind(data)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	hook, err := webhook.GetOrg(db, userOrg, webhookId)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	hook.Name = data.Name
+	hook.Disabled = data.Disabled
+	hook.Url = data.Url
+	hook.Secret = data.Secret
+	hook.Events = data.Events
+
+	fields := set.NewSet(
+		"name",
+		"disabled",
+		"url",
+		"secret",
+		"events",
+	)
+
+	errData, err := hook.Validate(db)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	if errData != nil {
+		c.JSON(400, errData)
+		return
+	}
+
+	err = hook.CommitFields(db, fields)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	event.PublishDispatch(db, "webhook.change")
+
+	c.JSON(200, hook)
+}
+
+func webhookPost(c *gin.Context) {
+	if demo.Blocked(c) {
+		return
+	}
+
+	db := c.MustGet("db").(*database.Database)
+	userOrg := c.MustGet("organization").(bson.ObjectId)
+	data := &webhookData{
+		Name: "New Webhook",
+	}
+
+	err := c.Bind(data)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	hook := &webhook.Webhook{
+		Name:         data.Name,
+		Organization: userOrg,
+		Disabled:     data.Disabled,
+		Url:          data.Url,
+		Secret:       data.Secret,
+		Events:       data.Events,
+	}
+
+	errData, err := hook.Validate(db)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	if errData != nil {
+		c.JSON(400, errData)
+		return
+	}
+
+	err = hook.Insert(db)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	event.PublishDispatch(db, "webhook.change")
+
+	c.JSON(200, hook)
+}
+
+func webhookDelete(c *gin.Context) {
+	if demo.Blocked(c) {
+		return
+	}
+
+	db := c.MustGet("db").(*database.Database)
+	userOrg := c.MustGet("organization").(bson.ObjectId)
+
+	webhookId, ok := utils.ParseObjectId(c.Param("webhook_id"))
+	if !ok {
+		utils.AbortWithStatus(c, 400)
+		return
+	}
+
+	err := webhook.RemoveOrg(db, userOrg, webhookId)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	event.PublishDispatch(db, "webhook.change")
+
+	c.JSON(200, nil)
+}
+
+func webhooksDelete(c *gin.Context) {
+	if demo.Blocked(c) {
+		return
+	}
+
+	db := c.MustGet("db").(*database.Database)
+	userOrg := c.MustGet("organization").(bson.ObjectId)
+	data := []bson.ObjectId{}
+
+	err := c.Bind(&data)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	err = webhook.RemoveMultiOrg(db, userOrg, data)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	event.PublishDispatch(db, "webhook.change")
+
+	c.JSON(200, nil)
+}
+
+func webhookGet(c *gin.Context) {
+	db := c.MustGet("db").(*database.Database)
+	userOrg := c.MustGet("organization").(bson.ObjectId)
+
+	webhookId, ok := utils.ParseObjectId(c.Param("webhook_id"))
+	if !ok {
+		utils.AbortWithStatus(c, 400)
+		return
+	}
+
+	hook, err := webhook.GetOrg(db, userOrg, webhookId)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	c.JSON(200, hook)
+}
+
+func webhookDeliveriesGet(c *gin.Context) {
+	db := c.MustGet("db").(*database.Database)
+	userOrg := c.MustGet("organization").(bson.ObjectId)
+
+	webhookId, ok := utils.ParseObjectId(c.Param("webhook_id"))
+	if !ok {
+		utils.AbortWithStatus(c, 400)
+		return
+	}
+
+	hook, err := webhook.GetOrg(db, userOrg, webhookId)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	delivs, err := webhook.GetDeliveries(db, hook.Id)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	c.JSON(200, delivs)
+}
+
+func webhooksGet(c *gin.Context) {
+	db := c.MustGet("db").(*database.Database)
+	userOrg := c.MustGet("organization").(bson.ObjectId)
+
+	page, _ := strconv.Atoi(c.Query("page"))
+	pageCount, _ := strconv.Atoi(c.Query("page_count"))
+
+	query := bson.M{
+		"organization": userOrg,
+	}
+
+	webhookId, ok := utils.ParseObjectId(c.Query("id"))
+	if ok {
+		query["_id"] = webhookId
+	}
+
+	name := strings.TrimSpace(c.Query("name"))
+	if name != "" {
+		query["name"] = &bson.M{
+			"$regex":   fmt.Sprintf(".*%s.*", name),
+			"$options": "i",
+		}
+	}
+
+	hooks, count, err := webhook.GetAllPaged(db, &query, page, pageCount)
+	if err != nil {
+		utils.AbortWithError(c, 500, err)
+		return
+	}
+
+	data := &webhooksData{
+		Webhooks: hooks,
+		Count:    count,
+	}
+
+	c.JSON(200, data)
+}
@@ -0,0 +1,38 @@
+package upload
+
+import (
+	"time"
+)
+
+const (
+	UploadSource = "upload"
+	ImportSource = "import"
+
+	Uploading   = "uploading"
+	Downloading = "downloading"
+	Processing  = "processing"
+	Complete    = "complete"
+	Failed      = "failed"
+
+	Qcow2 = "qcow2"
+	Raw   = "raw"
+	Vmdk  = "vmdk"
+	Vhd   = "vhd"
+
+	MaxSize      = int64(512) << 30
+	MaxChunkSize = int64(64) << 20
+	ExpireTtl    = 24 * time.Hour
+)
+
+var (
+	Formats = map[string]string{
+		Qcow2: "qcow2",
+		Raw:   "raw",
+		Vmdk:  "vmdk",
+		Vhd:   "vpc",
+	}
+	VmdkCreateTypes = map[string]bool{
+		"monolithicSparse": true,
+		"streamOptimized":  true,
+	}
+)
@@ -0,0 +1,340 @@
+package upload
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/dropbox/godropbox/container/set"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/datacenter"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/node"
+	"github.com/pritunl/pritunl-cloud/paths"
+	"github.com/pritunl/pritunl-cloud/quota"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"gopkg.in/mgo.v2/bson"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	chunkLock = utils.NewMultiTimeoutLock(5 * time.Minute)
+)
+
+type Upload struct {
+	Id           bson.ObjectId `bson:"_id,omitempty" json:"id"`
+	Name         string        `bson:"name" json:"name"`
+	Organization bson.ObjectId `bson:"organization" json:"organization"`
+	Datacenter   bson.ObjectId `bson:"datacenter" json:"datacenter"`
+	Node         bson.ObjectId `bson:"node" json:"node"`
+	Source       string        `bson:"source" json:"source"`
+	Format       string        `bson:"format" json:"format"`
+	Url          string        `bson:"url" json:"url"`
+	Restricted   bool          `bson:"restricted" json:"-"`
+	Checksum     string        `bson:"checksum" json:"checksum"`
+	Size         int64         `bson:"size" json:"size"`
+	Received     int64         `bson:"received" json:"received"`
+	State        string        `bson:"state" json:"state"`
+	Message      string        `bson:"message" json:"message"`
+	Image        bson.ObjectId `bson:"image,omitempty" json:"image"`
+	Timestamp    time.Time     `bson:"timestamp" json:"timestamp"`
+}
+
+func (u *Upload) Validate(db *database.Database) (
+	errData *errortypes.ErrorData, err error) {
+
+	u.Name = strings.TrimSpace(u.Name)
+	u.Checksum = strings.ToLower(strings.TrimSpace(u.Checksum))
+
+	if u.Organization == "" {
+		errData = &errortypes.ErrorData{
+			Error:   "organization_required",
+			Message: "Missing required organization",
+		}
+		return
+	}
+
+	if u.Name == "" {
+		errData = &errortypes.ErrorData{
+			Error:   "name_required",
+			Message: "Missing required name",
+		}
+		return
+	}
+
+	if _, ok := Formats[u.Format]; !ok {
+		errData = &errortypes.ErrorData{
+			Error:   "format_invalid",
+			Message: "Image format must be qcow2, raw, vmdk or vhd",
+		}
+		return
+	}
+
+	if u.Checksum != "" {
+		sum, e := hex.DecodeString(u.Checksum)
+		if e != nil || len(sum) != 32 {
+			errData = &errortypes.ErrorData{
+				Error:   "checksum_invalid",
+				Message: "Checksum must be a hex encoded SHA-256 digest",
+			}
+			return
+		}
+	}
+
+	switch u.Source {
+	case UploadSource:
+		if u.Size <= 0 || u.Size > MaxSize {
+			errData = &errortypes.ErrorData{
+				Error:   "size_invalid",
+				Message: "Upload size is invalid",
+			}
+			return
+		}
+
+		if u.State == "" {
+			u.State = Uploading
+		}
+		break
+	case ImportSource:
+		importUrl, e := url.Parse(u.Url)
+		if e != nil || importUrl.Host == "" ||
+			(importUrl.Scheme != "http" && importUrl.Scheme != "https") {
+
+			errData = &errortypes.ErrorData{
+				Error:   "url_invalid",
+				Message: "Import URL must be a valid HTTP or HTTPS URL",
+			}
+			return
+		}
+
+		if u.State == "" {
+			u.State = Downloading
+		}
+		break
+	default:
+		errData = &errortypes.ErrorData{
+			Error:   "source_invalid",
+			Message: "Upload source is invalid",
+		}
+		return
+	}
+
+	if u.Datacenter == "" {
+		errData = &errortypes.ErrorData{
+			Error:   "datacenter_required",
+			Message: "Missing required datacenter",
+		}
+		return
+	}
+
+	dc, err := datacenter.Get(db, u.Datacenter)
+	if err != nil {
+		return
+	}
+
+	if dc.PrivateStorage == "" {
+		errData = &errortypes.ErrorData{
+			Error:   "datacenter_storage_missing",
+			Message: "Datacenter does not have private storage",
+		}
+		return
+	}
+
+	if u.Id == "" {
+		errData, err = quota.Check(db, u.Organization, &quota.Usage{
+			Images: 1,
+		})
+		if err != nil || errData != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (u *Upload) Path() string {
+	return path.Join(paths.GetTempPath(),
+		fmt.Sprintf("upload-%s", u.Id.Hex()))
+}
+
+func (u *Upload) IsLocal() bool {
+	return u.Node == node.Self.Id
+}
+
+// WriteChunk appends data at offset, uploads must be written sequentially
+// so an interrupted upload resumes from the received offset
+func (u *Upload) WriteChunk(db *database.Database, offset int64,
+	reader io.Reader) (errData *errortypes.ErrorData, err error) {
+
+	lockId := chunkLock.Lock(u.Id.Hex())
+	defer chunkLock.Unlock(u.Id.Hex(), lockId)
+
+	coll := db.ImagesUpload()
+	err = coll.FindOneId(u.Id, u)
+	if err != nil {
+		return
+	}
+
+	if u.State != Uploading {
+		errData = &errortypes.ErrorData{
+			Error:   "upload_state_invalid",
+			Message: "Upload is not accepting data",
+		}
+		return
+	}
+
+	if !u.IsLocal() {
+		errData = &errortypes.ErrorData{
+			Error:   "upload_node_invalid",
+			Message: "Upload chunks must be sent to the upload node",
+		}
+		return
+	}
+
+	if offset != u.Received {
+		errData = &errortypes.ErrorData{
+			Error: "upload_offset_invalid",
+			Message: fmt.Sprintf(
+				"Upload offset must be %d", u.Received),
+		}
+		return
+	}
+
+	err = utils.ExistsMkdir(paths.GetTempPath(), 0755)
+	if err != nil {
+		return
+	}
+
+	file, err := os.OpenFile(u.Path(), os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "upload: Failed to open upload file"),
+		}
+		return
+	}
+	defer file.Close()
+
+	err = file.Truncate(u.Received)
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "upload: Failed to truncate upload file"),
+		}
+		return
+	}
+
+	_, err = file.Seek(u.Received, 0)
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "upload: Failed to seek upload file"),
+		}
+		return
+	}
+
+	n, err := io.Copy(file, io.LimitReader(reader, u.Size-u.Received+1))
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "upload: Failed to write upload chunk"),
+		}
+		return
+	}
+
+	if u.Received+n > u.Size {
+		file.Truncate(u.Received)
+		errData = &errortypes.ErrorData{
+			Error:   "size_invalid",
+			Message: "Upload data exceeds upload size",
+		}
+		return
+	}
+
+	err = file.Sync()
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "upload: Failed to sync upload file"),
+		}
+		return
+	}
+
+	u.Received += n
+	u.Timestamp = time.Now()
+	fields := set.NewSet("received", "timestamp")
+	if u.Received == u.Size {
+		u.State = Processing
+		fields.Add("state")
+	}
+
+	err = u.CommitFields(db, fields)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (u *Upload) SetState(db *database.Database, state, msg string) (
+	err error) {
+
+	u.State = state
+	u.Message = msg
+	u.Timestamp = time.Now()
+
+	err = u.CommitFields(db, set.NewSet(
+		"state", "message", "image", "size", "received", "timestamp"))
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (u *Upload) Commit(db *database.Database) (err error) {
+	coll := db.ImagesUpload()
+
+	err = coll.Commit(u.Id, u)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (u *Upload) CommitFields(db *database.Database, fields set.Set) (
+	err error) {
+
+	coll := db.ImagesUpload()
+
+	err = coll.CommitFields(u.Id, u, fields)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (u *Upload) Insert(db *database.Database) (err error) {
+	coll := db.ImagesUpload()
+
+	if u.Id != "" {
+		err = &errortypes.DatabaseError{
+			errors.New("upload: Upload already exists"),
+		}
+		return
+	}
+
+	u.Id = bson.NewObjectId()
+	u.Node = node.Self.Id
+	u.Timestamp = time.Now()
+
+	err = coll.Insert(u)
+	if err != nil {
+		u.Id = ""
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
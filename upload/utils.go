@@ -0,0 +1,138 @@
+package upload
+
+import (
+	"github.com/pritunl/pritunl-cloud/database"
+	"gopkg.in/mgo.v2/bson"
+	"os"
+	"time"
+)
+
+func Get(db *database.Database, upldId bson.ObjectId) (
+	upld *Upload, err error) {
+
+	coll := db.ImagesUpload()
+	upld = &Upload{}
+
+	err = coll.FindOneId(upldId, upld)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func GetOrg(db *database.Database, orgId, upldId bson.ObjectId) (
+	upld *Upload, err error) {
+
+	coll := db.ImagesUpload()
+	upld = &Upload{}
+
+	err = coll.FindOne(&bson.M{
+		"_id":          upldId,
+		"organization": orgId,
+	}, upld)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func GetAll(db *database.Database, query *bson.M) (
+	uplds []*Upload, err error) {
+
+	coll := db.ImagesUpload()
+	uplds = []*Upload{}
+
+	cursor := coll.Find(query).Sort("-timestamp").Iter()
+
+	upld := &Upload{}
+	for cursor.Next(upld) {
+		uplds = append(uplds, upld)
+		upld = &Upload{}
+	}
+
+	err = cursor.Close()
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+func Remove(db *database.Database, upldId bson.ObjectId) (err error) {
+	coll := db.ImagesUpload()
+
+	upld, err := Get(db, upldId)
+	if err != nil {
+		return
+	}
+
+	if upld.IsLocal() {
+		os.Remove(upld.Path())
+	}
+
+	err = coll.RemoveId(upldId)
+	if err != nil {
+		err = database.ParseError(err)
+		switch err.(type) {
+		case *database.NotFoundError:
+			err = nil
+		default:
+			return
+		}
+	}
+
+	return
+}
+
+func RemoveOrg(db *database.Database, orgId, upldId bson.ObjectId) (
+	err error) {
+
+	upld, err := GetOrg(db, orgId, upldId)
+	if err != nil {
+		return
+	}
+
+	err = Remove(db, upld.Id)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// Expire fails stalled uploads and removes old finished uploads
+func Expire(db *database.Database) (err error) {
+	uplds, err := GetAll(db, &bson.M{
+		"timestamp": &bson.M{
+			"$lt": time.Now().Add(-ExpireTtl),
+		},
+	})
+	if err != nil {
+		return
+	}
+
+	for _, upld := range uplds {
+		switch upld.State {
+		case Complete, Failed:
+			err = Remove(db, upld.Id)
+			if err != nil {
+				return
+			}
+			break
+		default:
+			if upld.IsLocal() {
+				os.Remove(upld.Path())
+			}
+
+			err = upld.SetState(db, Failed, "Upload expired")
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
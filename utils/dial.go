@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"net"
+	"net/http"
+	"time"
+)
+
+var (
+	restrictedNets = []*net.IPNet{}
+)
+
+func checkRestrictedHost(host string) (ips []net.IP, err error) {
+	ips, err = net.LookupIP(host)
+	if err != nil {
+		err = &errortypes.RequestError{
+			errors.Wrapf(err, "utils: Failed to resolve host %s", host),
+		}
+		return
+	}
+
+	if len(ips) == 0 {
+		err = &errortypes.RequestError{
+			errors.Newf("utils: Failed to resolve host %s", host),
+		}
+		return
+	}
+
+	for _, ip := range ips {
+		for _, restrictedNet := range restrictedNets {
+			if restrictedNet.Contains(ip) {
+				err = &errortypes.RequestError{
+					errors.Newf("utils: Address %s not allowed", ip),
+				}
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// RestrictedDial dials only public addresses, the resolved address is
+// dialed directly to prevent a second lookup
+func RestrictedDial(network, addr string) (conn net.Conn, err error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return
+	}
+
+	ips, err := checkRestrictedHost(host)
+	if err != nil {
+		return
+	}
+
+	conn, err = net.DialTimeout(network,
+		net.JoinHostPort(ips[0].String(), port), 30*time.Second)
+	return
+}
+
+// RestrictedRedirect rejects redirects to non http schemes or private
+// addresses
+func RestrictedRedirect(req *http.Request, via []*http.Request) (
+	err error) {
+
+	if len(via) >= 10 {
+		err = &errortypes.RequestError{
+			errors.New("utils: Too many redirects"),
+		}
+		return
+	}
+
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		err = &errortypes.RequestError{
+			errors.Newf("utils: Redirect scheme %s not allowed",
+				req.URL.Scheme),
+		}
+		return
+	}
+
+	_, err = checkRestrictedHost(req.URL.Hostname())
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func init() {
+	for _, cidr := range []string{
+		"0.0.0.0/8",
+		"10.0.0.0/8",
+		"100.64.0.0/10",
+		"127.0.0.0/8",
+		"169.254.0.0/16",
+		"172.16.0.0/12",
+		"192.168.0.0/16",
+		"198.18.0.0/15",
+		"224.0.0.0/4",
+		"::/128",
+		"::1/128",
+		"fc00::/7",
+		"fe80::/10",
+		"ff00::/8",
+	} {
+		_, network, _ := net.ParseCIDR(cidr)
+		restrictedNets = append(restrictedNets, network)
+	}
+}
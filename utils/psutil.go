@@ -3,8 +3,10 @@ package utils
 import (
 	"github.com/dropbox/godropbox/errors"
 	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/shirou/gopsutil/cpu"
 	"github.com/shirou/gopsutil/load"
 	"github.com/shirou/gopsutil/mem"
+	"github.com/shirou/gopsutil/process"
 	"runtime"
 )
 
@@ -51,3 +53,57 @@ func LoadAverage() (ld *LoadStat, err error) {
 
 	return
 }
+
+func CpuUsed() (used float64, err error) {
+	percents, err := cpu.Percent(0, false)
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrapf(err, "utils: Failed to read cpu usage"),
+		}
+		return
+	}
+
+	if len(percents) > 0 {
+		used = ToFixed(percents[0], 2)
+	}
+
+	return
+}
+
+type ProcessStat struct {
+	CpuTime float64
+	Memory  uint64
+}
+
+func ProcessUsage(pid int) (stat *ProcessStat, err error) {
+	proc, err := process.NewProcess(int32(pid))
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrapf(err, "utils: Failed to find process"),
+		}
+		return
+	}
+
+	times, err := proc.Times()
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrapf(err, "utils: Failed to read process cpu times"),
+		}
+		return
+	}
+
+	memInfo, err := proc.MemoryInfo()
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrapf(err, "utils: Failed to read process memory"),
+		}
+		return
+	}
+
+	stat = &ProcessStat{
+		CpuTime: times.User + times.System,
+		Memory:  memInfo.RSS,
+	}
+
+	return
+}
@@ -0,0 +1,30 @@
+package verify
+
+const (
+	Gpg      = "gpg"
+	Minisign = "minisign"
+
+	GpgSuffix      = ".sig"
+	MinisignSuffix = ".minisig"
+
+	None     = "none"
+	Signed   = "signed"
+	Required = "required"
+
+	Unsigned   = "unsigned"
+	Unverified = "unverified"
+	Verified   = "verified"
+	Invalid    = "invalid"
+)
+
+var (
+	Suffixes = map[string]string{
+		Gpg:      GpgSuffix,
+		Minisign: MinisignSuffix,
+	}
+	Policies = map[string]bool{
+		None:     true,
+		Signed:   true,
+		Required: true,
+	}
+)
@@ -0,0 +1,189 @@
+package verify
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/ed25519"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+const (
+	minisignUntrusted = "untrusted comment:"
+	minisignTrusted   = "trusted comment: "
+)
+
+type minisignKey struct {
+	Id  [8]byte
+	Key ed25519.PublicKey
+}
+
+func (m *minisignKey) KeyId() string {
+	return fmt.Sprintf("%016X", binary.LittleEndian.Uint64(m.Id[:]))
+}
+
+type minisignSig struct {
+	Id             [8]byte
+	Signature      []byte
+	TrustedComment string
+	GlobalSig      []byte
+}
+
+func minisignLines(data string) (lines []string) {
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return
+}
+
+func parseMinisignKey(data string) (key *minisignKey, err error) {
+	lines := minisignLines(data)
+	if len(lines) > 0 && strings.HasPrefix(lines[0], minisignUntrusted) {
+		lines = lines[1:]
+	}
+
+	if len(lines) != 1 {
+		err = &errortypes.ParseError{
+			errors.New("verify: Invalid minisign public key"),
+		}
+		return
+	}
+
+	keyData, e := base64.StdEncoding.DecodeString(lines[0])
+	if e != nil || len(keyData) != 42 || string(keyData[:2]) != "Ed" {
+		err = &errortypes.ParseError{
+			errors.New("verify: Invalid minisign public key"),
+		}
+		return
+	}
+
+	key = &minisignKey{
+		Key: ed25519.PublicKey(keyData[10:]),
+	}
+	copy(key.Id[:], keyData[2:10])
+
+	return
+}
+
+func parseMinisignSig(data []byte) (sig *minisignSig, err error) {
+	lines := minisignLines(string(data))
+	if len(lines) != 4 ||
+		!strings.HasPrefix(lines[0], minisignUntrusted) ||
+		!strings.HasPrefix(lines[2], minisignTrusted) {
+
+		err = &errortypes.ParseError{
+			errors.New("verify: Invalid minisign signature"),
+		}
+		return
+	}
+
+	sigData, e := base64.StdEncoding.DecodeString(lines[1])
+	if e != nil || len(sigData) != 74 {
+		err = &errortypes.ParseError{
+			errors.New("verify: Invalid minisign signature"),
+		}
+		return
+	}
+
+	if string(sigData[:2]) != "ED" {
+		err = &errortypes.ParseError{
+			errors.New("verify: Minisign signature must be prehashed"),
+		}
+		return
+	}
+
+	globalSig, e := base64.StdEncoding.DecodeString(lines[3])
+	if e != nil || len(globalSig) != ed25519.SignatureSize {
+		err = &errortypes.ParseError{
+			errors.New("verify: Invalid minisign global signature"),
+		}
+		return
+	}
+
+	sig = &minisignSig{
+		Signature:      sigData[10:],
+		TrustedComment: strings.TrimPrefix(lines[2], minisignTrusted),
+		GlobalSig:      globalSig,
+	}
+	copy(sig.Id[:], sigData[2:10])
+
+	return
+}
+
+func verifyMinisign(keys []*minisignKey, data io.Reader,
+	sigData io.Reader) (keyId string, err error) {
+
+	sigBytes, err := ioutil.ReadAll(sigData)
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "verify: Failed to read signature"),
+		}
+		return
+	}
+
+	sig, err := parseMinisignSig(sigBytes)
+	if err != nil {
+		return
+	}
+
+	var key *minisignKey
+	for _, k := range keys {
+		if bytes.Equal(k.Id[:], sig.Id[:]) {
+			key = k
+			break
+		}
+	}
+
+	if key == nil {
+		err = &errortypes.VerificationError{
+			errors.New("verify: Signature key is not trusted"),
+		}
+		return
+	}
+
+	hash, err := blake2b.New512(nil)
+	if err != nil {
+		err = &errortypes.UnknownError{
+			errors.Wrap(err, "verify: Failed to create hash"),
+		}
+		return
+	}
+
+	_, err = io.Copy(hash, data)
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "verify: Failed to read data"),
+		}
+		return
+	}
+
+	if !ed25519.Verify(key.Key, hash.Sum(nil), sig.Signature) {
+		err = &errortypes.VerificationError{
+			errors.New("verify: Signature verification failed"),
+		}
+		return
+	}
+
+	global := append([]byte{}, sig.Signature...)
+	global = append(global, []byte(sig.TrustedComment)...)
+	if !ed25519.Verify(key.Key, global, sig.GlobalSig) {
+		err = &errortypes.VerificationError{
+			errors.New("verify: Trusted comment verification failed"),
+		}
+		return
+	}
+
+	keyId = key.KeyId()
+
+	return
+}
@@ -0,0 +1,220 @@
+package verify
+
+import (
+	"bytes"
+	"encoding/base64"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/ed25519"
+	"strings"
+	"testing"
+)
+
+var (
+	testKeyId = []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}
+	testSeed  = bytes.Repeat([]byte{0x2a}, ed25519.SeedSize)
+)
+
+func testMinisignKey() string {
+	privKey := ed25519.NewKeyFromSeed(testSeed)
+
+	data := append([]byte("Ed"), testKeyId...)
+	data = append(data, privKey.Public().(ed25519.PublicKey)...)
+
+	return "untrusted comment: minisign public key 0807060504030201\n" +
+		base64.StdEncoding.EncodeToString(data) + "\n"
+}
+
+func testMinisignSig(algo string, sigLen int, globalLen int) string {
+	data := append([]byte(algo), testKeyId...)
+	data = append(data, bytes.Repeat([]byte{0x01}, sigLen)...)
+
+	return "untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(data) + "\n" +
+		"trusted comment: timestamp:1600000000\tfile:image.qcow2\n" +
+		base64.StdEncoding.EncodeToString(
+			bytes.Repeat([]byte{0x02}, globalLen)) + "\n"
+}
+
+func testMinisignSign(keyId []byte, data, comment string) string {
+	privKey := ed25519.NewKeyFromSeed(testSeed)
+
+	hash := blake2b.Sum512([]byte(data))
+	sig := ed25519.Sign(privKey, hash[:])
+	globalSig := ed25519.Sign(privKey, append(
+		append([]byte{}, sig...), []byte(comment)...))
+
+	sigData := append([]byte("ED"), keyId...)
+	sigData = append(sigData, sig...)
+
+	return "untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(sigData) + "\n" +
+		"trusted comment: " + comment + "\n" +
+		base64.StdEncoding.EncodeToString(globalSig) + "\n"
+}
+
+func TestParseMinisignKey(t *testing.T) {
+	key := testMinisignKey()
+	keyLine := strings.Split(key, "\n")[1]
+
+	tests := []struct {
+		name  string
+		data  string
+		valid bool
+	}{
+		{"comment", key, true},
+		{"no_comment", keyLine, true},
+		{"whitespace", "\n  " + keyLine + "  \n\n", true},
+		{"empty", "", false},
+		{"comment_only", strings.Split(key, "\n")[0], false},
+		{"extra_line", key + keyLine, false},
+		{"invalid_base64", "not base64!", false},
+		{"short", base64.StdEncoding.EncodeToString(
+			[]byte("Ed12345678")), false},
+		{"algorithm", "untrusted comment: key\n" +
+			strings.Replace(keyLine, "RW", "RX", 1), false},
+	}
+
+	for _, test := range tests {
+		minKey, err := parseMinisignKey(test.data)
+		if !test.valid {
+			if err == nil {
+				t.Errorf("%s: expected error", test.name)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		if !bytes.Equal(minKey.Id[:], testKeyId) {
+			t.Errorf("%s: invalid key id %x", test.name, minKey.Id)
+		}
+
+		if minKey.KeyId() != "0807060504030201" {
+			t.Errorf("%s: invalid key id string %s",
+				test.name, minKey.KeyId())
+		}
+
+		pubKey := ed25519.NewKeyFromSeed(testSeed).Public()
+		if !bytes.Equal(minKey.Key, pubKey.(ed25519.PublicKey)) {
+			t.Errorf("%s: invalid public key", test.name)
+		}
+	}
+}
+
+func TestParseMinisignSig(t *testing.T) {
+	sig := testMinisignSig("ED", ed25519.SignatureSize,
+		ed25519.SignatureSize)
+	sigLines := strings.Split(sig, "\n")
+
+	tests := []struct {
+		name  string
+		data  string
+		valid bool
+	}{
+		{"valid", sig, true},
+		{"whitespace", "\n" + strings.Join(sigLines, "\n\n"), true},
+		{"empty", "", false},
+		{"missing_global", strings.Join(sigLines[:3], "\n"), false},
+		{"missing_untrusted", strings.Join(sigLines[1:], "\n"), false},
+		{"missing_trusted", strings.Join(
+			[]string{sigLines[0], sigLines[1], "comment",
+				sigLines[3]}, "\n"), false},
+		{"legacy", testMinisignSig("Ed", ed25519.SignatureSize,
+			ed25519.SignatureSize), false},
+		{"short_sig", testMinisignSig("ED", 32,
+			ed25519.SignatureSize), false},
+		{"short_global", testMinisignSig("ED", ed25519.SignatureSize,
+			32), false},
+		{"invalid_base64", strings.Replace(
+			sig, sigLines[1], "not base64!", 1), false},
+	}
+
+	for _, test := range tests {
+		minSig, err := parseMinisignSig([]byte(test.data))
+		if !test.valid {
+			if err == nil {
+				t.Errorf("%s: expected error", test.name)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		if !bytes.Equal(minSig.Id[:], testKeyId) {
+			t.Errorf("%s: invalid key id %x", test.name, minSig.Id)
+		}
+
+		if !bytes.Equal(minSig.Signature,
+			bytes.Repeat([]byte{0x01}, ed25519.SignatureSize)) {
+
+			t.Errorf("%s: invalid signature", test.name)
+		}
+
+		if !bytes.Equal(minSig.GlobalSig,
+			bytes.Repeat([]byte{0x02}, ed25519.SignatureSize)) {
+
+			t.Errorf("%s: invalid global signature", test.name)
+		}
+
+		if minSig.TrustedComment !=
+			"timestamp:1600000000\tfile:image.qcow2" {
+
+			t.Errorf("%s: invalid trusted comment %q",
+				test.name, minSig.TrustedComment)
+		}
+	}
+}
+
+func TestVerifyMinisign(t *testing.T) {
+	key, err := parseMinisignKey(testMinisignKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []*minisignKey{key}
+
+	data := "pritunl-cloud image data"
+	comment := "timestamp:1600000000\tfile:image.qcow2"
+	sig := testMinisignSign(testKeyId, data, comment)
+
+	tests := []struct {
+		name  string
+		data  string
+		sig   string
+		valid bool
+	}{
+		{"valid", data, sig, true},
+		{"tampered_data", data + "\n", sig, false},
+		{"untrusted_key", data, testMinisignSign(
+			[]byte{0x08, 0x07, 0x06, 0x05, 0x04, 0x03, 0x02, 0x01},
+			data, comment), false},
+		{"tampered_comment", data, strings.Replace(
+			sig, comment, "timestamp:1600000000\tfile:other.qcow2",
+			1), false},
+	}
+
+	for _, test := range tests {
+		keyId, err := verifyMinisign(keys, strings.NewReader(test.data),
+			strings.NewReader(test.sig))
+		if !test.valid {
+			if err == nil {
+				t.Errorf("%s: expected error", test.name)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		if keyId != "0807060504030201" {
+			t.Errorf("%s: invalid key id %s", test.name, keyId)
+		}
+	}
+}
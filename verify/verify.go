@@ -0,0 +1,111 @@
+package verify
+
+import (
+	"bytes"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"golang.org/x/crypto/openpgp"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+const gpgKeyHeader = "-----BEGIN PGP PUBLIC KEY BLOCK-----"
+
+type Keyring struct {
+	gpg      openpgp.EntityList
+	minisign []*minisignKey
+}
+
+func (k *Keyring) Add(key string) (err error) {
+	key = strings.TrimSpace(key)
+
+	if strings.HasPrefix(key, gpgKeyHeader) {
+		entities, e := openpgp.ReadArmoredKeyRing(strings.NewReader(key))
+		if e != nil {
+			err = &errortypes.ParseError{
+				errors.Wrap(e, "verify: Failed to parse GPG public key"),
+			}
+			return
+		}
+
+		k.gpg = append(k.gpg, entities...)
+	} else {
+		mkey, e := parseMinisignKey(key)
+		if e != nil {
+			err = e
+			return
+		}
+
+		k.minisign = append(k.minisign, mkey)
+	}
+
+	return
+}
+
+func (k *Keyring) Verify(sigType string, data, sig io.Reader) (
+	keyId string, err error) {
+
+	switch sigType {
+	case Gpg:
+		keyId, err = k.verifyGpg(data, sig)
+	case Minisign:
+		keyId, err = verifyMinisign(k.minisign, data, sig)
+	default:
+		err = &errortypes.ParseError{
+			errors.Newf("verify: Unknown signature type '%s'", sigType),
+		}
+	}
+
+	return
+}
+
+func (k *Keyring) verifyGpg(data, sig io.Reader) (keyId string, err error) {
+	if len(k.gpg) == 0 {
+		err = &errortypes.VerificationError{
+			errors.New("verify: No trusted GPG keys"),
+		}
+		return
+	}
+
+	sigBytes, err := ioutil.ReadAll(sig)
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "verify: Failed to read signature"),
+		}
+		return
+	}
+
+	var entity *openpgp.Entity
+	var e error
+	if bytes.HasPrefix(bytes.TrimSpace(sigBytes), []byte("-----BEGIN")) {
+		entity, e = openpgp.CheckArmoredDetachedSignature(
+			k.gpg, data, bytes.NewReader(sigBytes))
+	} else {
+		entity, e = openpgp.CheckDetachedSignature(
+			k.gpg, data, bytes.NewReader(sigBytes))
+	}
+	if e != nil || entity == nil {
+		err = &errortypes.VerificationError{
+			errors.Wrap(e, "verify: Signature verification failed"),
+		}
+		return
+	}
+
+	keyId = entity.PrimaryKey.KeyIdString()
+
+	return
+}
+
+func NewKeyring(keys ...string) (keyring *Keyring, err error) {
+	keyring = &Keyring{}
+
+	for _, key := range keys {
+		err = keyring.Add(key)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
@@ -0,0 +1,127 @@
+package verify
+
+import (
+	"bytes"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"strings"
+	"testing"
+)
+
+func testGpgEntity(t *testing.T) (entity *openpgp.Entity, key string) {
+	entity, err := openpgp.NewEntity("Pritunl Test", "",
+		"test@pritunl.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	writer, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = entity.Serialize(writer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key = buf.String()
+
+	return
+}
+
+func TestKeyringGpg(t *testing.T) {
+	entity, key := testGpgEntity(t)
+	other, _ := testGpgEntity(t)
+
+	keyring, err := NewKeyring(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := "pritunl-cloud image data"
+
+	sig := &bytes.Buffer{}
+	err = openpgp.DetachSign(sig, entity, strings.NewReader(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	armoredSig := &bytes.Buffer{}
+	err = openpgp.ArmoredDetachSign(armoredSig, entity,
+		strings.NewReader(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherSig := &bytes.Buffer{}
+	err = openpgp.DetachSign(otherSig, other, strings.NewReader(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		data  string
+		sig   []byte
+		valid bool
+	}{
+		{"valid", data, sig.Bytes(), true},
+		{"armored", data, armoredSig.Bytes(), true},
+		{"tampered_data", data + "\n", sig.Bytes(), false},
+		{"untrusted_key", data, otherSig.Bytes(), false},
+		{"empty", data, []byte{}, false},
+	}
+
+	for _, test := range tests {
+		keyId, err := keyring.Verify(Gpg, strings.NewReader(test.data),
+			bytes.NewReader(test.sig))
+		if !test.valid {
+			if err == nil {
+				t.Errorf("%s: expected error", test.name)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		if keyId != entity.PrimaryKey.KeyIdString() {
+			t.Errorf("%s: invalid key id %s", test.name, keyId)
+		}
+	}
+}
+
+func TestKeyringMinisign(t *testing.T) {
+	keyring, err := NewKeyring(testMinisignKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := "pritunl-cloud image data"
+	sig := testMinisignSign(testKeyId, data, "timestamp:1600000000")
+
+	keyId, err := keyring.Verify(Minisign, strings.NewReader(data),
+		strings.NewReader(sig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if keyId != "0807060504030201" {
+		t.Errorf("Invalid key id %s", keyId)
+	}
+
+	_, err = keyring.Verify(Gpg, strings.NewReader(data),
+		strings.NewReader(sig))
+	if err == nil {
+		t.Error("Expected error for keyring without GPG keys")
+	}
+}
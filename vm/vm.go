@@ -2,6 +2,8 @@ package vm
 
 import (
 	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/webhook"
+	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 	"path"
 	"strings"
@@ -17,6 +19,21 @@ type VirtualMachine struct {
 	NetworkAdapters []*NetworkAdapter `json:"network_adapters"`
 }
 
+type stateDoc struct {
+	Name         string        `bson:"name"`
+	Organization bson.ObjectId `bson:"organization"`
+	Node         bson.ObjectId `bson:"node"`
+	VmState      string        `bson:"vm_state"`
+}
+
+type stateChange struct {
+	Instance      bson.ObjectId `json:"instance"`
+	Name          string        `json:"name"`
+	Node          bson.ObjectId `json:"node"`
+	State         string        `json:"state"`
+	PreviousState string        `json:"previous_state"`
+}
+
 type Disk struct {
 	Index int    `json:"index"`
 	Path  string `json:"path"`
@@ -53,18 +70,34 @@ func (v *VirtualMachine) Commit(db *database.Database) (err error) {
 		}
 	}
 
-	err = coll.UpdateId(v.Id, &bson.M{
-		"$set": &bson.M{
-			"vm_state":    v.State,
-			"public_ips":  addrs,
-			"public_ips6": addrs6,
+	prev := &stateDoc{}
+	_, err = coll.FindId(v.Id).Apply(mgo.Change{
+		Update: &bson.M{
+			"$set": &bson.M{
+				"vm_state":    v.State,
+				"public_ips":  addrs,
+				"public_ips6": addrs6,
+			},
 		},
-	})
+	}, prev)
 	if err != nil {
 		err = database.ParseError(err)
 		if _, ok := err.(*database.NotFoundError); ok {
 			err = nil
-		} else {
+		}
+		return
+	}
+
+	if prev.VmState != v.State {
+		err = webhook.Publish(db, prev.Organization, webhook.InstanceState,
+			&stateChange{
+				Instance:      v.Id,
+				Name:          prev.Name,
+				Node:          prev.Node,
+				State:         v.State,
+				PreviousState: prev.VmState,
+			})
+		if err != nil {
 			return
 		}
 	}
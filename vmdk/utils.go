@@ -36,6 +36,12 @@ func SetRandUuid(diskPath string) (err error) {
 	}
 
 	i := bytes.Index(buffer, []byte("ddb.uuid.image="))
+	if i < 0 || i+52 > len(buffer) {
+		err = &errortypes.ParseError{
+			errors.New("vmdk: Failed to find disk uuid"),
+		}
+		return
+	}
 
 	newBuffer := append(buffer[:i+16], []byte(diskUuid.String())...)
 	newBuffer = append(newBuffer, buffer[i+52:]...)
@@ -86,6 +92,12 @@ func SetUuid(diskPath string, diskUuid string) (err error) {
 	}
 
 	i := bytes.Index(buffer, []byte("ddb.uuid.image="))
+	if i < 0 || i+52 > len(buffer) {
+		err = &errortypes.ParseError{
+			errors.New("vmdk: Failed to find disk uuid"),
+		}
+		return
+	}
 
 	newBuffer := append(buffer[:i+16], []byte(diskUuid)...)
 	newBuffer = append(newBuffer, buffer[i+52:]...)
@@ -136,6 +148,12 @@ func GetUuid(diskPath string) (diskUuid string, err error) {
 	}
 
 	i := bytes.Index(buffer, []byte("ddb.uuid.image="))
+	if i < 0 || i+52 > len(buffer) {
+		err = &errortypes.ParseError{
+			errors.New("vmdk: Failed to find disk uuid"),
+		}
+		return
+	}
 
 	diskUuid = string(buffer[i+16 : i+52])
 
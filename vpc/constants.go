@@ -4,3 +4,16 @@ const (
 	Instance = "instance"
 	Gateway  = "gateway"
 )
+
+const (
+	Psk         = "psk"
+	Certificate = "certificate"
+)
+
+const (
+	Priority = "priority"
+	Ecmp     = "ecmp"
+
+	LinkNodeMax = 8
+	RouteMetric = 97
+)
@@ -0,0 +1,14 @@
+package vpc
+
+import (
+	"gopkg.in/mgo.v2/bson"
+	"time"
+)
+
+type LinkHolder struct {
+	Id        bson.ObjectId `bson:"_id,omitempty"`
+	Vpc       bson.ObjectId `bson:"vpc"`
+	Node      bson.ObjectId `bson:"node"`
+	Priority  int           `bson:"priority"`
+	Timestamp time.Time     `bson:"timestamp"`
+}
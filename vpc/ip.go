@@ -8,4 +8,5 @@ type VpcIp struct {
 	Ip       int64         `bson:"ip"`
 	Type     string        `bson:"type"`
 	Instance bson.ObjectId `bson:"instance"`
+	Static   bool          `bson:"static"`
 }
@@ -0,0 +1,195 @@
+package vpc
+
+import (
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"gopkg.in/mgo.v2/bson"
+	"net"
+	"regexp"
+	"strings"
+)
+
+var (
+	proposalRe = regexp.MustCompile("^[a-z0-9_\\-!,]*$")
+	remoteIdRe = regexp.MustCompile("^[a-zA-Z0-9_\\-=@.,: ]*$")
+	hostnameRe = regexp.MustCompile(
+		"^([a-zA-Z0-9]([a-zA-Z0-9\\-]{0,61}[a-zA-Z0-9])?\\.)*" +
+			"[a-zA-Z0-9]([a-zA-Z0-9\\-]{0,61}[a-zA-Z0-9])?$")
+)
+
+type Peer struct {
+	Id            bson.ObjectId `bson:"id" json:"id"`
+	Name          string        `bson:"name" json:"name"`
+	Remote        string        `bson:"remote" json:"remote"`
+	RemoteId      string        `bson:"remote_id" json:"remote_id"`
+	LocalSubnets  []string      `bson:"local_subnets" json:"local_subnets"`
+	RemoteSubnets []string      `bson:"remote_subnets" json:"remote_subnets"`
+	IkeProposal   string        `bson:"ike_proposal" json:"ike_proposal"`
+	EspProposal   string        `bson:"esp_proposal" json:"esp_proposal"`
+	Auth          string        `bson:"auth" json:"auth"`
+	PreSharedKey  string        `bson:"pre_shared_key" json:"pre_shared_key"`
+	RemoteCa      string        `bson:"remote_ca" json:"remote_ca"`
+}
+
+func parseSubnets(subnets []string) (parsed []string, valid bool) {
+	parsed = []string{}
+
+	for _, subnet := range subnets {
+		subnet = strings.TrimSpace(subnet)
+		if subnet == "" {
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(subnet)
+		if err != nil {
+			return
+		}
+
+		parsed = append(parsed, network.String())
+	}
+
+	valid = true
+	return
+}
+
+func validRemote(remote string) bool {
+	if net.ParseIP(remote) != nil {
+		return true
+	}
+
+	return len(remote) <= 253 && hostnameRe.MatchString(remote)
+}
+
+// MergePeers keeps the stored pre-shared key of peers submitted without one
+func MergePeers(cur, peers []*Peer) {
+	curPeers := map[bson.ObjectId]*Peer{}
+	for _, peer := range cur {
+		curPeers[peer.Id] = peer
+	}
+
+	for _, peer := range peers {
+		if peer.PreSharedKey != "" {
+			continue
+		}
+
+		curPeer := curPeers[peer.Id]
+		if curPeer != nil {
+			peer.PreSharedKey = curPeer.PreSharedKey
+		}
+	}
+}
+
+func (p *Peer) Json() {
+	p.PreSharedKey = ""
+}
+
+func (p *Peer) Validate(vc *Vpc) (errData *errortypes.ErrorData) {
+	if p.Id == "" {
+		p.Id = bson.NewObjectId()
+	}
+
+	p.Name = strings.TrimSpace(p.Name)
+	p.Remote = strings.TrimSpace(p.Remote)
+	p.RemoteId = strings.TrimSpace(p.RemoteId)
+	p.IkeProposal = strings.TrimSpace(p.IkeProposal)
+	p.EspProposal = strings.TrimSpace(p.EspProposal)
+
+	if !validRemote(p.Remote) {
+		errData = &errortypes.ErrorData{
+			Error:   "peer_remote_invalid",
+			Message: "Peer remote address invalid",
+		}
+		return
+	}
+
+	remoteIp := net.ParseIP(p.Remote)
+	if remoteIp != nil {
+		p.Remote = remoteIp.String()
+	}
+
+	if !remoteIdRe.MatchString(p.RemoteId) {
+		errData = &errortypes.ErrorData{
+			Error:   "peer_remote_id_invalid",
+			Message: "Peer remote ID invalid",
+		}
+		return
+	}
+
+	localSubnets, valid := parseSubnets(p.LocalSubnets)
+	if !valid {
+		errData = &errortypes.ErrorData{
+			Error:   "peer_local_subnets_invalid",
+			Message: "Peer local subnet invalid",
+		}
+		return
+	}
+	if len(localSubnets) == 0 {
+		localSubnets = append(localSubnets, vc.Network)
+	}
+	p.LocalSubnets = localSubnets
+
+	remoteSubnets, valid := parseSubnets(p.RemoteSubnets)
+	if !valid {
+		errData = &errortypes.ErrorData{
+			Error:   "peer_remote_subnets_invalid",
+			Message: "Peer remote subnet invalid",
+		}
+		return
+	}
+	if len(remoteSubnets) == 0 {
+		errData = &errortypes.ErrorData{
+			Error:   "peer_remote_subnets_required",
+			Message: "Peer remote subnets required",
+		}
+		return
+	}
+	p.RemoteSubnets = remoteSubnets
+
+	if !proposalRe.MatchString(p.IkeProposal) {
+		errData = &errortypes.ErrorData{
+			Error:   "peer_ike_proposal_invalid",
+			Message: "Peer IKE proposal invalid",
+		}
+		return
+	}
+
+	if !proposalRe.MatchString(p.EspProposal) {
+		errData = &errortypes.ErrorData{
+			Error:   "peer_esp_proposal_invalid",
+			Message: "Peer ESP proposal invalid",
+		}
+		return
+	}
+
+	switch p.Auth {
+	case "":
+		p.Auth = Psk
+		break
+	case Psk, Certificate:
+		break
+	default:
+		errData = &errortypes.ErrorData{
+			Error:   "peer_auth_invalid",
+			Message: "Peer authentication mode invalid",
+		}
+		return
+	}
+
+	if p.Auth == Psk {
+		p.RemoteCa = ""
+
+		if p.PreSharedKey == "" ||
+			strings.ContainsAny(p.PreSharedKey, "\"\n") {
+
+			errData = &errortypes.ErrorData{
+				Error:   "peer_pre_shared_key_invalid",
+				Message: "Peer pre-shared key invalid",
+			}
+			return
+		}
+	} else {
+		p.PreSharedKey = ""
+		p.RemoteCa = strings.TrimSpace(p.RemoteCa)
+	}
+
+	return
+}
@@ -203,6 +203,16 @@ func Remove(db *database.Database, vcId bson.ObjectId) (err error) {
 		return
 	}
 
+	coll = db.VpcsLink()
+
+	_, err = coll.RemoveAll(&bson.M{
+		"vpc": vcId,
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
 	coll = db.Vpcs()
 
 	err = coll.Remove(&bson.M{
@@ -232,6 +242,16 @@ func RemoveOrg(db *database.Database, orgId, vcId bson.ObjectId) (err error) {
 		return
 	}
 
+	coll = db.VpcsLink()
+
+	_, err = coll.RemoveAll(&bson.M{
+		"vpc": vcId,
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
 	coll = db.Vpcs()
 
 	err = coll.Remove(&bson.M{
@@ -264,6 +284,18 @@ func RemoveMulti(db *database.Database, vcIds []bson.ObjectId) (err error) {
 		return
 	}
 
+	coll = db.VpcsLink()
+
+	_, err = coll.RemoveAll(&bson.M{
+		"vpc": &bson.M{
+			"$in": vcIds,
+		},
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
 	coll = db.Vpcs()
 
 	_, err = coll.RemoveAll(&bson.M{
@@ -309,12 +341,13 @@ func RemoveInstanceIp(db *database.Database, instId, vpcId bson.ObjectId) (
 
 	coll := db.VpcsIp()
 
-	err = coll.Update(&bson.M{
+	_, err = coll.UpdateAll(&bson.M{
 		"vpc":      vpcId,
 		"instance": instId,
 	}, &bson.M{
 		"$set": &bson.M{
 			"instance": nil,
+			"static":   false,
 		},
 	})
 	if err != nil {
@@ -329,3 +362,25 @@ func RemoveInstanceIp(db *database.Database, instId, vpcId bson.ObjectId) (
 
 	return
 }
+
+func ReleaseStaticIp(db *database.Database, instId, vpcId bson.ObjectId) (
+	err error) {
+
+	coll := db.VpcsIp()
+
+	_, err = coll.UpdateAll(&bson.M{
+		"vpc":      vpcId,
+		"instance": instId,
+		"static":   true,
+	}, &bson.M{
+		"$set": &bson.M{
+			"static": false,
+		},
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
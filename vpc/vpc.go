@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"crypto/md5"
 	"fmt"
+	"github.com/Sirupsen/logrus"
 	"github.com/dropbox/godropbox/container/set"
 	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/certificate"
 	"github.com/pritunl/pritunl-cloud/database"
 	"github.com/pritunl/pritunl-cloud/errortypes"
 	"github.com/pritunl/pritunl-cloud/node"
+	"github.com/pritunl/pritunl-cloud/settings"
 	"github.com/pritunl/pritunl-cloud/utils"
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
@@ -19,9 +22,11 @@ import (
 )
 
 type Route struct {
-	Destination string `bson:"destination" json:"destination"`
-	Target      string `bson:"target" json:"target"`
-	Link        bool   `bson:"link" json:"link"`
+	Destination string        `bson:"destination" json:"destination"`
+	Target      string        `bson:"target" json:"target"`
+	Link        bool          `bson:"link" json:"link"`
+	Node        bson.ObjectId `bson:"node,omitempty" json:"node"`
+	Priority    int           `bson:"priority" json:"priority"`
 }
 
 type Vpc struct {
@@ -34,6 +39,13 @@ type Vpc struct {
 	Datacenter    bson.ObjectId `bson:"datacenter" json:"datacenter"`
 	Routes        []*Route      `bson:"routes" json:"routes"`
 	LinkUris      []string      `bson:"link_uris" json:"link_uris"`
+	LinkAuth      string        `bson:"link_auth" json:"link_auth"`
+	LinkCert      bson.ObjectId `bson:"link_cert,omitempty" json:"link_cert"`
+	LinkCaCert    string        `bson:"link_ca_cert" json:"link_ca_cert"`
+	LinkCaKey     string        `bson:"link_ca_key" json:"-"`
+	LinkPeers     []*Peer       `bson:"link_peers" json:"link_peers"`
+	LinkNodeCount int           `bson:"link_node_count" json:"link_node_count"`
+	LinkRouting   string        `bson:"link_routing" json:"link_routing"`
 	LinkNode      bson.ObjectId `bson:"link_node,omitempty" json:"link_node"`
 	LinkTimestamp time.Time     `bson:"link_timestamp" json:"link_timestamp"`
 }
@@ -101,16 +113,99 @@ func (v *Vpc) Validate(db *database.Database) (
 	}
 	v.LinkUris = linkUris
 
-	destinations := set.NewSet()
-	for _, route := range v.Routes {
-		if destinations.Contains(route.Destination) {
+	switch v.LinkAuth {
+	case "":
+		v.LinkAuth = Psk
+		break
+	case Psk, Certificate:
+		break
+	default:
+		errData = &errortypes.ErrorData{
+			Error:   "link_auth_invalid",
+			Message: "Link authentication mode invalid",
+		}
+		return
+	}
+
+	if v.LinkAuth != Certificate {
+		v.LinkCert = ""
+	}
+
+	if v.LinkPeers == nil {
+		v.LinkPeers = []*Peer{}
+	}
+
+	for _, peer := range v.LinkPeers {
+		errData = peer.Validate(v)
+		if errData != nil {
+			return
+		}
+	}
+
+	if v.LinkNodeCount == 0 {
+		v.LinkNodeCount = 1
+	}
+
+	if v.LinkNodeCount < 1 || v.LinkNodeCount > LinkNodeMax {
+		errData = &errortypes.ErrorData{
+			Error:   "link_node_count_invalid",
+			Message: "Link node count invalid",
+		}
+		return
+	}
+
+	switch v.LinkRouting {
+	case "":
+		v.LinkRouting = Priority
+		break
+	case Priority, Ecmp:
+		break
+	default:
+		errData = &errortypes.ErrorData{
+			Error:   "link_routing_invalid",
+			Message: "Link routing mode invalid",
+		}
+		return
+	}
+
+	if v.LinkCert != "" {
+		cert, e := certificate.Get(db, v.LinkCert)
+		if e != nil {
+			if _, ok := e.(*database.NotFoundError); ok {
+				errData = &errortypes.ErrorData{
+					Error:   "link_cert_invalid",
+					Message: "Link certificate not found",
+				}
+			} else {
+				err = e
+			}
+			return
+		}
+
+		if cert.Certificate == "" || cert.Key == "" {
 			errData = &errortypes.ErrorData{
-				Error:   "duplicate_destination",
-				Message: "Duplicate route destinations",
+				Error:   "link_cert_invalid",
+				Message: "Link certificate missing key or certificate",
 			}
 			return
 		}
-		destinations.Add(route.Destination)
+	}
+
+	destinations := set.NewSet()
+	for _, route := range v.Routes {
+		if !route.Link {
+			if destinations.Contains(route.Destination) {
+				errData = &errortypes.ErrorData{
+					Error:   "duplicate_destination",
+					Message: "Duplicate route destinations",
+				}
+				return
+			}
+			destinations.Add(route.Destination)
+
+			route.Node = ""
+			route.Priority = 0
+		}
 
 		if strings.Contains(route.Destination, ":") !=
 			strings.Contains(route.Target, ":") {
@@ -196,6 +291,10 @@ func (v *Vpc) Json() {
 	}
 
 	v.Network6 = ipBuf.String() + "::/64"
+
+	for _, peer := range v.LinkPeers {
+		peer.Json()
+	}
 }
 
 func (v *Vpc) GetNetwork() (network *net.IPNet, err error) {
@@ -286,6 +385,9 @@ func (v *Vpc) GetIp(db *database.Database, typ string, instId bson.ObjectId) (
 			"vpc":      v.Id,
 			"type":     typ,
 			"instance": nil,
+			"static": &bson.M{
+				"$ne": true,
+			},
 		}).Apply(change, vpcIp)
 		if e != nil {
 			err = database.ParseError(e)
@@ -313,6 +415,9 @@ func (v *Vpc) GetIp(db *database.Database, typ string, instId bson.ObjectId) (
 		err = coll.Find(&bson.M{
 			"vpc":  v.Id,
 			"type": typ,
+			"static": &bson.M{
+				"$ne": true,
+			},
 		}).Sort(sort).One(vpcIp)
 		if err != nil {
 			vpcIp = nil
@@ -396,6 +501,164 @@ func (v *Vpc) GetIp(db *database.Database, typ string, instId bson.ObjectId) (
 	return
 }
 
+func (v *Vpc) ValidateIp(db *database.Database, addr string,
+	instId bson.ObjectId) (errData *errortypes.ErrorData, err error) {
+
+	ip := net.ParseIP(addr)
+	if ip == nil || ip.To4() == nil {
+		errData = &errortypes.ErrorData{
+			Error:   "static_ip_invalid",
+			Message: "Static IP address invalid",
+		}
+		return
+	}
+	ip = ip.To4()
+
+	network, err := v.GetNetwork()
+	if err != nil {
+		return
+	}
+
+	if !network.Contains(ip) {
+		errData = &errortypes.ErrorData{
+			Error:   "static_ip_invalid_network",
+			Message: "Static IP address not in VPC network",
+		}
+		return
+	}
+
+	gateway, err := v.GetGateway()
+	if err != nil {
+		return
+	}
+
+	if ip.Equal(network.IP) || ip.Equal(gateway) ||
+		ip.Equal(utils.GetLastIpAddress(network)) {
+
+		errData = &errortypes.ErrorData{
+			Error:   "static_ip_reserved",
+			Message: "Static IP address is reserved",
+		}
+		return
+	}
+
+	coll := db.VpcsIp()
+	vpcIp := &VpcIp{}
+
+	err = coll.FindOne(&bson.M{
+		"vpc": v.Id,
+		"ip":  utils.IpAddress2Int(ip),
+	}, vpcIp)
+	if err != nil {
+		vpcIp = nil
+		if _, ok := err.(*database.NotFoundError); ok {
+			err = nil
+		} else {
+			return
+		}
+	}
+
+	if vpcIp != nil {
+		if vpcIp.Type != Instance {
+			errData = &errortypes.ErrorData{
+				Error:   "static_ip_reserved",
+				Message: "Static IP address is reserved",
+			}
+			return
+		}
+
+		if vpcIp.Instance != "" && vpcIp.Instance != instId {
+			errData = &errortypes.ErrorData{
+				Error:   "static_ip_in_use",
+				Message: "Static IP address in use by another instance",
+			}
+			return
+		}
+	}
+
+	return
+}
+
+func (v *Vpc) ReserveIp(db *database.Database, instId bson.ObjectId,
+	addr string) (err error) {
+
+	coll := db.VpcsIp()
+
+	ip := net.ParseIP(addr)
+	if ip == nil || ip.To4() == nil {
+		err = &errortypes.ParseError{
+			errors.New("vpc: Failed to parse static address"),
+		}
+		return
+	}
+	ipInt := utils.IpAddress2Int(ip.To4())
+
+	_, err = coll.UpdateAll(&bson.M{
+		"vpc":      v.Id,
+		"type":     Instance,
+		"instance": instId,
+		"ip": &bson.M{
+			"$ne": ipInt,
+		},
+	}, &bson.M{
+		"$set": &bson.M{
+			"instance": nil,
+			"static":   false,
+		},
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	vpcIp := &VpcIp{
+		Vpc:      v.Id,
+		Type:     Instance,
+		Ip:       ipInt,
+		Instance: instId,
+		Static:   true,
+	}
+
+	err = coll.Insert(vpcIp)
+	if err != nil {
+		err = database.ParseError(err)
+		if _, ok := err.(*database.DuplicateKeyError); !ok {
+			return
+		}
+		err = nil
+	} else {
+		return
+	}
+
+	err = coll.Update(&bson.M{
+		"vpc":  v.Id,
+		"ip":   ipInt,
+		"type": Instance,
+		"instance": &bson.M{
+			"$in": []interface{}{
+				nil,
+				instId,
+			},
+		},
+	}, &bson.M{
+		"$set": &bson.M{
+			"instance": instId,
+			"static":   true,
+		},
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		if _, ok := err.(*database.NotFoundError); ok {
+			err = &errortypes.NotFoundError{
+				errors.New("vpc: Static address unavailable"),
+			}
+		}
+		return
+	}
+
+	return
+}
+
 func (v *Vpc) GetIp6(addr net.IP) net.IP {
 	netHash := md5.New()
 	netHash.Write([]byte(v.Id))
@@ -418,6 +681,99 @@ func (v *Vpc) GetIp6(addr net.IP) net.IP {
 	return net.ParseIP(ipBuf.String())
 }
 
+func (v *Vpc) HasLinks() bool {
+	return (v.LinkUris != nil && len(v.LinkUris) > 0) ||
+		(v.LinkPeers != nil && len(v.LinkPeers) > 0)
+}
+
+func (v *Vpc) HasLinkCert() bool {
+	if v.LinkAuth == Certificate {
+		return true
+	}
+
+	if v.LinkPeers != nil {
+		for _, peer := range v.LinkPeers {
+			if peer.Auth == Certificate {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (v *Vpc) GetLinkCa(db *database.Database) (
+	caCert, caKey string, err error) {
+
+	if v.LinkCaCert != "" && v.LinkCaKey != "" {
+		expires, e := certificate.GetExpiration(v.LinkCaCert)
+		if e == nil && time.Until(expires) > time.Duration(
+			settings.Ipsec.CaRenew)*time.Hour {
+
+			caCert = v.LinkCaCert
+			caKey = v.LinkCaKey
+			return
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"vpc_id": v.Id.Hex(),
+	}).Info("vpc: Generating link certificate authority")
+
+	newCaCert, newCaKey, err := certificate.NewCa(
+		fmt.Sprintf("vpc-%s", v.Id.Hex()),
+		time.Duration(settings.Ipsec.CaLifetime)*time.Hour,
+	)
+	if err != nil {
+		return
+	}
+
+	coll := db.Vpcs()
+
+	query := bson.M{
+		"_id":          v.Id,
+		"link_ca_cert": v.LinkCaCert,
+	}
+	if v.LinkCaCert == "" {
+		query["link_ca_cert"] = &bson.M{
+			"$in": []interface{}{
+				"",
+				nil,
+			},
+		}
+	}
+
+	err = coll.Update(query, &bson.M{
+		"$set": &bson.M{
+			"link_ca_cert": newCaCert,
+			"link_ca_key":  newCaKey,
+		},
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		if _, ok := err.(*database.NotFoundError); !ok {
+			return
+		}
+		err = nil
+
+		vc, e := Get(db, v.Id)
+		if e != nil {
+			err = e
+			return
+		}
+
+		newCaCert = vc.LinkCaCert
+		newCaKey = vc.LinkCaKey
+	}
+
+	v.LinkCaCert = newCaCert
+	v.LinkCaKey = newCaKey
+	caCert = newCaCert
+	caKey = newCaKey
+
+	return
+}
+
 func (v *Vpc) PingLink(db *database.Database) (held bool, err error) {
 	coll := db.Vpcs()
 
@@ -450,31 +806,292 @@ func (v *Vpc) PingLink(db *database.Database) (held bool, err error) {
 	return
 }
 
+func (v *Vpc) IsLinkMulti() bool {
+	return v.LinkNodeCount > 1
+}
+
+func (v *Vpc) GetLinkIp(db *database.Database) (ip net.IP, err error) {
+	instId := v.Id
+	if v.IsLinkMulti() {
+		instId = node.Self.Id
+	}
+
+	ip, err = v.GetIp(db, Gateway, instId)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (v *Vpc) GetLinkHolders(db *database.Database) (
+	holders []*LinkHolder, err error) {
+
+	coll := db.VpcsLink()
+	holders = []*LinkHolder{}
+
+	cursor := coll.Find(&bson.M{
+		"vpc": v.Id,
+		"timestamp": &bson.M{
+			"$gte": time.Now().Add(-time.Duration(
+				settings.Ipsec.LinkTimeout) * time.Second),
+		},
+	}).Sort("priority").Iter()
+
+	holder := &LinkHolder{}
+	for cursor.Next(holder) {
+		holders = append(holders, holder)
+		holder = &LinkHolder{}
+	}
+
+	err = cursor.Close()
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+func (v *Vpc) GetLinkHolder(db *database.Database) (
+	holder *LinkHolder, err error) {
+
+	coll := db.VpcsLink()
+	holder = &LinkHolder{}
+
+	err = coll.FindOne(&bson.M{
+		"vpc":  v.Id,
+		"node": node.Self.Id,
+	}, holder)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (v *Vpc) clearLinkHolders(db *database.Database) (err error) {
+	coll := db.VpcsLink()
+
+	stale := []*LinkHolder{}
+	err = coll.Find(&bson.M{
+		"vpc": v.Id,
+		"$or": []*bson.M{
+			&bson.M{
+				"timestamp": &bson.M{
+					"$lt": time.Now().Add(-time.Duration(
+						settings.Ipsec.LinkTimeout) * time.Second),
+				},
+			},
+			&bson.M{
+				"priority": &bson.M{
+					"$gte": v.LinkNodeCount,
+				},
+			},
+		},
+	}).All(&stale)
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	for _, holder := range stale {
+		err = coll.Remove(&bson.M{
+			"_id":       holder.Id,
+			"timestamp": holder.Timestamp,
+		})
+		if err != nil {
+			err = database.ParseError(err)
+			if _, ok := err.(*database.NotFoundError); ok {
+				err = nil
+				continue
+			}
+			return
+		}
+
+		err = db.Vpcs().Update(&bson.M{
+			"_id": v.Id,
+		}, &bson.M{
+			"$pull": &bson.M{
+				"routes": &bson.M{
+					"link": true,
+					"node": holder.Node,
+				},
+			},
+		})
+		if err != nil {
+			err = database.ParseError(err)
+			if _, ok := err.(*database.NotFoundError); ok {
+				err = nil
+			} else {
+				return
+			}
+		}
+
+		err = RemoveInstanceIp(db, holder.Node, v.Id)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (v *Vpc) PingLinkHolder(db *database.Database) (held bool, err error) {
+	coll := db.VpcsLink()
+
+	err = v.clearLinkHolders(db)
+	if err != nil {
+		return
+	}
+
+	err = coll.Update(&bson.M{
+		"vpc":  v.Id,
+		"node": node.Self.Id,
+	}, &bson.M{
+		"$set": &bson.M{
+			"timestamp": time.Now(),
+		},
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		if _, ok := err.(*database.NotFoundError); ok {
+			err = nil
+		} else {
+			return
+		}
+	} else {
+		held = true
+		return
+	}
+
+	holders, err := v.GetLinkHolders(db)
+	if err != nil {
+		return
+	}
+
+	if len(holders) >= v.LinkNodeCount {
+		return
+	}
+
+	priorities := set.NewSet()
+	for _, holder := range holders {
+		priorities.Add(holder.Priority)
+	}
+
+	priority := 0
+	for priorities.Contains(priority) {
+		priority += 1
+	}
+
+	err = coll.Insert(&LinkHolder{
+		Vpc:       v.Id,
+		Node:      node.Self.Id,
+		Priority:  priority,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		if _, ok := err.(*database.DuplicateKeyError); ok {
+			err = nil
+		}
+		return
+	}
+
+	held = true
+
+	return
+}
+
 func (v *Vpc) AddLinkRoutes(db *database.Database, routes []*Route) (
 	err error) {
 
+	coll := db.Vpcs()
+
 	vc, err := Get(db, v.Id)
 	if err != nil {
 		return
 	}
 
-	linkDsts := set.NewSet()
+	linkDsts := []string{}
 	for _, route := range routes {
-		linkDsts.Add(route.Destination)
+		linkDsts = append(linkDsts, route.Destination)
 	}
 
-	for _, route := range vc.Routes {
-		if route.Link || linkDsts.Contains(route.Destination) {
-			continue
+	holderNodes := []bson.ObjectId{}
+	if vc.IsLinkMulti() {
+		holder, e := vc.GetLinkHolder(db)
+		if e != nil {
+			err = e
+			return
 		}
 
-		routes = append(routes, route)
+		for _, route := range routes {
+			route.Node = node.Self.Id
+			if vc.LinkRouting == Priority {
+				route.Priority = holder.Priority
+			}
+		}
+
+		holders, e := vc.GetLinkHolders(db)
+		if e != nil {
+			err = e
+			return
+		}
+
+		for _, hldr := range holders {
+			if hldr.Node != node.Self.Id {
+				holderNodes = append(holderNodes, hldr.Node)
+			}
+		}
 	}
 
-	vc.Routes = routes
+	// Other link nodes update their own routes concurrently, only remove
+	// routes from this node and nodes no longer holding the link
+	err = coll.Update(&bson.M{
+		"_id": v.Id,
+	}, &bson.M{
+		"$pull": &bson.M{
+			"routes": &bson.M{
+				"$or": []*bson.M{
+					&bson.M{
+						"link": true,
+						"node": &bson.M{
+							"$nin": holderNodes,
+						},
+					},
+					&bson.M{
+						"link": &bson.M{
+							"$ne": true,
+						},
+						"destination": &bson.M{
+							"$in": linkDsts,
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	if len(routes) == 0 {
+		return
+	}
 
-	err = vc.CommitFields(db, set.NewSet("routes"))
+	err = coll.Update(&bson.M{
+		"_id": v.Id,
+	}, &bson.M{
+		"$push": &bson.M{
+			"routes": &bson.M{
+				"$each": routes,
+			},
+		},
+	})
 	if err != nil {
+		err = database.ParseError(err)
 		return
 	}
 
@@ -515,8 +1132,11 @@ func (v *Vpc) Insert(db *database.Database) (err error) {
 		return
 	}
 
+	v.Id = bson.NewObjectId()
+
 	err = coll.Insert(v)
 	if err != nil {
+		v.Id = ""
 		err = database.ParseError(err)
 		return
 	}
@@ -0,0 +1,29 @@
+package webhook
+
+import (
+	"github.com/dropbox/godropbox/container/set"
+)
+
+const (
+	InstanceState    = "instance.state"
+	DiskSnapshot     = "disk.snapshot"
+	NodeOffline      = "node.offline"
+	CertificateRenew = "certificate.renew"
+	AuditEntry       = "audit.entry"
+
+	Pending   = "pending"
+	Delivered = "delivered"
+	Failed    = "failed"
+
+	MaxAttempts = 8
+)
+
+var (
+	Events = set.NewSet(
+		InstanceState,
+		DiskSnapshot,
+		NodeOffline,
+		CertificateRenew,
+		AuditEntry,
+	)
+)
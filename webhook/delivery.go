@@ -0,0 +1,168 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/base64"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"gopkg.in/mgo.v2/bson"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	client = &http.Client{
+		Timeout: 20 * time.Second,
+	}
+	restrictedClient = &http.Client{
+		Transport: &http.Transport{
+			Dial: utils.RestrictedDial,
+		},
+		CheckRedirect: utils.RestrictedRedirect,
+		Timeout:       20 * time.Second,
+	}
+)
+
+type Delivery struct {
+	Id           bson.ObjectId `bson:"_id,omitempty" json:"id"`
+	Webhook      bson.ObjectId `bson:"webhook" json:"webhook"`
+	Organization bson.ObjectId `bson:"organization,omitempty" json:"organization"`
+	Event        string        `bson:"event" json:"event"`
+	Payload      string        `bson:"payload" json:"payload"`
+	State        string        `bson:"state" json:"state"`
+	Attempts     int           `bson:"attempts" json:"attempts"`
+	Timestamp    time.Time     `bson:"timestamp" json:"timestamp"`
+	NextAttempt  time.Time     `bson:"next_attempt" json:"next_attempt"`
+	ResponseCode int           `bson:"response_code" json:"response_code"`
+	Error        string        `bson:"error" json:"error"`
+}
+
+func (d *Delivery) sign(secret string, timestamp time.Time) string {
+	authString := strings.Join([]string{
+		d.Id.Hex(),
+		strconv.FormatInt(timestamp.Unix(), 10),
+		d.Payload,
+	}, "&")
+
+	hashFunc := hmac.New(sha512.New, []byte(secret))
+	hashFunc.Write([]byte(authString))
+	rawSignature := hashFunc.Sum(nil)
+
+	return base64.StdEncoding.EncodeToString(rawSignature)
+}
+
+func (d *Delivery) send(hook *Webhook) (code int, err error) {
+	timestamp := time.Now()
+
+	req, err := http.NewRequest(
+		"POST",
+		hook.Url,
+		bytes.NewBufferString(d.Payload),
+	)
+	if err != nil {
+		err = &errortypes.RequestError{
+			errors.Wrap(err, "webhook: Failed to create request"),
+		}
+		return
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "pritunl-cloud")
+	req.Header.Set("Pritunl-Delivery", d.Id.Hex())
+	req.Header.Set("Pritunl-Event", d.Event)
+	req.Header.Set("Pritunl-Timestamp",
+		strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set("Pritunl-Signature", d.sign(hook.Secret, timestamp))
+
+	clnt := client
+	if hook.Organization != "" {
+		clnt = restrictedClient
+	}
+
+	resp, err := clnt.Do(req)
+	if err != nil {
+		err = &errortypes.RequestError{
+			errors.Wrap(err, "webhook: Request failed"),
+		}
+		return
+	}
+	defer resp.Body.Close()
+
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 65536))
+
+	code = resp.StatusCode
+	if code < 200 || code >= 300 {
+		err = &errortypes.RequestError{
+			errors.Newf("webhook: Bad status %d", code),
+		}
+		return
+	}
+
+	return
+}
+
+func (d *Delivery) Insert(db *database.Database) (err error) {
+	coll := db.WebhooksDelivery()
+
+	err = coll.Insert(d)
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+func (d *Delivery) finish(db *database.Database, code int, e error) (
+	err error) {
+
+	coll := db.WebhooksDelivery()
+
+	d.ResponseCode = code
+	if e == nil {
+		d.State = Delivered
+		d.Error = ""
+	} else {
+		d.Error = e.Error()
+		if d.Attempts >= MaxAttempts {
+			d.State = Failed
+		} else {
+			d.NextAttempt = time.Now().Add(backoff(d.Attempts))
+		}
+	}
+
+	err = coll.UpdateId(d.Id, &bson.M{
+		"$set": &bson.M{
+			"state":         d.State,
+			"next_attempt":  d.NextAttempt,
+			"response_code": d.ResponseCode,
+			"error":         d.Error,
+		},
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		if _, ok := err.(*database.NotFoundError); ok {
+			err = nil
+		} else {
+			return
+		}
+	}
+
+	return
+}
+
+func backoff(attempts int) time.Duration {
+	delay := 30 * time.Second * time.Duration(1<<uint(attempts-1))
+	if delay > 6*time.Hour {
+		delay = 6 * time.Hour
+	}
+	return delay
+}
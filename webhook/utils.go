@@ -0,0 +1,342 @@
+package webhook
+
+import (
+	"encoding/json"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"time"
+)
+
+type payload struct {
+	Id           bson.ObjectId `json:"id"`
+	Event        string        `json:"event"`
+	Organization bson.ObjectId `json:"organization,omitempty"`
+	Timestamp    time.Time     `json:"timestamp"`
+	Data         interface{}   `json:"data"`
+}
+
+func Get(db *database.Database, hookId bson.ObjectId) (
+	hook *Webhook, err error) {
+
+	coll := db.Webhooks()
+	hook = &Webhook{}
+
+	err = coll.FindOneId(hookId, hook)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func GetOrg(db *database.Database, orgId, hookId bson.ObjectId) (
+	hook *Webhook, err error) {
+
+	coll := db.Webhooks()
+	hook = &Webhook{}
+
+	err = coll.FindOne(&bson.M{
+		"_id":          hookId,
+		"organization": orgId,
+	}, hook)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func GetAll(db *database.Database, query *bson.M) (
+	hooks []*Webhook, err error) {
+
+	coll := db.Webhooks()
+	hooks = []*Webhook{}
+
+	cursor := coll.Find(query).Iter()
+
+	hook := &Webhook{}
+	for cursor.Next(hook) {
+		hooks = append(hooks, hook)
+		hook = &Webhook{}
+	}
+
+	err = cursor.Close()
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+func GetAllPaged(db *database.Database, query *bson.M, page, pageCount int) (
+	hooks []*Webhook, count int, err error) {
+
+	coll := db.Webhooks()
+	hooks = []*Webhook{}
+
+	qury := coll.Find(query)
+
+	count, err = qury.Count()
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	skip := utils.Min(page*pageCount, utils.Max(0, count-pageCount))
+
+	cursor := qury.Sort("name").Skip(skip).Limit(pageCount).Iter()
+
+	hook := &Webhook{}
+	for cursor.Next(hook) {
+		hooks = append(hooks, hook)
+		hook = &Webhook{}
+	}
+
+	err = cursor.Close()
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+func GetDeliveries(db *database.Database, hookId bson.ObjectId) (
+	delivs []*Delivery, err error) {
+
+	coll := db.WebhooksDelivery()
+	delivs = []*Delivery{}
+
+	cursor := coll.Find(&bson.M{
+		"webhook": hookId,
+	}).Sort("-timestamp").Limit(100).Iter()
+
+	deliv := &Delivery{}
+	for cursor.Next(deliv) {
+		delivs = append(delivs, deliv)
+		deliv = &Delivery{}
+	}
+
+	err = cursor.Close()
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+func Remove(db *database.Database, hookId bson.ObjectId) (err error) {
+	coll := db.Webhooks()
+
+	err = coll.Remove(&bson.M{
+		"_id": hookId,
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		switch err.(type) {
+		case *database.NotFoundError:
+			err = nil
+		default:
+			return
+		}
+	}
+
+	return
+}
+
+func RemoveOrg(db *database.Database, orgId, hookId bson.ObjectId) (
+	err error) {
+
+	coll := db.Webhooks()
+
+	err = coll.Remove(&bson.M{
+		"_id":          hookId,
+		"organization": orgId,
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		switch err.(type) {
+		case *database.NotFoundError:
+			err = nil
+		default:
+			return
+		}
+	}
+
+	return
+}
+
+func RemoveMulti(db *database.Database, hookIds []bson.ObjectId) (err error) {
+	coll := db.Webhooks()
+
+	_, err = coll.RemoveAll(&bson.M{
+		"_id": &bson.M{
+			"$in": hookIds,
+		},
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+func RemoveMultiOrg(db *database.Database, orgId bson.ObjectId,
+	hookIds []bson.ObjectId) (err error) {
+
+	coll := db.Webhooks()
+
+	_, err = coll.RemoveAll(&bson.M{
+		"_id": &bson.M{
+			"$in": hookIds,
+		},
+		"organization": orgId,
+	})
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
+
+func Publish(db *database.Database, orgId bson.ObjectId, evt string,
+	data interface{}) (err error) {
+
+	scopes := []*bson.M{
+		&bson.M{
+			"organization": &bson.M{
+				"$exists": false,
+			},
+		},
+	}
+	if orgId != "" {
+		scopes = append(scopes, &bson.M{
+			"organization": orgId,
+		})
+	}
+
+	hooks, err := GetAll(db, &bson.M{
+		"events":   evt,
+		"disabled": false,
+		"$or":      scopes,
+	})
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+
+	for _, hook := range hooks {
+		deliv := &Delivery{
+			Id:           bson.NewObjectId(),
+			Webhook:      hook.Id,
+			Organization: hook.Organization,
+			Event:        evt,
+			State:        Pending,
+			Timestamp:    now,
+			NextAttempt:  now,
+		}
+
+		body, e := json.Marshal(&payload{
+			Id:           deliv.Id,
+			Event:        evt,
+			Organization: orgId,
+			Timestamp:    now,
+			Data:         data,
+		})
+		if e != nil {
+			err = &errortypes.ParseError{
+				errors.Wrap(e, "webhook: Failed to marshal payload"),
+			}
+			return
+		}
+		deliv.Payload = string(body)
+
+		err = deliv.Insert(db)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func claim(db *database.Database) (deliv *Delivery, err error) {
+	coll := db.WebhooksDelivery()
+	now := time.Now()
+
+	deliv = &Delivery{}
+	_, err = coll.Find(&bson.M{
+		"state": Pending,
+		"next_attempt": &bson.M{
+			"$lte": now,
+		},
+	}).Sort("next_attempt").Apply(mgo.Change{
+		Update: &bson.M{
+			"$set": &bson.M{
+				"next_attempt": now.Add(2 * time.Minute),
+			},
+			"$inc": &bson.M{
+				"attempts": 1,
+			},
+		},
+		ReturnNew: true,
+	}, deliv)
+	if err != nil {
+		err = database.ParseError(err)
+		if _, ok := err.(*database.NotFoundError); ok {
+			deliv = nil
+			err = nil
+		}
+		return
+	}
+
+	return
+}
+
+func Process(db *database.Database) (err error) {
+	for i := 0; i < 100; i++ {
+		deliv, e := claim(db)
+		if e != nil {
+			err = e
+			return
+		}
+
+		if deliv == nil {
+			return
+		}
+
+		hook, e := Get(db, deliv.Webhook)
+		if e != nil {
+			if _, ok := e.(*database.NotFoundError); !ok {
+				err = e
+				return
+			}
+
+			deliv.Attempts = MaxAttempts
+			err = deliv.finish(db, 0, &errortypes.NotFoundError{
+				errors.New("webhook: Webhook not found"),
+			})
+			if err != nil {
+				return
+			}
+			continue
+		}
+
+		code, e := deliv.send(hook)
+		err = deliv.finish(db, code, e)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
@@ -0,0 +1,111 @@
+package webhook
+
+import (
+	"github.com/dropbox/godropbox/container/set"
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-cloud/database"
+	"github.com/pritunl/pritunl-cloud/errortypes"
+	"github.com/pritunl/pritunl-cloud/utils"
+	"gopkg.in/mgo.v2/bson"
+	"net/url"
+)
+
+type Webhook struct {
+	Id           bson.ObjectId `bson:"_id,omitempty" json:"id"`
+	Name         string        `bson:"name" json:"name"`
+	Organization bson.ObjectId `bson:"organization,omitempty" json:"organization"`
+	Disabled     bool          `bson:"disabled" json:"disabled"`
+	Url          string        `bson:"url" json:"url"`
+	Secret       string        `bson:"secret" json:"secret"`
+	Events       []string      `bson:"events" json:"events"`
+}
+
+func (w *Webhook) Validate(db *database.Database) (
+	errData *errortypes.ErrorData, err error) {
+
+	u, e := url.Parse(w.Url)
+	if e != nil || (u.Scheme != "http" && u.Scheme != "https") ||
+		u.Host == "" {
+
+		errData = &errortypes.ErrorData{
+			Error:   "webhook_url_invalid",
+			Message: "Webhook URL is invalid",
+		}
+		return
+	}
+
+	if w.Events == nil {
+		w.Events = []string{}
+	}
+
+	events := []string{}
+	eventsSet := set.NewSet()
+	for _, evt := range w.Events {
+		if !Events.Contains(evt) {
+			errData = &errortypes.ErrorData{
+				Error:   "webhook_event_invalid",
+				Message: "Webhook event is invalid",
+			}
+			return
+		}
+
+		if eventsSet.Contains(evt) {
+			continue
+		}
+		eventsSet.Add(evt)
+		events = append(events, evt)
+	}
+	w.Events = events
+
+	if w.Secret == "" {
+		w.Secret, err = utils.RandStr(32)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (w *Webhook) Commit(db *database.Database) (err error) {
+	coll := db.Webhooks()
+
+	err = coll.Commit(w.Id, w)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (w *Webhook) CommitFields(db *database.Database, fields set.Set) (
+	err error) {
+
+	coll := db.Webhooks()
+
+	err = coll.CommitFields(w.Id, w, fields)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (w *Webhook) Insert(db *database.Database) (err error) {
+	coll := db.Webhooks()
+
+	if w.Id != "" {
+		err = &errortypes.DatabaseError{
+			errors.New("webhook: Webhook already exists"),
+		}
+		return
+	}
+
+	err = coll.Insert(w)
+	if err != nil {
+		err = database.ParseError(err)
+		return
+	}
+
+	return
+}
@@ -547,6 +547,17 @@ export default class InstanceNew extends React.Component<Props, State> {
 						>
 							{vpcsSelect}
 						</PageSelect>
+						<PageInput
+							disabled={this.state.disabled}
+							label="Static Private IP"
+							help="Optional static private IPv4 address in the VPC network. The address will be kept reserved if the instance is destroyed and can be reused by a new instance. Leave blank to assign an address automatically."
+							type="text"
+							placeholder="Automatic"
+							value={instance.static_ip}
+							onChange={(val): void => {
+								this.set('static_ip', val);
+							}}
+						/>
 						<PageSelect
 							disabled={this.state.disabled || !hasNodes}
 							label="Node"
@@ -3,6 +3,7 @@ import * as React from 'react';
 import * as OrganizationTypes from '../types/OrganizationTypes';
 import * as OrganizationActions from '../actions/OrganizationActions';
 import PageInput from './PageInput';
+import PageNumInput from './PageNumInput';
 import PageInfo from './PageInfo';
 import PageSave from './PageSave';
 import PageInputButton from './PageInputButton';
@@ -79,6 +80,33 @@ export default class Organization extends React.Component<Props, State> {
 		});
 	}
 
+	setQuota(name: string, val: number): void {
+		let organization: OrganizationTypes.Organization;
+
+		if (this.state.changed) {
+			organization = {
+				...this.state.organization,
+			};
+		} else {
+			organization = {
+				...this.props.organization,
+			};
+		}
+
+		let quota: any = {
+			...(organization.quota || {}),
+		};
+		quota[name] = val;
+
+		organization.quota = quota;
+
+		this.setState({
+			...this.state,
+			changed: true,
+			organization: organization,
+		});
+	}
+
 	onAddRole = (): void => {
 		let organization: OrganizationTypes.Organization;
 
@@ -206,6 +234,8 @@ export default class Organization extends React.Component<Props, State> {
 		let org: OrganizationTypes.Organization = this.state.organization ||
 			this.props.organization;
 
+		let quota = org.quota || {};
+
 		let roles: JSX.Element[] = [];
 		for (let role of (org.roles || [])) {
 			roles.push(
@@ -286,6 +316,90 @@ export default class Organization extends React.Component<Props, State> {
 							},
 						]}
 					/>
+					<PageNumInput
+						label="Instance Quota"
+						help="Maximum number of instances in organization, set to zero for unlimited."
+						min={0}
+						minorStepSize={1}
+						stepSize={1}
+						majorStepSize={10}
+						disabled={this.state.disabled}
+						selectAllOnFocus={true}
+						onChange={(val: number): void => {
+							this.setQuota('instances', val);
+						}}
+						value={quota.instances || 0}
+					/>
+					<PageNumInput
+						label="Processor Quota"
+						help="Maximum total number of processors for all instances in organization, set to zero for unlimited."
+						min={0}
+						minorStepSize={1}
+						stepSize={1}
+						majorStepSize={10}
+						disabled={this.state.disabled}
+						selectAllOnFocus={true}
+						onChange={(val: number): void => {
+							this.setQuota('processors', val);
+						}}
+						value={quota.processors || 0}
+					/>
+					<PageNumInput
+						label="Memory Quota"
+						help="Maximum total memory in megabytes for all instances in organization, set to zero for unlimited."
+						min={0}
+						minorStepSize={1}
+						stepSize={1}
+						majorStepSize={10}
+						disabled={this.state.disabled}
+						selectAllOnFocus={true}
+						onChange={(val: number): void => {
+							this.setQuota('memory', val);
+						}}
+						value={quota.memory || 0}
+					/>
+					<PageNumInput
+						label="Disk Quota"
+						help="Maximum total disk size in gigabytes for all disks in organization, set to zero for unlimited."
+						min={0}
+						minorStepSize={1}
+						stepSize={1}
+						majorStepSize={10}
+						disabled={this.state.disabled}
+						selectAllOnFocus={true}
+						onChange={(val: number): void => {
+							this.setQuota('disk_size', val);
+						}}
+						value={quota.disk_size || 0}
+					/>
+					<PageNumInput
+						label="Image Quota"
+						help="Maximum number of images and snapshots in organization, set to zero for unlimited."
+						min={0}
+						minorStepSize={1}
+						stepSize={1}
+						majorStepSize={10}
+						disabled={this.state.disabled}
+						selectAllOnFocus={true}
+						onChange={(val: number): void => {
+							this.setQuota('images', val);
+						}}
+						value={quota.images || 0}
+					/>
+					<PageNumInput
+						label="VPC Quota"
+						help="Maximum number of VPCs in organization, set to zero for unlimited."
+						min={0}
+						minorStepSize={1}
+						stepSize={1}
+						majorStepSize={10}
+						disabled={this.state.disabled}
+						selectAllOnFocus={true}
+						onChange={(val: number): void => {
+							this.setQuota('vpcs', val);
+						}}
+						value={quota.vpcs || 0}
+					/>
 				</div>
 			</div>
 			<PageSave
@@ -8,6 +8,8 @@ import OrganizationsStore from "../stores/OrganizationsStore";
 import VpcRoute from './VpcRoute';
 import VpcLinkUri from './VpcLinkUri';
 import PageInput from './PageInput';
+import PageSelect from './PageSelect';
+import PageNumInput from './PageNumInput';
 import PageInfo from './PageInfo';
 import PageSave from './PageSave';
 import ConfirmButton from './ConfirmButton';
@@ -540,6 +542,46 @@ export default class VpcDetailed extends React.Component<Props, State> {
 					<div style={css.list}>
 						{linkUris}
 					</div>
+					<PageSelect
+						disabled={this.state.disabled}
+						label="Link Authentication"
+						help="Authentication mode for IPsec links. Certificate mode uses IKEv2 certificates signed by a generated VPC certificate authority that are rotated automatically before expiration."
+						value={vpc.link_auth || 'psk'}
+						onChange={(val): void => {
+							this.set('link_auth', val);
+						}}
+					>
+						<option value="psk">Pre-Shared Key</option>
+						<option value="certificate">Certificate</option>
+					</PageSelect>
+					<PageNumInput
+						label="Link Nodes"
+						help="Number of nodes that will terminate links for this VPC at the same time. Each node is assigned a separate gateway address in the VPC network."
+						min={1}
+						max={8}
+						minorStepSize={1}
+						stepSize={1}
+						majorStepSize={1}
+						disabled={this.state.disabled}
+						selectAllOnFocus={true}
+						onChange={(val: number): void => {
+							this.set('link_node_count', val);
+						}}
+						value={vpc.link_node_count || 1}
+					/>
+					<PageSelect
+						disabled={this.state.disabled}
+						hidden={(vpc.link_node_count || 1) < 2}
+						label="Link Routing"
+						help="Routing mode used when multiple link nodes are active. Priority routing sends traffic to a single link node and fails over to the next node. ECMP routing balances traffic across all active link nodes."
+						value={vpc.link_routing || 'priority'}
+						onChange={(val): void => {
+							this.set('link_routing', val);
+						}}
+					>
+						<option value="priority">Priority</option>
+						<option value="ecmp">ECMP</option>
+					</PageSelect>
 				</div>
 				<div style={css.group}>
 					<PageInfo
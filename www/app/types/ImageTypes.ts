@@ -14,6 +14,7 @@ export interface Image {
 	key?: string;
 	type?: string;
 	etag?: string;
+	size?: number;
 	last_modified?: string;
 }
 
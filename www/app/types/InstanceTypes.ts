@@ -18,6 +18,7 @@ export interface Instance {
 	public_ips6?: string[];
 	private_ips?: string[];
 	private_ips6?: string[];
+	static_ip?: string;
 	name?: string;
 	init_disk_size?: number;
 	memory?: number;
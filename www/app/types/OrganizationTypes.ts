@@ -7,6 +7,16 @@ export interface Organization {
 	id: string;
 	name?: string;
 	roles?: string[];
+	quota?: Quota;
+}
+
+export interface Quota {
+	instances?: number;
+	processors?: number;
+	memory?: number;
+	disk_size?: number;
+	images?: number;
+	vpcs?: number;
 }
 
 export type Organizations = Organization[];
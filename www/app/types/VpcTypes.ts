@@ -14,11 +14,34 @@ export interface Vpc {
 	datacenter?: string;
 	routes?: Route[];
 	link_uris?: string[];
+	link_auth?: string;
+	link_cert?: string;
+	link_ca_cert?: string;
+	link_peers?: Peer[];
+	link_node_count?: number;
+	link_routing?: string;
 }
 
 export interface Route {
 	destination?: string;
 	target?: string;
+	link?: boolean;
+	node?: string;
+	priority?: number;
+}
+
+export interface Peer {
+	id?: string;
+	name?: string;
+	remote?: string;
+	remote_id?: string;
+	local_subnets?: string[];
+	remote_subnets?: string[];
+	ike_proposal?: string;
+	esp_proposal?: string;
+	auth?: string;
+	pre_shared_key?: string;
+	remote_ca?: string;
 }
 
 export interface Filter {
@@ -6,17 +6,33 @@ import (
 	"github.com/pritunl/pritunl-cloud/database"
 	"github.com/pritunl/pritunl-cloud/errortypes"
 	"gopkg.in/mgo.v2/bson"
+	"strings"
 )
 
 type Zone struct {
-	Id         bson.ObjectId `bson:"_id,omitempty" json:"id"`
-	Datacenter bson.ObjectId `bson:"datacenter,omitempty" json:"datacenter"`
-	Name       string        `bson:"name" json:"name"`
+	Id               bson.ObjectId   `bson:"_id,omitempty" json:"id"`
+	Datacenter       bson.ObjectId   `bson:"datacenter,omitempty" json:"datacenter"`
+	Name             string          `bson:"name" json:"name"`
+	PrefetchImages   []bson.ObjectId `bson:"prefetch_images" json:"prefetch_images"`
+	PrefetchFamilies []string        `bson:"prefetch_families" json:"prefetch_families"`
 }
 
 func (z *Zone) Validate(db *database.Database) (
 	errData *errortypes.ErrorData, err error) {
 
+	if z.PrefetchImages == nil {
+		z.PrefetchImages = []bson.ObjectId{}
+	}
+
+	families := []string{}
+	for _, family := range z.PrefetchFamilies {
+		family = strings.ToLower(strings.TrimSpace(family))
+		if family != "" {
+			families = append(families, family)
+		}
+	}
+	z.PrefetchFamilies = families
+
 	if z.Datacenter == "" {
 		errData = &errortypes.ErrorData{
 			Error:   "datacenter_required",